@@ -1,7 +1,9 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"time"
 
 	"github.com/joho/godotenv"
 
@@ -17,6 +19,12 @@ import (
 // @name Authorization
 
 func main() {
+	gcGuests := flag.Bool("gc-guests", false, "remove abandoned guest accounts once and exit")
+	dryRun := flag.Bool("dry-run", false, "with -gc-guests, only report what would be removed")
+	rebuildRollups := flag.Bool("rebuild-rollups", false, "recompute the daily click rollups from the clicks once and exit")
+	since := flag.String("since", "", "with -rebuild-rollups, first day to rebuild as YYYY-MM-DD (UTC), defaults to the first click")
+	flag.Parse()
+
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
@@ -31,6 +39,31 @@ func main() {
 		log.Fatalf("Failed to initialize app: %v", err)
 	}
 
+	if *gcGuests {
+		report, err := application.CollectGuests(*dryRun)
+		if err != nil {
+			log.Fatalf("Guest GC failed: %v", err)
+		}
+		log.Printf("Guest GC: %d guests, %d anonymous guests, %d links (dry run: %t)",
+			report.Guests, report.Anonymous, report.Links, report.DryRun)
+		return
+	}
+
+	if *rebuildRollups {
+		var from time.Time
+		if *since != "" {
+			if from, err = time.Parse(time.DateOnly, *since); err != nil {
+				log.Fatalf("Invalid -since: %v", err)
+			}
+		}
+		days, err := application.RebuildRollups(from)
+		if err != nil {
+			log.Fatalf("Rollup rebuild failed after %d days: %v", days, err)
+		}
+		log.Printf("Rollup rebuild: %d days", days)
+		return
+	}
+
 	// Run server
 	if err := application.Run(); err != nil {
 		log.Fatalf("Server error: %v", err)
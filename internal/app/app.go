@@ -2,7 +2,10 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -21,6 +24,7 @@ import (
 	"quocbui.dev/m/internal/repository"
 	"quocbui.dev/m/internal/repository/postgres"
 	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/pkg/utils"
 )
 
 type App struct {
@@ -29,20 +33,39 @@ type App struct {
 	Router *gin.Engine
 	Server *http.Server
 
-	UserRepo  repository.UserRepository
-	LinkRepo  repository.LinkRepository
-	ClickRepo repository.ClickRepository
-	TxManager repository.TransactionManager
+	// MetricsServer serves internal metrics on a separate listener, nil if disabled
+	MetricsServer *http.Server
+
+	// stopJobs stops the background jobs started by Run
+	stopJobs context.CancelFunc
+
+	UserRepo     repository.UserRepository
+	LinkRepo     repository.LinkRepository
+	ClickRepo    repository.ClickRepository
+	DomainRepo   repository.DomainRepository
+	TransferRepo repository.LinkTransferRepository
+	QRStyleRepo  repository.QRStyleRepository
+	SaltRepo     repository.VisitorSaltRepository
+	RollupRepo   repository.ClickRollupRepository
+	TxManager    repository.TransactionManager
+
+	ReservedWords *utils.ReservedWords
 
 	AuthService      *service.AuthService
 	LinkService      *service.LinkService
 	AnalyticsService *service.AnalyticsService
 	GeoIPService     *service.GeoIPService
 	QRService        *service.QRService
-
-	AuthHandler *handlers.AuthHandler
-	UserHandler *handlers.UserHandler
-	LinkHandler *handlers.LinkHandler
+	DomainService    *service.DomainService
+	TransferService  *service.TransferService
+	GuestService     *service.GuestService
+
+	AuthHandler     *handlers.AuthHandler
+	UserHandler     *handlers.UserHandler
+	LinkHandler     *handlers.LinkHandler
+	DomainHandler   *handlers.DomainHandler
+	TransferHandler *handlers.TransferHandler
+	QRHandler       *handlers.QRHandler
 }
 
 func New(cfg *config.Config) (*App, error) {
@@ -53,8 +76,12 @@ func New(cfg *config.Config) (*App, error) {
 	}
 
 	app.initRepositories()
-	app.initServices()
-	app.initHandlers()
+	if err := app.initServices(); err != nil {
+		return nil, err
+	}
+	if err := app.initHandlers(); err != nil {
+		return nil, err
+	}
 	app.initRouter()
 	app.initServer()
 
@@ -74,27 +101,112 @@ func (a *App) initRepositories() {
 	a.UserRepo = postgres.NewUserRepository(a.DB)
 	a.LinkRepo = postgres.NewLinkRepository(a.DB)
 	a.ClickRepo = postgres.NewClickRepository(a.DB)
+	a.DomainRepo = postgres.NewDomainRepository(a.DB)
+	a.TransferRepo = postgres.NewLinkTransferRepository(a.DB)
+	a.QRStyleRepo = postgres.NewQRStyleRepository(a.DB)
+	a.SaltRepo = postgres.NewVisitorSaltRepository(a.DB)
+	a.RollupRepo = postgres.NewClickRollupRepository(a.DB)
 	a.TxManager = postgres.NewTransactionManager(a.DB)
 }
 
-func (a *App) initServices() {
+func (a *App) initServices() error {
+	reputation, err := a.newReputationChecker()
+	if err != nil {
+		return err
+	}
+
+	shortCode := a.Config.ShortCode
+	codeGenerator, err := service.NewCodeGenerator(shortCode.Strategy, shortCode.Alphabet, shortCode.Salt, a.LinkRepo)
+	if err != nil {
+		return err
+	}
+
+	a.ReservedWords = utils.NewReservedWords(shortCode.Reserved, shortCode.BlockedWords)
+
 	a.GeoIPService = service.NewGeoIPService()
-	a.QRService = service.NewQRService("assets/logo.png")
+	qrCache, err := service.NewQRCache(a.Config.QR.CacheSizeMB<<20, a.Config.QR.CacheDir, a.Config.QR.CacheDiskSizeMB<<20)
+	if err != nil {
+		return err
+	}
+	a.QRService = service.NewQRService("assets/logo.png", a.QRStyleRepo, qrCache)
 	a.AuthService = service.NewAuthService(a.UserRepo, a.Config.JWT.Secret, a.Config.JWT.ExpiryHours)
-	a.LinkService = service.NewLinkService(a.LinkRepo, a.ClickRepo, a.TxManager, a.GeoIPService, a.AuthService)
-	a.AnalyticsService = service.NewAnalyticsService(a.ClickRepo, a.LinkRepo)
+	a.GuestService = service.NewGuestService(a.UserRepo, a.LinkRepo, a.TxManager, a.AuthService)
+	a.DomainService = service.NewDomainService(a.DomainRepo, map[string]service.DomainVerifier{
+		service.DomainVerificationDNS:  service.NewDNSDomainVerifier(net.DefaultResolver),
+		service.DomainVerificationHTTP: service.NewHTTPDomainVerifier("http"),
+	}, a.Config.App.Domains())
+	a.LinkService = service.NewLinkService(
+		a.LinkRepo,
+		a.ClickRepo,
+		a.TxManager,
+		a.GeoIPService,
+		a.AuthService,
+		reputation,
+		service.LinkServiceConfig{
+			OwnDomains:                a.Config.App.Domains(),
+			ShortenerDomains:          a.Config.App.ShortenerDomains,
+			ReputationCheckOnRedirect: a.Config.Reputation.CheckOnRedirect,
+			Reserved:                  a.ReservedWords,
+			CodeGenerator:             codeGenerator,
+			CustomDomains:             a.DomainService,
+			Visitors:                  service.NewVisitorHasher(a.SaltRepo),
+			Rollups:                   a.RollupRepo,
+		},
+	)
+	a.TransferService = service.NewTransferService(a.TransferRepo, a.LinkRepo, a.UserRepo, a.TxManager, a.LinkService)
+	a.AnalyticsService = service.NewAnalyticsService(a.ClickRepo, a.RollupRepo, a.LinkRepo)
+	return nil
 }
 
-func (a *App) initHandlers() {
-	a.AuthHandler = handlers.NewAuthHandler(a.AuthService)
+// newReputationChecker builds the URL reputation checker selected by config
+func (a *App) newReputationChecker() (service.URLReputationChecker, error) {
+	cfg := a.Config.Reputation
+
+	var checker service.URLReputationChecker
+	switch cfg.Provider {
+	case "", "none":
+		return nil, nil
+	case "file":
+		fileChecker, err := service.NewFileReputationChecker(cfg.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load reputation file: %w", err)
+		}
+		checker = fileChecker
+	case "http":
+		if cfg.Endpoint == "" {
+			return nil, fmt.Errorf("REPUTATION_ENDPOINT is required for the http reputation provider")
+		}
+		checker = service.NewHTTPReputationChecker(cfg.Endpoint, cfg.APIKey)
+	default:
+		return nil, fmt.Errorf("unknown reputation provider %q", cfg.Provider)
+	}
+
+	if cfg.CacheTTL > 0 {
+		checker = service.NewCachedReputationChecker(checker, time.Duration(cfg.CacheTTL)*time.Second)
+	}
+	return checker, nil
+}
+
+func (a *App) initHandlers() error {
+	pages, err := handlers.NewPages(a.Config.App.PagesDir)
+	if err != nil {
+		return fmt.Errorf("failed to load pages: %w", err)
+	}
+
+	a.AuthHandler = handlers.NewAuthHandler(a.AuthService, a.GuestService)
 	a.UserHandler = handlers.NewUserHandler(a.UserRepo)
 	a.LinkHandler = handlers.NewLinkHandler(
 		a.LinkService,
 		a.AnalyticsService,
 		a.QRService,
+		pages,
 		a.Config.App.Domain,
 		a.Config.ShortCode.Length,
 	)
+	a.DomainHandler = handlers.NewDomainHandler(a.DomainService)
+	a.TransferHandler = handlers.NewTransferHandler(a.TransferService, a.Config.App.Domain)
+	a.QRHandler = handlers.NewQRHandler(a.QRService)
+	return nil
 }
 
 func (a *App) initRouter() {
@@ -114,9 +226,26 @@ func (a *App) initRouter() {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	a.registerRoutes(r)
+
+	// Short codes share the root path with every other route
+	for _, route := range r.Routes() {
+		a.ReservedWords.ReserveRoutes(route.Path)
+	}
+	// Kept reserved so the metrics can move back to the public listener
+	a.ReservedWords.ReserveRoutes("/metrics")
 	a.Router = r
 }
 
+// metricsHandler serves internal stats, which must not be reachable publicly
+func (a *App) metricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"short_codes": a.LinkService.CollisionStats()})
+	})
+	return mux
+}
+
 func (a *App) registerRoutes(r *gin.Engine) {
 	api := r.Group("/api/v1")
 	{
@@ -128,15 +257,49 @@ func (a *App) registerRoutes(r *gin.Engine) {
 	}
 
 	protected := r.Group("/api/v1/me")
-	protected.Use(middleware.AuthMiddleware(a.Config.JWT.Secret))
+	protected.Use(middleware.AuthMiddleware(a.Config.JWT.Secret, a.GuestService))
 	{
 		protected.GET("", a.UserHandler.GetMe)
 		protected.GET("/links", a.LinkHandler.GetMyLinks)
 		protected.GET("/links/:code", a.LinkHandler.GetMyLinkDetail)
+		protected.GET("/links/:code/qr", a.LinkHandler.GetMyLinkQRCode)
+		protected.GET("/links/:code/analytics/timeseries", a.LinkHandler.GetMyLinkTimeSeries)
+		protected.GET("/links/:code/analytics/bots", a.LinkHandler.GetMyLinkBotTraffic)
+		protected.GET("/links/:code/clicks", a.LinkHandler.GetMyLinkClicks)
+		protected.GET("/links/:code/clicks/export", a.LinkHandler.ExportMyLinkClicks)
+		protected.GET("/clicks/export", a.LinkHandler.ExportMyClicks)
+		protected.GET("/analytics", a.LinkHandler.GetMyAnalytics)
+		protected.PATCH("/links/:code", a.LinkHandler.UpdateMyLink)
 		protected.DELETE("/links/:code", a.LinkHandler.DeleteMyLink)
+		protected.GET("/links/:code/vcard", a.LinkHandler.GetMyVCard)
+		protected.PUT("/links/:code/vcard", a.LinkHandler.UpdateMyVCard)
+		protected.POST("/vcards", a.LinkHandler.CreateVCard)
+
+		protected.GET("/domains", a.DomainHandler.GetMyDomains)
+		protected.POST("/domains", a.DomainHandler.CreateDomain)
+		protected.POST("/domains/:id/verify", a.DomainHandler.VerifyDomain)
+		protected.DELETE("/domains/:id", a.DomainHandler.DeleteMyDomain)
+
+		protected.GET("/transfers", a.TransferHandler.GetMyTransfers)
+		protected.POST("/transfers", a.TransferHandler.CreateTransfer)
+		protected.POST("/transfers/:id/accept", a.TransferHandler.AcceptTransfer)
+		protected.POST("/transfers/:id/decline", a.TransferHandler.DeclineTransfer)
+		protected.DELETE("/transfers/:id", a.TransferHandler.CancelTransfer)
+
+		protected.GET("/qr/styles", a.QRHandler.GetMyQRStyles)
+		protected.POST("/qr/styles", a.QRHandler.CreateQRStyle)
+		protected.PUT("/qr/styles/:id", a.QRHandler.UpdateQRStyle)
+		protected.DELETE("/qr/styles/:id", a.QRHandler.DeleteQRStyle)
+		protected.GET("/qr/logo", a.QRHandler.GetMyQRLogo)
+		protected.PUT("/qr/logo", a.QRHandler.UploadQRLogo)
+		protected.DELETE("/qr/logo", a.QRHandler.DeleteQRLogo)
+		protected.POST("/qr/payloads", a.QRHandler.RenderPayload)
+		protected.POST("/qr/exports", a.LinkHandler.ExportMyQRCodes)
 	}
 
 	r.GET("/:code", a.LinkHandler.Redirect)
+	r.GET("/:code/qr", a.LinkHandler.GetQRCode)
+	r.GET("/:code/vcard", a.LinkHandler.DownloadVCard)
 }
 
 func (a *App) initServer() {
@@ -147,9 +310,33 @@ func (a *App) initServer() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+
+	if a.Config.App.MetricsAddr != "" {
+		a.MetricsServer = &http.Server{
+			Addr:         a.Config.App.MetricsAddr,
+			Handler:      a.metricsHandler(),
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+		}
+	}
 }
 
 func (a *App) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopJobs = cancel
+	if a.Config.GuestGC.Enabled {
+		go a.runGuestGC(ctx)
+	}
+
+	if a.MetricsServer != nil {
+		go func() {
+			log.Printf("Metrics server starting on %s", a.MetricsServer.Addr)
+			if err := a.MetricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Metrics server error: %v", err)
+			}
+		}()
+	}
+
 	go func() {
 		log.Printf("Server starting on %s:%s", a.Config.App.Host, a.Config.App.Port)
 		if err := a.Server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -166,9 +353,16 @@ func (a *App) Run() error {
 }
 
 func (a *App) Shutdown() error {
+	if a.stopJobs != nil {
+		a.stopJobs()
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if a.MetricsServer != nil {
+		a.MetricsServer.Shutdown(ctx)
+	}
 	if err := a.Server.Shutdown(ctx); err != nil {
 		return err
 	}
@@ -180,3 +374,42 @@ func (a *App) Shutdown() error {
 	log.Println("Server stopped")
 	return nil
 }
+
+// CollectGuests runs one guest account cleanup with the configured policy
+func (a *App) CollectGuests(dryRun bool) (*service.GuestGCReport, error) {
+	cfg := a.Config.GuestGC
+	return a.GuestService.CollectGarbage(service.GuestGCPolicy{
+		TokenLifetime: time.Duration(a.Config.JWT.ExpiryHours) * time.Hour,
+		InactiveFor:   time.Duration(cfg.InactiveDays) * 24 * time.Hour,
+		BatchSize:     cfg.BatchSize,
+		DryRun:        dryRun,
+	})
+}
+
+// RebuildRollups recomputes the daily click rollups from the day of since, or
+// from the first click if since is zero, and returns the number of days rebuilt
+func (a *App) RebuildRollups(since time.Time) (int, error) {
+	return a.AnalyticsService.RebuildRollups(since)
+}
+
+// runGuestGC cleans up abandoned guest accounts until ctx is canceled
+func (a *App) runGuestGC(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(max(a.Config.GuestGC.Interval, 1)) * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		report, err := a.CollectGuests(a.Config.GuestGC.DryRun)
+		if err != nil {
+			log.Printf("Guest GC failed: %v", err)
+		} else {
+			log.Printf("Guest GC: %d guests, %d anonymous guests, %d links (dry run: %t)",
+				report.Guests, report.Anonymous, report.Links, report.DryRun)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
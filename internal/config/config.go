@@ -3,23 +3,33 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+
+	"quocbui.dev/m/pkg/utils"
 )
 
 type Config struct {
-	Env       string // development, staging, production
-	App       AppConfig
-	DB        DBConfig
-	JWT       JWTConfig
-	ShortCode ShortCodeConfig
-	RateLimit RateLimitConfig
-	Redis     RedisConfig
+	Env        string // development, staging, production
+	App        AppConfig
+	DB         DBConfig
+	JWT        JWTConfig
+	ShortCode  ShortCodeConfig
+	RateLimit  RateLimitConfig
+	Redis      RedisConfig
+	Reputation ReputationConfig
+	GuestGC    GuestGCConfig
+	QR         QRConfig
 }
 
 type AppConfig struct {
-	Host   string
-	Port   string
-	Domain string
-	Debug  bool
+	Host             string
+	Port             string
+	Domain           string
+	AliasDomains     []string // other domains that also serve our short links
+	ShortenerDomains []string // third-party shorteners that links may not point to
+	PagesDir         string   // directory with HTML page overrides, empty for built-in pages
+	MetricsAddr      string   // internal listener for /metrics, empty to disable
+	Debug            bool
 }
 
 type RedisConfig struct {
@@ -44,8 +54,12 @@ type JWTConfig struct {
 }
 
 type ShortCodeConfig struct {
-	Length   int
-	Alphabet string
+	Length       int
+	Alphabet     string
+	Strategy     string   // random, sequential, hashids, words
+	Salt         string   // salt for the hashids strategy
+	Reserved     []string // codes that can never be claimed, in addition to route names
+	BlockedWords []string // words that may not appear anywhere in a code
 }
 
 type RateLimitConfig struct {
@@ -53,16 +67,43 @@ type RateLimitConfig struct {
 	Window   int
 }
 
+type ReputationConfig struct {
+	Provider        string // none, file, http
+	FilePath        string
+	Endpoint        string
+	APIKey          string
+	CacheTTL        int // seconds
+	CheckOnRedirect bool
+}
+
+type GuestGCConfig struct {
+	Enabled      bool
+	Interval     int  // hours between runs
+	InactiveDays int  // guests with clicks in this window are kept
+	BatchSize    int  // guests removed per run
+	DryRun       bool // only log what would be removed
+}
+
+type QRConfig struct {
+	CacheSizeMB     int    // memory for rendered QR images
+	CacheDir        string // optional directory to keep rendered images across restarts
+	CacheDiskSizeMB int    // disk space for rendered QR images in CacheDir
+}
+
 func Load() *Config {
 	env := getEnv("APP_ENV", "development")
 
 	return &Config{
 		Env: env,
 		App: AppConfig{
-			Host:   getEnv("APP_HOST", "0.0.0.0"),
-			Port:   getEnv("APP_PORT", "8080"),
-			Domain: getEnv("APP_DOMAIN", "localhost:8080"),
-			Debug:  env != "production",
+			Host:             getEnv("APP_HOST", "0.0.0.0"),
+			Port:             getEnv("APP_PORT", "8080"),
+			Domain:           getEnv("APP_DOMAIN", "localhost:8080"),
+			AliasDomains:     getEnvList("APP_ALIAS_DOMAINS", nil),
+			ShortenerDomains: getEnvList("SHORTENER_DOMAINS", defaultShortenerDomains),
+			PagesDir:         getEnv("APP_PAGES_DIR", ""),
+			MetricsAddr:      getEnv("APP_METRICS_ADDR", "127.0.0.1:9090"),
+			Debug:            env != "production",
 		},
 		DB: DBConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -76,8 +117,12 @@ func Load() *Config {
 			ExpiryHours: getEnvInt("JWT_EXPIRY_HOURS", 24),
 		},
 		ShortCode: ShortCodeConfig{
-			Length:   getEnvInt("SHORT_CODE_LENGTH", 6),
-			Alphabet: getEnv("SHORT_CODE_ALPHABET", "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"),
+			Length:       getEnvInt("SHORT_CODE_LENGTH", 6),
+			Alphabet:     getEnv("SHORT_CODE_ALPHABET", utils.DefaultAlphabet),
+			Strategy:     getEnv("SHORT_CODE_STRATEGY", "random"),
+			Salt:         getEnv("SHORT_CODE_SALT", ""),
+			Reserved:     getEnvList("SHORT_CODE_RESERVED", defaultReservedCodes),
+			BlockedWords: getEnvList("SHORT_CODE_BLOCKED_WORDS", defaultBlockedWords),
 		},
 		RateLimit: RateLimitConfig{
 			Requests: getEnvInt("RATE_LIMIT_REQUESTS", 100),
@@ -90,6 +135,26 @@ func Load() *Config {
 			DB:       getEnvInt("REDIS_DB", 0),
 			Enabled:  getEnvBool("REDIS_ENABLED", false),
 		},
+		Reputation: ReputationConfig{
+			Provider:        getEnv("REPUTATION_PROVIDER", "none"),
+			FilePath:        getEnv("REPUTATION_FILE", "assets/reputation.txt"),
+			Endpoint:        getEnv("REPUTATION_ENDPOINT", ""),
+			APIKey:          getEnv("REPUTATION_API_KEY", ""),
+			CacheTTL:        getEnvInt("REPUTATION_CACHE_TTL", 3600),
+			CheckOnRedirect: getEnvBool("REPUTATION_CHECK_ON_REDIRECT", false),
+		},
+		GuestGC: GuestGCConfig{
+			Enabled:      getEnvBool("GUEST_GC_ENABLED", false),
+			Interval:     getEnvInt("GUEST_GC_INTERVAL", 24),
+			InactiveDays: getEnvInt("GUEST_GC_INACTIVE_DAYS", 30),
+			BatchSize:    getEnvInt("GUEST_GC_BATCH_SIZE", 500),
+			DryRun:       getEnvBool("GUEST_GC_DRY_RUN", true),
+		},
+		QR: QRConfig{
+			CacheSizeMB:     getEnvInt("QR_CACHE_SIZE_MB", 64),
+			CacheDir:        getEnv("QR_CACHE_DIR", ""),
+			CacheDiskSizeMB: getEnvInt("QR_CACHE_DISK_SIZE_MB", 512),
+		},
 	}
 }
 
@@ -103,6 +168,24 @@ func (c *Config) IsProduction() bool {
 	return c.Env == "production"
 }
 
+var defaultShortenerDomains = []string{
+	"bit.ly", "tinyurl.com", "goo.gl", "ow.ly", "is.gd", "buff.ly",
+	"rebrand.ly", "cutt.ly", "shorturl.at", "t.ly", "rb.gy", "tiny.cc",
+}
+
+var defaultReservedCodes = []string{
+	"admin", "assets", "static", "login", "logout", "register", "me", "www",
+}
+
+var defaultBlockedWords = []string{
+	"fuck", "shit", "cunt", "bitch", "dick", "cock", "pussy", "slut", "whore", "nazi", "porn",
+}
+
+// Domains returns the primary domain followed by all alias domains
+func (c *AppConfig) Domains() []string {
+	return append([]string{c.Domain}, c.AliasDomains...)
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -119,6 +202,21 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
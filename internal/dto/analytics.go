@@ -4,6 +4,8 @@ import "time"
 
 // AnalyticsSummary contains aggregated analytics data
 type AnalyticsSummary struct {
+	From           *time.Time       `json:"from,omitempty"` // range of the counted clicks, open if omitted
+	To             *time.Time       `json:"to,omitempty"`
 	TotalClicks    int64            `json:"total_clicks"`
 	Browsers       map[string]int64 `json:"browsers,omitempty"`
 	OS             map[string]int64 `json:"os,omitempty"`
@@ -11,6 +13,51 @@ type AnalyticsSummary struct {
 	Countries      map[string]int64 `json:"countries,omitempty"`
 	RefererSources map[string]int64 `json:"referer_sources,omitempty"` // Facebook, Google, Direct...
 	RefererDomains map[string]int64 `json:"referer_domains,omitempty"` // Chi tiết domain
+	Channels       map[string]int64 `json:"channels,omitempty"`        // qr, direct
+	Visitors       *UniqueVisitors  `json:"visitors,omitempty"`
+}
+
+// UniqueVisitors counts distinct visitors instead of clicks. Visitor IDs rotate
+// daily, so someone returning on another day is counted again.
+type UniqueVisitors struct {
+	Total          int64            `json:"total"`
+	Approximate    bool             `json:"approximate"` // HyperLogLog estimate for very large links
+	Browsers       map[string]int64 `json:"browsers,omitempty"`
+	OS             map[string]int64 `json:"os,omitempty"`
+	Devices        map[string]int64 `json:"devices,omitempty"`
+	Countries      map[string]int64 `json:"countries,omitempty"`
+	RefererSources map[string]int64 `json:"referer_sources,omitempty"`
+	Channels       map[string]int64 `json:"channels,omitempty"`
+}
+
+// TimeSeriesPoint is the number of clicks and unique visitors in the bucket starting at Time
+type TimeSeriesPoint struct {
+	Time     time.Time `json:"time"`
+	Clicks   int64     `json:"clicks"`
+	Visitors int64     `json:"visitors"`
+}
+
+// TimeSeriesResponse represents clicks over time in zero-filled buckets
+type TimeSeriesResponse struct {
+	Interval string            `json:"interval"` // hour, day, week or month
+	Timezone string            `json:"timezone"`
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Total    int64             `json:"total"`
+	Points   []TimeSeriesPoint `json:"points"`
+}
+
+// TopLinkResponse is one of the most clicked links of an account
+type TopLinkResponse struct {
+	Link   LinkResponse `json:"link"`
+	Clicks int64        `json:"clicks"` // in the overview range, without bots
+}
+
+// AccountAnalyticsResponse summarizes the clicks of all of a user's links
+type AccountAnalyticsResponse struct {
+	Summary  *AnalyticsSummary   `json:"summary"`
+	Series   *TimeSeriesResponse `json:"series"`
+	TopLinks []TopLinkResponse   `json:"top_links"`
 }
 
 // ClickResponse represents a single click event
@@ -25,12 +72,14 @@ type ClickResponse struct {
 	CountryCode string    `json:"country_code"`
 	City        string    `json:"city"`
 	Referer     string    `json:"referer"`
+	Channel     string    `json:"channel"`
+	IsBot       bool      `json:"is_bot"`
 	ClickedAt   time.Time `json:"clicked_at"`
 }
 
 // AnalyticsResponse represents analytics data for a link
 type AnalyticsResponse struct {
-	Summary *AnalyticsSummary `json:"summary"`
+	Summary *AnalyticsSummary `json:"summary,omitempty"`
 	Clicks  []ClickResponse   `json:"clicks,omitempty"`
 	Total   int64             `json:"total"`
 	Page    int               `json:"page"`
@@ -7,19 +7,31 @@ type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email" example:"user@gmail.com"`
 	Password string `json:"password" binding:"required" example:"123"`
 	Name     string `json:"name" binding:"required" example:"user"`
+	// GuestToken claims the links created anonymously with this token
+	GuestToken string `json:"guest_token,omitempty"`
 }
 
 // LoginRequest represents a user login request
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email" example:"user@gmail.com"`
 	Password string `json:"password" binding:"required" example:"123"`
+	// GuestToken claims the links created anonymously with this token
+	GuestToken string `json:"guest_token,omitempty"`
+}
+
+// RegisterResponse represents a newly registered user
+type RegisterResponse struct {
+	UserResponse
+	ClaimedLinks int64  `json:"claimed_links,omitempty"` // guest links moved to the account
+	Warning      string `json:"warning,omitempty"`       // set if the guest links could not be claimed
 }
 
 // LoginResponse represents a successful login response
 type LoginResponse struct {
-	Token     string       `json:"token"`
-	ExpiresAt time.Time    `json:"expires_at"`
-	User      UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+	User         UserResponse `json:"user"`
+	ClaimedLinks int64        `json:"claimed_links,omitempty"` // guest links moved to the account
 }
 
 // UserResponse represents user data in responses
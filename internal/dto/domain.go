@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// CreateDomainRequest represents a request to add a branded domain
+type CreateDomainRequest struct {
+	Host   string `json:"host" binding:"required" example:"go.example.com"`
+	Method string `json:"method,omitempty" binding:"omitempty,oneof=dns http" example:"dns"`
+}
+
+// DomainVerification explains how to prove ownership of a domain
+type DomainVerification struct {
+	Method      string `json:"method"`                 // dns, http
+	Token       string `json:"token"`                  // value to publish
+	RecordName  string `json:"record_name,omitempty"`  // TXT record for dns
+	RecordValue string `json:"record_value,omitempty"` // TXT value for dns
+	URL         string `json:"url,omitempty"`          // file to serve for http
+}
+
+// DomainResponse represents a branded domain in API responses
+type DomainResponse struct {
+	ID           uint                `json:"id"`
+	Host         string              `json:"host"`
+	Verified     bool                `json:"verified"`
+	VerifiedAt   *time.Time          `json:"verified_at,omitempty"`
+	Verification *DomainVerification `json:"verification,omitempty"` // only while unverified
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+// ListDomainsResponse represents the domains of a user
+type ListDomainsResponse struct {
+	Domains []DomainResponse `json:"domains"`
+}
@@ -6,17 +6,29 @@ import "time"
 type CreateLinkRequest struct {
 	URL       string  `json:"url" binding:"required,url" example:"https://github.com"`
 	Alias     *string `json:"alias,omitempty" example:"my-link"`
+	Domain    string  `json:"domain,omitempty" example:"go.example.com"` // verified branded domain, empty for the shared one
 	ExpiresIn *int    `json:"expires_in,omitempty" example:"24"`
 }
 
+// UpdateLinkRequest represents a request to change link settings; omitted fields are unchanged
+type UpdateLinkRequest struct {
+	Paused      *bool   `json:"paused,omitempty" example:"true"`
+	FallbackURL *string `json:"fallback_url,omitempty" example:"https://example.com/expired"` // empty string removes it
+}
+
 // LinkResponse represents a link in API responses
 type LinkResponse struct {
 	ID          uint       `json:"id"`
 	ShortCode   string     `json:"short_code"`
 	ShortURL    string     `json:"short_url"`
-	OriginalURL string     `json:"original_url"`
+	Domain      string     `json:"domain"`
+	Type        string     `json:"type"`         // url, vcard
+	OriginalURL string     `json:"original_url"` // empty for vcard links
 	ClickCount  int64      `json:"click_count"`
-	QRCode      string     `json:"qr_code,omitempty"` // base64 encoded PNG
+	QRCode      string     `json:"qr_code,omitempty"` // base64 encoded PNG, in lists only with include=qr
+	QRCodeURL   string     `json:"qr_code_url"`       // image endpoint for loading the QR code lazily
+	Paused      bool       `json:"paused"`
+	FallbackURL *string    `json:"fallback_url,omitempty"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 }
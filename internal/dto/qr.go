@@ -0,0 +1,72 @@
+package dto
+
+import "time"
+
+// QRStyleRequest represents a request to create or replace a QR style.
+// Omitted colors, shape and error correction take their defaults.
+type QRStyleRequest struct {
+	Name            string `json:"name" binding:"required,max=64" example:"Brand"`
+	Foreground      string `json:"foreground,omitempty" example:"#1a237e"`
+	Background      string `json:"background,omitempty" example:"#ffffff"`
+	Shape           string `json:"shape,omitempty" binding:"omitempty,oneof=square circle" example:"circle"`
+	ErrorCorrection string `json:"error_correction,omitempty" binding:"omitempty,oneof=L M Q H" example:"H"`
+	WithLogo        bool   `json:"with_logo"`
+	IsDefault       bool   `json:"is_default"`
+}
+
+// QRStyleResponse represents a QR style in API responses
+type QRStyleResponse struct {
+	ID              uint      `json:"id"`
+	Name            string    `json:"name"`
+	Foreground      string    `json:"foreground"`
+	Background      string    `json:"background"`
+	Shape           string    `json:"shape"`
+	ErrorCorrection string    `json:"error_correction"`
+	WithLogo        bool      `json:"with_logo"`
+	IsDefault       bool      `json:"is_default"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ListQRStylesResponse represents the QR styles of a user
+type ListQRStylesResponse struct {
+	Styles []QRStyleResponse `json:"styles"`
+}
+
+// WiFiPayloadRequest is a network phones join on scan
+type WiFiPayloadRequest struct {
+	SSID     string `json:"ssid" binding:"required" example:"Conference"`
+	Password string `json:"password,omitempty" example:"welcome2024"`
+	Security string `json:"security,omitempty" binding:"omitempty,oneof=WPA WEP nopass" example:"WPA"` // defaults to WPA
+	Hidden   bool   `json:"hidden"`
+}
+
+// EmailPayloadRequest is a pre-filled email
+type EmailPayloadRequest struct {
+	To      string `json:"to" binding:"required" example:"events@example.com"`
+	Subject string `json:"subject,omitempty" example:"Registration"`
+	Body    string `json:"body,omitempty"`
+}
+
+// SMSPayloadRequest is a pre-filled text message
+type SMSPayloadRequest struct {
+	Phone   string `json:"phone" binding:"required" example:"+84901234567"`
+	Message string `json:"message,omitempty" example:"JOIN"`
+}
+
+// QRPayloadRequest represents non-URL content to encode; the object matching type is required
+type QRPayloadRequest struct {
+	Type  string               `json:"type" binding:"required,oneof=wifi email sms" example:"wifi"`
+	WiFi  *WiFiPayloadRequest  `json:"wifi,omitempty"`
+	Email *EmailPayloadRequest `json:"email,omitempty"`
+	SMS   *SMSPayloadRequest   `json:"sms,omitempty"`
+}
+
+// QRExportRequest represents a batch of links whose QR codes are exported together.
+// Links have no tags yet, so Tag is rejected rather than ignored.
+type QRExportRequest struct {
+	Codes  []string `json:"codes" binding:"omitempty,max=1000,dive,required" example:"abc123,xyz789"`
+	Tag    string   `json:"tag,omitempty"`                                                    // not supported yet
+	Domain string   `json:"domain,omitempty" example:"go.example.com"`                        // empty for the shared domain
+	Output string   `json:"output,omitempty" binding:"omitempty,oneof=zip pdf" example:"zip"` // defaults to zip
+}
@@ -41,22 +41,52 @@ type Message struct {
 
 // Error codes
 const (
-	ErrCodeBadRequest         = "BAD_REQUEST"
-	ErrCodeUnauthorized       = "UNAUTHORIZED"
-	ErrCodeForbidden          = "FORBIDDEN"
-	ErrCodeNotFound           = "NOT_FOUND"
-	ErrCodeConflict           = "CONFLICT"
-	ErrCodeGone               = "GONE"
-	ErrCodeValidation         = "VALIDATION_ERROR"
-	ErrCodeInternalServer     = "INTERNAL_SERVER_ERROR"
-	ErrCodeInvalidURL         = "INVALID_URL"
-	ErrCodeInvalidAlias       = "INVALID_ALIAS"
-	ErrCodeAliasExists        = "ALIAS_EXISTS"
-	ErrCodeLinkNotFound       = "LINK_NOT_FOUND"
-	ErrCodeLinkExpired        = "LINK_EXPIRED"
-	ErrCodeEmailExists        = "EMAIL_EXISTS"
-	ErrCodeInvalidCredentials = "INVALID_CREDENTIALS"
-	ErrCodeRateLimitExceeded  = "RATE_LIMIT_EXCEEDED"
+	ErrCodeBadRequest          = "BAD_REQUEST"
+	ErrCodeUnauthorized        = "UNAUTHORIZED"
+	ErrCodeForbidden           = "FORBIDDEN"
+	ErrCodeNotFound            = "NOT_FOUND"
+	ErrCodeConflict            = "CONFLICT"
+	ErrCodeGone                = "GONE"
+	ErrCodeValidation          = "VALIDATION_ERROR"
+	ErrCodeInternalServer      = "INTERNAL_SERVER_ERROR"
+	ErrCodeInvalidURL          = "INVALID_URL"
+	ErrCodeInvalidAlias        = "INVALID_ALIAS"
+	ErrCodeAliasExists         = "ALIAS_EXISTS"
+	ErrCodeAliasReserved       = "ALIAS_RESERVED"
+	ErrCodeShortCodeExhausted  = "SHORT_CODE_EXHAUSTED"
+	ErrCodeInvalidDomain       = "INVALID_DOMAIN"
+	ErrCodeDomainNotFound      = "DOMAIN_NOT_FOUND"
+	ErrCodeDomainExists        = "DOMAIN_EXISTS"
+	ErrCodeDomainUnverified    = "DOMAIN_VERIFICATION_FAILED"
+	ErrCodeDomainInUse         = "DOMAIN_IN_USE"
+	ErrCodeLinkNotFound        = "LINK_NOT_FOUND"
+	ErrCodeLinkExpired         = "LINK_EXPIRED"
+	ErrCodeLinkPaused          = "LINK_PAUSED"
+	ErrCodeEmailExists         = "EMAIL_EXISTS"
+	ErrCodeInvalidCredentials  = "INVALID_CREDENTIALS"
+	ErrCodeInvalidGuestToken   = "INVALID_GUEST_TOKEN"
+	ErrCodeRateLimitExceeded   = "RATE_LIMIT_EXCEEDED"
+	ErrCodeURLBlocked          = "URL_BLOCKED"
+	ErrCodeRedirectLoop        = "REDIRECT_LOOP"
+	ErrCodeTargetLinkNotFound  = "TARGET_LINK_NOT_FOUND"
+	ErrCodeShortenerChain      = "SHORTENER_CHAIN"
+	ErrCodeInvalidQROptions    = "INVALID_QR_OPTIONS"
+	ErrCodeInvalidQRStyle      = "INVALID_QR_STYLE"
+	ErrCodeQRStyleNotFound     = "QR_STYLE_NOT_FOUND"
+	ErrCodeLowQRContrast       = "LOW_QR_CONTRAST"
+	ErrCodeInvalidLogo         = "INVALID_LOGO"
+	ErrCodeLogoNotFound        = "LOGO_NOT_FOUND"
+	ErrCodeInvalidQRPayload    = "INVALID_QR_PAYLOAD"
+	ErrCodeInvalidVCard        = "INVALID_VCARD"
+	ErrCodeInvalidTimeRange    = "INVALID_TIME_RANGE"
+	ErrCodeInvalidTimezone     = "INVALID_TIMEZONE"
+	ErrCodeInvalidInterval     = "INVALID_INTERVAL"
+	ErrCodeInvalidExportFormat = "INVALID_EXPORT_FORMAT"
+	ErrCodeInvalidTransfer     = "INVALID_TRANSFER"
+	ErrCodeRecipientNotFound   = "RECIPIENT_NOT_FOUND"
+	ErrCodeTransferNotFound    = "TRANSFER_NOT_FOUND"
+	ErrCodeTransferNotPending  = "TRANSFER_NOT_PENDING"
+	ErrCodeTransferDomain      = "TRANSFER_DOMAIN"
 )
 
 // Response helpers
@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+// LinkRef identifies a link by short code and domain
+type LinkRef struct {
+	Code   string `json:"code" binding:"required" example:"abc123"`
+	Domain string `json:"domain,omitempty" example:"go.example.com"` // empty for the shared domain
+}
+
+// CreateTransferRequest represents a request to transfer links to another user
+type CreateTransferRequest struct {
+	Email string    `json:"email" binding:"required,email" example:"colleague@example.com"`
+	Links []LinkRef `json:"links" binding:"required,min=1,max=100,dive"`
+}
+
+// TransferLink summarizes a link included in a transfer
+type TransferLink struct {
+	ShortCode   string `json:"short_code"`
+	ShortURL    string `json:"short_url"`
+	OriginalURL string `json:"original_url"`
+}
+
+// TransferResponse represents a link transfer in API responses
+type TransferResponse struct {
+	ID          uint           `json:"id"`
+	From        string         `json:"from"` // sender email
+	To          string         `json:"to"`   // recipient email
+	Status      string         `json:"status"`
+	Links       []TransferLink `json:"links"`
+	CreatedAt   time.Time      `json:"created_at"`
+	RespondedAt *time.Time     `json:"responded_at,omitempty"`
+}
+
+// ListTransfersResponse represents the pending transfers of a user
+type ListTransfersResponse struct {
+	Incoming []TransferResponse `json:"incoming"`
+	Outgoing []TransferResponse `json:"outgoing"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+// VCardRequest represents the fields of a contact card; it needs a name or an organization
+type VCardRequest struct {
+	FirstName    string `json:"first_name,omitempty" example:"Quoc"`
+	LastName     string `json:"last_name,omitempty" example:"Bui"`
+	Organization string `json:"organization,omitempty" example:"Example Inc."`
+	Title        string `json:"title,omitempty" example:"Engineer"`
+	Phone        string `json:"phone,omitempty" example:"+84901234567"`
+	Email        string `json:"email,omitempty" example:"quoc@example.com"`
+	Website      string `json:"website,omitempty" example:"https://example.com"`
+	Address      string `json:"address,omitempty"`
+	Note         string `json:"note,omitempty"`
+}
+
+// CreateVCardRequest represents a request to host a contact card behind a short link
+type CreateVCardRequest struct {
+	Card      VCardRequest `json:"card" binding:"required"`
+	Alias     *string      `json:"alias,omitempty" example:"my-card"`
+	Domain    string       `json:"domain,omitempty" example:"go.example.com"` // verified branded domain, empty for the shared one
+	ExpiresIn *int         `json:"expires_in,omitempty" example:"24"`
+}
+
+// VCardResponse represents a hosted contact card
+type VCardResponse struct {
+	VCardRequest
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// VCardLinkResponse represents a vCard link with its card
+type VCardLinkResponse struct {
+	Link LinkResponse  `json:"link"`
+	Card VCardResponse `json:"card"`
+}
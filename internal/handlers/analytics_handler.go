@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"quocbui.dev/m/internal/dto"
+	"quocbui.dev/m/internal/middleware"
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+)
+
+// GetMyLinkTimeSeries godoc
+// @Summary      Get link clicks over time
+// @Description  Get the clicks of a link owned by authenticated user per hour, day, week (starting Monday) or month. Buckets without clicks are included. Without from/to the last 48 hours, 30 days, 12 weeks or 12 months are returned.
+// @Tags         analytics
+// @Produce      json
+// @Security     BearerAuth
+// @Param        code path string true "Short code"
+// @Param        domain query string false "Branded domain of the link, empty for the shared domain"
+// @Param        interval query string false "Bucket size" Enums(hour, day, week, month) default(day)
+// @Param        from query string false "Start, RFC 3339 time or YYYY-MM-DD date in tz"
+// @Param        to query string false "End (exclusive), RFC 3339 time or YYYY-MM-DD date in tz (inclusive day)"
+// @Param        tz query string false "IANA time zone buckets are aligned to" default(UTC)
+// @Success      200 {object} dto.TimeSeriesResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/links/{code}/analytics/timeseries [get]
+func (h *LinkHandler) GetMyLinkTimeSeries(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	link, ok := h.ownedLink(c, userID)
+	if !ok {
+		return
+	}
+	timeRange, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+
+	series, err := h.analyticsService.GetTimeSeries(link.ID, userID, c.DefaultQuery("interval", service.IntervalDay), timeRange)
+	if err != nil {
+		analyticsError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusOK, series)
+}
+
+// GetMyLinkBotTraffic godoc
+// @Summary      Get link bot traffic
+// @Description  Get analytics of the crawler and link preview (Slackbot, facebookexternalhit, TelegramBot...) requests of a link owned by authenticated user. These are left out of click counts and other analytics.
+// @Tags         analytics
+// @Produce      json
+// @Security     BearerAuth
+// @Param        code path string true "Short code"
+// @Param        domain query string false "Branded domain of the link, empty for the shared domain"
+// @Param        from query string false "Start, RFC 3339 time or YYYY-MM-DD date in tz"
+// @Param        to query string false "End (exclusive), RFC 3339 time or YYYY-MM-DD date in tz (inclusive day)"
+// @Param        tz query string false "IANA time zone dates are read in" default(UTC)
+// @Success      200 {object} dto.AnalyticsSummary
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/links/{code}/analytics/bots [get]
+func (h *LinkHandler) GetMyLinkBotTraffic(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	link, ok := h.ownedLink(c, userID)
+	if !ok {
+		return
+	}
+	timeRange, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.analyticsService.GetBotTraffic(link.ID, userID, timeRange)
+	if err != nil {
+		analyticsError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusOK, report)
+}
+
+// GetMyLinkClicks godoc
+// @Summary      Get link clicks
+// @Description  List the individual clicks of a link owned by authenticated user, newest first
+// @Tags         analytics
+// @Produce      json
+// @Security     BearerAuth
+// @Param        code path string true "Short code"
+// @Param        domain query string false "Branded domain of the link, empty for the shared domain"
+// @Param        page query int false "Page number" default(1)
+// @Param        per_page query int false "Items per page" default(10)
+// @Param        country query string false "Country name, as in the analytics summary"
+// @Param        browser query string false "Browser name"
+// @Param        device query string false "Device" Enums(Desktop, Mobile, Bot)
+// @Param        referer_source query string false "Referer source such as Facebook, Google or Direct"
+// @Param        from query string false "Start, RFC 3339 time or YYYY-MM-DD date in tz"
+// @Param        to query string false "End (exclusive), RFC 3339 time or YYYY-MM-DD date in tz (inclusive day)"
+// @Param        tz query string false "IANA time zone dates are read in" default(UTC)
+// @Param        include_bots query bool false "List crawlers and link previews too" default(false)
+// @Success      200 {object} dto.AnalyticsResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/links/{code}/clicks [get]
+func (h *LinkHandler) GetMyLinkClicks(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	link, ok := h.ownedLink(c, userID)
+	if !ok {
+		return
+	}
+	timeRange, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+	includeBots, _ := strconv.ParseBool(c.Query("include_bots"))
+
+	clicks, total, err := h.analyticsService.GetClicksByLinkID(link.ID, userID, service.ClickLogFilter{
+		Range:         timeRange,
+		Country:       c.Query("country"),
+		Browser:       c.Query("browser"),
+		Device:        c.Query("device"),
+		RefererSource: c.Query("referer_source"),
+		IncludeBots:   includeBots,
+	}, page, perPage)
+	if err != nil {
+		analyticsError(c, err)
+		return
+	}
+
+	clickResponses := make([]dto.ClickResponse, len(clicks))
+	for i, click := range clicks {
+		clickResponses[i] = toClickResponse(click)
+	}
+	dto.Success(c, http.StatusOK, dto.AnalyticsResponse{
+		Clicks:  clickResponses,
+		Total:   total,
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// GetMyAnalytics godoc
+// @Summary      Get account analytics
+// @Description  Get an overview of the clicks on all links owned by authenticated user: totals per country, referer source, device and more, clicks over time and the most clicked links. Without from/to the default range of the interval is used, as for a link's time series.
+// @Tags         analytics
+// @Produce      json
+// @Security     BearerAuth
+// @Param        interval query string false "Bucket size of the series" Enums(hour, day, week, month) default(day)
+// @Param        from query string false "Start, RFC 3339 time or YYYY-MM-DD date in tz"
+// @Param        to query string false "End (exclusive), RFC 3339 time or YYYY-MM-DD date in tz (inclusive day)"
+// @Param        tz query string false "IANA time zone for dates and buckets" default(UTC)
+// @Param        top query int false "Number of top links (max 100)" default(10)
+// @Success      200 {object} dto.AccountAnalyticsResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Router       /me/analytics [get]
+func (h *LinkHandler) GetMyAnalytics(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	timeRange, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	top, _ := strconv.Atoi(c.DefaultQuery("top", "10"))
+	if top < 1 || top > service.MaxTopLinks {
+		top = 10
+	}
+
+	overview, err := h.analyticsService.GetAccountAnalytics(userID, c.DefaultQuery("interval", service.IntervalDay), timeRange, top)
+	if err != nil {
+		analyticsError(c, err)
+		return
+	}
+
+	topLinks := make([]dto.TopLinkResponse, len(overview.TopLinks))
+	for i, t := range overview.TopLinks {
+		topLinks[i] = dto.TopLinkResponse{Link: h.toLinkResponse(t.Link, false), Clicks: t.Clicks}
+	}
+	dto.Success(c, http.StatusOK, dto.AccountAnalyticsResponse{
+		Summary:  overview.Summary,
+		Series:   overview.Series,
+		TopLinks: topLinks,
+	})
+}
+
+// ExportMyLinkClicks godoc
+// @Summary      Export link clicks
+// @Description  Stream every click of a link owned by authenticated user, oldest first, as CSV or NDJSON
+// @Tags         analytics
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Security     BearerAuth
+// @Param        code path string true "Short code"
+// @Param        domain query string false "Branded domain of the link, empty for the shared domain"
+// @Param        format query string false "Output format" Enums(csv, ndjson) default(csv)
+// @Param        from query string false "Start, RFC 3339 time or YYYY-MM-DD date in tz"
+// @Param        to query string false "End (exclusive), RFC 3339 time or YYYY-MM-DD date in tz (inclusive day)"
+// @Param        tz query string false "IANA time zone dates are read in" default(UTC)
+// @Param        include_bots query bool false "Export crawlers and link previews too" default(false)
+// @Param        omit_pii query bool false "Leave out IP addresses and full user agents" default(false)
+// @Success      200 {file} binary
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/links/{code}/clicks/export [get]
+func (h *LinkHandler) ExportMyLinkClicks(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	link, ok := h.ownedLink(c, userID)
+	if !ok {
+		return
+	}
+	h.exportClicks(c, link.ID, userID, "clicks-"+link.ShortCode)
+}
+
+// ExportMyClicks godoc
+// @Summary      Export all clicks
+// @Description  Stream the clicks of all links owned by authenticated user, oldest first, as CSV or NDJSON
+// @Tags         analytics
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Security     BearerAuth
+// @Param        format query string false "Output format" Enums(csv, ndjson) default(csv)
+// @Param        from query string false "Start, RFC 3339 time or YYYY-MM-DD date in tz"
+// @Param        to query string false "End (exclusive), RFC 3339 time or YYYY-MM-DD date in tz (inclusive day)"
+// @Param        tz query string false "IANA time zone dates are read in" default(UTC)
+// @Param        include_bots query bool false "Export crawlers and link previews too" default(false)
+// @Param        omit_pii query bool false "Leave out IP addresses and full user agents" default(false)
+// @Success      200 {file} binary
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Router       /me/clicks/export [get]
+func (h *LinkHandler) ExportMyClicks(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	h.exportClicks(c, 0, userID, "clicks")
+}
+
+// exportClicks streams the clicks of linkID, or of all the user's links if it is 0.
+// Errors found once the body has started can only abort the response.
+func (h *LinkHandler) exportClicks(c *gin.Context, linkID, userID uint, name string) {
+	timeRange, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	includeBots, _ := strconv.ParseBool(c.Query("include_bots"))
+	omitPII, _ := strconv.ParseBool(c.Query("omit_pii"))
+	format := c.DefaultQuery("format", service.ClickExportCSV)
+
+	export, err := h.analyticsService.PrepareClickExport(linkID, userID, service.ClickExportOptions{
+		Format:      format,
+		Range:       timeRange,
+		IncludeBots: includeBots,
+		OmitPII:     omitPII,
+	})
+	if err != nil {
+		analyticsError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Disposition", `attachment; filename="`+name+"."+format+`"`)
+	c.Header("Content-Type", export.ContentType())
+	c.Status(http.StatusOK)
+	if err := export.Write(newExportWriter(c.Writer)); err != nil {
+		// The status is sent already, so the export just ends early
+		_ = c.Error(err)
+		c.Abort()
+	}
+}
+
+// exportWriteTimeout bounds each write of a streamed export. Large exports
+// outlive the server's write timeout, but a stalled client still times out.
+const exportWriteTimeout = 30 * time.Second
+
+// exportChunkSize splits large writes, like a whole PDF, so that each chunk
+// gets its own write deadline
+const exportChunkSize = 64 << 10
+
+// exportWriter extends the response's write deadline before each chunk
+type exportWriter struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func newExportWriter(w http.ResponseWriter) *exportWriter {
+	return &exportWriter{w: w, rc: http.NewResponseController(w)}
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), exportChunkSize)]
+		err := e.rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return written, err
+		}
+		n, err := e.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// ownedLink gets the link named by the code path and domain query parameters.
+// It responds with an error and returns false if the user doesn't own it.
+func (h *LinkHandler) ownedLink(c *gin.Context, userID uint) (*models.Link, bool) {
+	link, err := h.linkService.GetLinkWithAnalytics(c.Query("domain"), c.Param("code"), userID)
+	if err != nil {
+		analyticsError(c, err)
+		return nil, false
+	}
+	return link, true
+}
+
+// analyticsRange reads the from, to and tz query parameters.
+// It responds with 400 and returns false if they are malformed.
+func analyticsRange(c *gin.Context) (service.TimeRange, bool) {
+	timeRange, err := service.ParseTimeRange(c.Query("from"), c.Query("to"), c.Query("tz"))
+	if err != nil {
+		analyticsError(c, err)
+		return service.TimeRange{}, false
+	}
+	return timeRange, true
+}
+
+// analyticsError responds to a failed analytics request
+func analyticsError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrLinkNotFound:
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeLinkNotFound, "link not found")
+	case service.ErrUnauthorized:
+		dto.Forbidden(c, "you don't own this link")
+	case service.ErrInvalidTimeRange:
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidTimeRange, "from and to must be RFC 3339 times or YYYY-MM-DD dates, from before to, spanning at most 1000 buckets")
+	case service.ErrInvalidTimezone:
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidTimezone, "tz must be an IANA time zone such as Asia/Ho_Chi_Minh")
+	case service.ErrInvalidInterval:
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidInterval, "interval must be hour, day, week or month")
+	case service.ErrInvalidExportFormat:
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidExportFormat, "format must be csv or ndjson")
+	default:
+		dto.InternalServerError(c, "failed to get analytics")
+	}
+}
+
+func toClickResponse(click *models.Click) dto.ClickResponse {
+	return dto.ClickResponse{
+		ID:          click.ID,
+		IPAddress:   click.IPAddress,
+		Browser:     click.Browser,
+		BrowserVer:  click.BrowserVer,
+		OS:          click.OS,
+		Device:      click.Device,
+		Country:     click.Country,
+		CountryCode: click.CountryCode,
+		City:        click.City,
+		Referer:     click.Referer,
+		Channel:     click.Channel,
+		IsBot:       click.IsBot,
+		ClickedAt:   click.ClickedAt,
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"time"
 
@@ -10,23 +11,26 @@ import (
 )
 
 type AuthHandler struct {
-	authService *service.AuthService
+	authService  *service.AuthService
+	guestService *service.GuestService
 }
 
-func NewAuthHandler(authService *service.AuthService) *AuthHandler {
+func NewAuthHandler(authService *service.AuthService, guestService *service.GuestService) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:  authService,
+		guestService: guestService,
 	}
 }
 
 // Register godoc
 // @Summary      Register new user
-// @Description  Create a new user account
+// @Description  Create a new user account. Links created with guest_token are moved to the new account.
+// @Description  If that fails the account is still created with a warning; logging in with the guest_token claims the links.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        request body dto.RegisterRequest true "Register request"
-// @Success      201 {object} dto.UserResponse
+// @Success      201 {object} dto.RegisterResponse
 // @Failure      400 {object} dto.ErrorResponse
 // @Failure      409 {object} dto.ErrorResponse
 // @Router       /auth/register [post]
@@ -37,6 +41,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	guest, ok := h.resolveGuest(c, req.GuestToken)
+	if !ok {
+		return
+	}
+
 	user, err := h.authService.Register(req.Email, req.Password, req.Name)
 	if err != nil {
 		if err == service.ErrEmailAlreadyExists {
@@ -47,17 +56,30 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	dto.Success(c, http.StatusCreated, dto.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-	})
+	resp := dto.RegisterResponse{
+		UserResponse: dto.UserResponse{
+			ID:        user.ID,
+			Email:     user.Email,
+			Name:      user.Name,
+			CreatedAt: user.CreatedAt,
+		},
+	}
+	// The account exists now, so a retry would only conflict; the links can
+	// still be claimed by logging in with the guest token
+	if guest != nil {
+		resp.ClaimedLinks, err = h.guestService.ClaimLinks(guest, user.ID)
+		if err != nil {
+			log.Printf("Failed to claim guest links for user %d: %v", user.ID, err)
+			resp.Warning = "guest links could not be claimed, log in with the guest token to retry"
+		}
+	}
+
+	dto.Success(c, http.StatusCreated, resp)
 }
 
 // Login godoc
 // @Summary      Login user
-// @Description  Authenticate user and return JWT token
+// @Description  Authenticate user and return JWT token. Links created with guest_token are moved to the account.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -73,12 +95,22 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	guest, ok := h.resolveGuest(c, req.GuestToken)
+	if !ok {
+		return
+	}
+
 	user, token, err := h.authService.LoginWithToken(req.Email, req.Password)
 	if err != nil {
 		dto.Error(c, http.StatusUnauthorized, dto.ErrCodeInvalidCredentials, "invalid credentials")
 		return
 	}
 
+	claimed, ok := h.claimGuestLinks(c, guest, user.ID)
+	if !ok {
+		return
+	}
+
 	dto.Success(c, http.StatusOK, dto.LoginResponse{
 		Token:     token,
 		ExpiresAt: time.Now().Add(24 * time.Hour), // Should get from config
@@ -88,5 +120,32 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			Name:      user.Name,
 			CreatedAt: user.CreatedAt,
 		},
+		ClaimedLinks: claimed,
 	})
 }
+
+// resolveGuest validates an optional guest token before the account is touched
+func (h *AuthHandler) resolveGuest(c *gin.Context, guestToken string) (*service.Guest, bool) {
+	if guestToken == "" {
+		return nil, true
+	}
+	guest, err := h.guestService.ResolveGuest(guestToken)
+	if err != nil {
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidGuestToken, "invalid guest token")
+		return nil, false
+	}
+	return guest, true
+}
+
+// claimGuestLinks moves the guest's links to the user, if a guest was given
+func (h *AuthHandler) claimGuestLinks(c *gin.Context, guest *service.Guest, userID uint) (int64, bool) {
+	if guest == nil {
+		return 0, true
+	}
+	claimed, err := h.guestService.ClaimLinks(guest, userID)
+	if err != nil {
+		dto.InternalServerError(c, "failed to claim guest links")
+		return 0, false
+	}
+	return claimed, true
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"quocbui.dev/m/internal/dto"
+	"quocbui.dev/m/internal/middleware"
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+)
+
+type DomainHandler struct {
+	domainService *service.DomainService
+}
+
+func NewDomainHandler(domainService *service.DomainService) *DomainHandler {
+	return &DomainHandler{
+		domainService: domainService,
+	}
+}
+
+// CreateDomain godoc
+// @Summary      Add branded domain
+// @Description  Register a domain to serve short links on. It must be verified before use.
+// @Tags         domains
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body dto.CreateDomainRequest true "Create domain request"
+// @Success      201 {object} dto.DomainResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Router       /me/domains [post]
+func (h *DomainHandler) CreateDomain(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	var req dto.CreateDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.ValidationError(c, err.Error())
+		return
+	}
+	domain, err := h.domainService.AddDomain(userID, req.Host, req.Method)
+	if err != nil {
+		h.handleDomainError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusCreated, toDomainResponse(domain))
+}
+
+// GetMyDomains godoc
+// @Summary      Get my domains
+// @Description  Get all branded domains of the authenticated user
+// @Tags         domains
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} dto.ListDomainsResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Router       /me/domains [get]
+func (h *DomainHandler) GetMyDomains(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	domains, err := h.domainService.ListDomains(userID)
+	if err != nil {
+		dto.InternalServerError(c, "failed to fetch domains")
+		return
+	}
+	responses := make([]dto.DomainResponse, len(domains))
+	for i, domain := range domains {
+		responses[i] = toDomainResponse(domain)
+	}
+	dto.Success(c, http.StatusOK, dto.ListDomainsResponse{Domains: responses})
+}
+
+// VerifyDomain godoc
+// @Summary      Verify domain
+// @Description  Check that the verification token is published via DNS TXT record or HTTP file
+// @Tags         domains
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "Domain ID"
+// @Success      200 {object} dto.DomainResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      422 {object} dto.ErrorResponse
+// @Router       /me/domains/{id}/verify [post]
+func (h *DomainHandler) VerifyDomain(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		dto.NotFound(c, "domain not found")
+		return
+	}
+	domain, err := h.domainService.VerifyDomain(userID, uint(id))
+	if err != nil {
+		h.handleDomainError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusOK, toDomainResponse(domain))
+}
+
+// DeleteMyDomain godoc
+// @Summary      Delete domain
+// @Description  Delete a branded domain that has no links
+// @Tags         domains
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "Domain ID"
+// @Success      200 {object} dto.MessageResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Router       /me/domains/{id} [delete]
+func (h *DomainHandler) DeleteMyDomain(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		dto.NotFound(c, "domain not found")
+		return
+	}
+	if err := h.domainService.DeleteDomain(userID, uint(id)); err != nil {
+		h.handleDomainError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusOK, dto.Message{Message: "domain deleted successfully"})
+}
+
+func toDomainResponse(domain *models.Domain) dto.DomainResponse {
+	resp := dto.DomainResponse{
+		ID:         domain.ID,
+		Host:       domain.Host,
+		Verified:   domain.IsVerified(),
+		VerifiedAt: domain.VerifiedAt,
+		CreatedAt:  domain.CreatedAt,
+	}
+	if domain.IsVerified() {
+		return resp
+	}
+
+	resp.Verification = &dto.DomainVerification{
+		Method: domain.VerificationMethod,
+		Token:  domain.VerificationToken,
+	}
+	if domain.VerificationMethod == service.DomainVerificationHTTP {
+		resp.Verification.URL = service.DomainVerificationURL(domain.Host)
+	} else {
+		resp.Verification.RecordName = service.DomainTXTRecordName(domain.Host)
+		resp.Verification.RecordValue = domain.VerificationToken
+	}
+	return resp
+}
+
+func (h *DomainHandler) handleDomainError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidDomain):
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidDomain, "invalid domain")
+	case errors.Is(err, service.ErrDomainAlreadyExists):
+		dto.Error(c, http.StatusConflict, dto.ErrCodeDomainExists, "domain already exists")
+	case errors.Is(err, service.ErrDomainNotFound):
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeDomainNotFound, "domain not found")
+	case errors.Is(err, service.ErrUnauthorized):
+		dto.Forbidden(c, "you don't own this domain")
+	case errors.Is(err, service.ErrDomainVerificationFailed):
+		dto.Error(c, http.StatusUnprocessableEntity, dto.ErrCodeDomainUnverified, "verification token not found")
+	case errors.Is(err, service.ErrDomainInUse):
+		dto.Error(c, http.StatusConflict, dto.ErrCodeDomainInUse, "domain still has links")
+	default:
+		dto.InternalServerError(c, "internal server error")
+	}
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +19,7 @@ type LinkHandler struct {
 	linkService      *service.LinkService
 	analyticsService *service.AnalyticsService
 	qrService        *service.QRService
+	pages            *Pages
 	domain           string
 	shortCodeLength  int
 }
@@ -25,6 +28,7 @@ func NewLinkHandler(
 	linkService *service.LinkService,
 	analyticsService *service.AnalyticsService,
 	qrService *service.QRService,
+	pages *Pages,
 	domain string,
 	shortCodeLength int,
 ) *LinkHandler {
@@ -32,6 +36,7 @@ func NewLinkHandler(
 		linkService:      linkService,
 		analyticsService: analyticsService,
 		qrService:        qrService,
+		pages:            pages,
 		domain:           domain,
 		shortCodeLength:  shortCodeLength,
 	}
@@ -39,7 +44,7 @@ func NewLinkHandler(
 
 // Shorten godoc
 // @Summary      Shorten URL
-// @Description  Create shortened link. If token provided, link belongs to that user. Otherwise creates guest account.
+// @Description  Create shortened link. If token provided, link belongs to that user or guest. Otherwise a new guest token is returned.
 // @Tags         links
 // @Accept       json
 // @Produce      json
@@ -69,6 +74,7 @@ func (h *LinkHandler) Shorten(c *gin.Context) {
 	link, token, err := h.linkService.CreateLinkWithAuth(
 		req.URL,
 		req.Alias,
+		req.Domain,
 		expiresAt,
 		authHeader,
 		h.shortCodeLength,
@@ -79,17 +85,22 @@ func (h *LinkHandler) Shorten(c *gin.Context) {
 	}
 
 	dto.Success(c, http.StatusCreated, dto.PublicLinkResponse{
-		Link:  h.toLinkResponse(link),
+		Link:  h.toLinkResponse(link, true),
 		Token: token,
 	})
 }
 
 // Redirect godoc
 // @Summary      Redirect to original URL
-// @Description  Redirect short URL to original URL and track click
+// @Description  Redirect short URL to original URL and track click.
+// @Description  Errors are served as HTML pages to clients that accept text/html.
 // @Tags         redirect
 // @Param        code path string true "Short code"
-// @Success      301 "Redirect to original URL"
+// @Param        confirm query string false "Set to 1 to continue past the suspicious link warning"
+// @Param        src query string false "Visit source marker; qr is added to URLs in QR codes" Enums(qr)
+// @Success      302 "Redirect to the original URL, or to the fallback URL of an expired link"
+// @Success      200 "Warning page for suspicious links"
+// @Failure      403 {object} dto.ErrorResponse
 // @Failure      404 {object} dto.ErrorResponse
 // @Failure      410 {object} dto.ErrorResponse
 // @Router       /{code} [get]
@@ -99,21 +110,58 @@ func (h *LinkHandler) Redirect(c *gin.Context) {
 		IPAddress: c.ClientIP(),
 		UserAgent: c.GetHeader("User-Agent"),
 		Referer:   c.GetHeader("Referer"),
+		Host:      c.Request.Host,
+		Source:    c.Query(service.QRSourceParam),
+
+		WarningAccepted: c.Query("confirm") == "1",
 	}
 	originalURL, err := h.linkService.Redirect(code, clickInfo)
 	if err != nil {
+		if err == service.ErrLinkSuspicious {
+			h.pages.Render(c, http.StatusOK, PageWarning, gin.H{"Code": code, "URL": originalURL, "Source": clickInfo.Source})
+			return
+		}
+		if err == service.ErrURLBlocked {
+			dto.Error(c, http.StatusForbidden, dto.ErrCodeURLBlocked, "link destination is blocked")
+			return
+		}
 		if err == service.ErrLinkNotFound {
-			dto.Error(c, http.StatusNotFound, dto.ErrCodeLinkNotFound, "link not found")
+			h.redirectError(c, code, http.StatusNotFound, PageNotFound, dto.ErrCodeLinkNotFound, "link not found")
 			return
 		}
 		if err == service.ErrLinkExpired {
-			dto.Error(c, http.StatusGone, dto.ErrCodeLinkExpired, "link has expired")
+			if originalURL != "" {
+				redirectUncached(c, originalURL)
+				return
+			}
+			h.redirectError(c, code, http.StatusGone, PageExpired, dto.ErrCodeLinkExpired, "link has expired")
+			return
+		}
+		if err == service.ErrLinkPaused {
+			h.redirectError(c, code, http.StatusForbidden, PagePaused, dto.ErrCodeLinkPaused, "link is paused")
 			return
 		}
 		dto.InternalServerError(c, "internal server error")
 		return
 	}
-	c.Redirect(http.StatusMovedPermanently, originalURL)
+	redirectUncached(c, originalURL)
+}
+
+// redirectUncached redirects to url without letting browsers or proxies cache
+// the redirect, since links may be paused, expire or change their fallback and
+// every visit must be counted
+func redirectUncached(c *gin.Context, url string) {
+	c.Header("Cache-Control", "private, no-store")
+	c.Redirect(http.StatusFound, url)
+}
+
+// redirectError responds with an HTML page to browsers and a JSON error otherwise
+func (h *LinkHandler) redirectError(c *gin.Context, code string, status int, page, errCode, message string) {
+	if wantsHTML(c) {
+		h.pages.Render(c, status, page, gin.H{"Code": code})
+		return
+	}
+	dto.Error(c, status, errCode, message)
 }
 
 // GetMyLinks godoc
@@ -124,6 +172,7 @@ func (h *LinkHandler) Redirect(c *gin.Context) {
 // @Security     BearerAuth
 // @Param        page query int false "Page number" default(1)
 // @Param        per_page query int false "Items per page" default(10)
+// @Param        include query string false "Comma-separated extras; qr embeds each QR code as base64 PNG" Enums(qr)
 // @Success      200 {object} dto.ListLinksResponse
 // @Failure      401 {object} dto.ErrorResponse
 // @Router       /me/links [get]
@@ -146,9 +195,10 @@ func (h *LinkHandler) GetMyLinks(c *gin.Context) {
 		dto.InternalServerError(c, "failed to fetch links")
 		return
 	}
+	includeQR := slices.Contains(strings.Split(c.Query("include"), ","), "qr")
 	linkResponses := make([]dto.LinkResponse, len(links))
 	for i, link := range links {
-		linkResponses[i] = h.toLinkResponse(link)
+		linkResponses[i] = h.toLinkResponse(link, includeQR)
 	}
 	dto.Success(c, http.StatusOK, dto.ListLinksResponse{
 		Links:   linkResponses,
@@ -160,12 +210,18 @@ func (h *LinkHandler) GetMyLinks(c *gin.Context) {
 
 // GetMyLinkDetail godoc
 // @Summary      Get link detail
-// @Description  Get link detail with analytics
+// @Description  Get link detail with analytics, optionally of the clicks in a time range only
 // @Tags         links
 // @Produce      json
 // @Security     BearerAuth
 // @Param        code path string true "Short code"
+// @Param        domain query string false "Branded domain of the link, empty for the shared domain"
+// @Param        from query string false "Start, RFC 3339 time or YYYY-MM-DD date in tz"
+// @Param        to query string false "End (exclusive), RFC 3339 time or YYYY-MM-DD date in tz (inclusive day)"
+// @Param        tz query string false "IANA time zone dates are read in" default(UTC)
+// @Param        include_bots query bool false "Count crawlers and link previews too" default(false)
 // @Success      200 {object} dto.LinkDetailResponse
+// @Failure      400 {object} dto.ErrorResponse
 // @Failure      401 {object} dto.ErrorResponse
 // @Failure      403 {object} dto.ErrorResponse
 // @Failure      404 {object} dto.ErrorResponse
@@ -177,7 +233,7 @@ func (h *LinkHandler) GetMyLinkDetail(c *gin.Context) {
 		return
 	}
 	code := c.Param("code")
-	link, err := h.linkService.GetLinkWithAnalytics(code, userID)
+	link, err := h.linkService.GetLinkWithAnalytics(c.Query("domain"), code, userID)
 	if err != nil {
 		if err == service.ErrLinkNotFound {
 			dto.Error(c, http.StatusNotFound, dto.ErrCodeLinkNotFound, "link not found")
@@ -190,9 +246,18 @@ func (h *LinkHandler) GetMyLinkDetail(c *gin.Context) {
 		dto.InternalServerError(c, "internal server error")
 		return
 	}
-	analytics, _ := h.analyticsService.GetAnalyticsSummary(link.ID, userID)
+	timeRange, ok := analyticsRange(c)
+	if !ok {
+		return
+	}
+	includeBots, _ := strconv.ParseBool(c.Query("include_bots"))
+	analytics, err := h.analyticsService.GetAnalyticsSummary(link.ID, userID, timeRange, includeBots)
+	if err != nil {
+		analyticsError(c, err)
+		return
+	}
 	dto.Success(c, http.StatusOK, dto.LinkDetailResponse{
-		Link:      h.toLinkResponse(link),
+		Link:      h.toLinkResponse(link, true),
 		Analytics: analytics,
 	})
 }
@@ -204,6 +269,7 @@ func (h *LinkHandler) GetMyLinkDetail(c *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        code path string true "Short code"
+// @Param        domain query string false "Branded domain of the link, empty for the shared domain"
 // @Success      200 {object} dto.MessageResponse
 // @Failure      401 {object} dto.ErrorResponse
 // @Failure      403 {object} dto.ErrorResponse
@@ -216,7 +282,7 @@ func (h *LinkHandler) DeleteMyLink(c *gin.Context) {
 		return
 	}
 	code := c.Param("code")
-	err := h.linkService.DeleteLink(code, userID)
+	err := h.linkService.DeleteLink(c.Query("domain"), code, userID)
 	if err != nil {
 		if err == service.ErrLinkNotFound {
 			dto.Error(c, http.StatusNotFound, dto.ErrCodeLinkNotFound, "link not found")
@@ -232,19 +298,269 @@ func (h *LinkHandler) DeleteMyLink(c *gin.Context) {
 	dto.Success(c, http.StatusOK, dto.Message{Message: "link deleted successfully"})
 }
 
-func (h *LinkHandler) toLinkResponse(link *models.Link) dto.LinkResponse {
-	shortURL := fmt.Sprintf("https://%s/%s", h.domain, link.ShortCode)
+// UpdateMyLink godoc
+// @Summary      Update link
+// @Description  Pause or resume a link and set the URL visitors are sent to after it expires
+// @Tags         links
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        code path string true "Short code"
+// @Param        domain query string false "Branded domain of the link, empty for the shared domain"
+// @Param        request body dto.UpdateLinkRequest true "Update link request"
+// @Success      200 {object} dto.LinkResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/links/{code} [patch]
+func (h *LinkHandler) UpdateMyLink(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	var req dto.UpdateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.ValidationError(c, err.Error())
+		return
+	}
+	link, err := h.linkService.UpdateLink(c.Query("domain"), c.Param("code"), userID, service.LinkUpdate{
+		Paused:      req.Paused,
+		FallbackURL: req.FallbackURL,
+	})
+	if err != nil {
+		if err == service.ErrLinkNotFound {
+			dto.Error(c, http.StatusNotFound, dto.ErrCodeLinkNotFound, "link not found")
+			return
+		}
+		if err == service.ErrUnauthorized {
+			dto.Forbidden(c, "you don't own this link")
+			return
+		}
+		if err == service.ErrInvalidURL {
+			dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidURL, "invalid fallback URL")
+			return
+		}
+		if err == service.ErrURLBlocked {
+			dto.Error(c, http.StatusBadRequest, dto.ErrCodeURLBlocked, "fallback URL is flagged as malicious")
+			return
+		}
+		if err == service.ErrRedirectLoop {
+			dto.Error(c, http.StatusBadRequest, dto.ErrCodeRedirectLoop, "fallback URL leads back to this link or through too many short links")
+			return
+		}
+		if err == service.ErrTargetLinkNotFound {
+			dto.Error(c, http.StatusBadRequest, dto.ErrCodeTargetLinkNotFound, "fallback URL points to a short link that does not exist")
+			return
+		}
+		if err == service.ErrShortenerChain {
+			dto.Error(c, http.StatusBadRequest, dto.ErrCodeShortenerChain, "fallback URL points to another URL shortener")
+			return
+		}
+		dto.InternalServerError(c, "internal server error")
+		return
+	}
+	dto.Success(c, http.StatusOK, h.toLinkResponse(link, true))
+}
 
-	// Generate QR code using QR service
-	qrCode, _ := h.qrService.GenerateQRCodeBase64(shortURL)
+// GetQRCode godoc
+// @Summary      Get QR code
+// @Description  Get the QR code image of a short link, drawn in the owner's default QR style
+// @Tags         redirect
+// @Produce      png
+// @Produce      image/svg+xml
+// @Param        code path string true "Short code"
+// @Param        format query string false "Image format" Enums(png, svg) default(png)
+// @Param        size query int false "Image size in pixels (64-2048)" default(256)
+// @Param        margin query int false "Quiet zone in modules (0-16)" default(4)
+// @Success      200 {file} binary
+// @Success      304 "Not modified"
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /{code}/qr [get]
+func (h *LinkHandler) GetQRCode(c *gin.Context) {
+	link, err := h.linkService.GetLink(c.Request.Host, c.Param("code"))
+	if err != nil {
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeLinkNotFound, "link not found")
+		return
+	}
+	var style *models.QRStyle
+	if link.UserID != nil {
+		if style, err = h.qrService.ResolveStyle(*link.UserID, 0); err != nil {
+			dto.InternalServerError(c, "failed to generate QR code")
+			return
+		}
+	}
+	h.renderQRCode(c, link, style, "public, max-age=86400")
+}
+
+// GetMyLinkQRCode godoc
+// @Summary      Get my link QR code
+// @Description  Get the QR code image of a link owned by authenticated user
+// @Tags         links
+// @Produce      png
+// @Produce      image/svg+xml
+// @Security     BearerAuth
+// @Param        code path string true "Short code"
+// @Param        domain query string false "Branded domain of the link, empty for the shared domain"
+// @Param        format query string false "Image format" Enums(png, svg) default(png)
+// @Param        size query int false "Image size in pixels (64-2048)" default(256)
+// @Param        margin query int false "Quiet zone in modules (0-16)" default(4)
+// @Param        style query int false "QR style ID, defaults to the user's default style"
+// @Success      200 {file} binary
+// @Success      304 "Not modified"
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/links/{code}/qr [get]
+func (h *LinkHandler) GetMyLinkQRCode(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	link, err := h.linkService.GetLinkWithAnalytics(c.Query("domain"), c.Param("code"), userID)
+	if err != nil {
+		if err == service.ErrUnauthorized {
+			dto.Forbidden(c, "you don't own this link")
+			return
+		}
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeLinkNotFound, "link not found")
+		return
+	}
+	style, ok := qrStyle(c, h.qrService, userID)
+	if !ok {
+		return
+	}
+	h.renderQRCode(c, link, style, "private, max-age=3600")
+}
+
+// ExportMyQRCodes godoc
+// @Summary      Export QR codes
+// @Description  Export the QR codes of up to 1000 links owned by authenticated user, as a ZIP of images named by short code or as a printable A4 PDF label sheet with the short URL under each code. The ZIP is streamed; the PDF is built in full before it is sent, so large sheets take a while to start downloading. Links are selected by short code; selecting them by tag is not supported yet and is rejected.
+// @Tags         qr
+// @Accept       json
+// @Produce      application/zip
+// @Produce      application/pdf
+// @Security     BearerAuth
+// @Param        request body dto.QRExportRequest true "Links to export"
+// @Param        format query string false "Image format in the ZIP; the PDF always embeds PNG" Enums(png, svg) default(png)
+// @Param        size query int false "Image size in pixels (64-2048)" default(256)
+// @Param        margin query int false "Quiet zone in modules (0-16)" default(4)
+// @Param        style query int false "QR style ID, defaults to the user's default style"
+// @Success      200 {file} binary
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/qr/exports [post]
+func (h *LinkHandler) ExportMyQRCodes(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	var req dto.QRExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.ValidationError(c, err.Error())
+		return
+	}
+	if req.Tag != "" {
+		dto.ValidationError(c, "selecting links by tag is not supported yet, list their codes instead")
+		return
+	}
+	if len(req.Codes) == 0 {
+		dto.ValidationError(c, "codes must list at least one short code")
+		return
+	}
+	opts, ok := qrOptions(c)
+	if !ok {
+		return
+	}
+	if opts.Style, ok = qrStyle(c, h.qrService, userID); !ok {
+		return
+	}
+
+	links, missing, err := h.linkService.GetOwnedLinks(req.Domain, req.Codes, userID)
+	if err != nil {
+		dto.InternalServerError(c, "failed to get links")
+		return
+	}
+	if len(missing) > 0 {
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeLinkNotFound, "links not found: "+strings.Join(missing, ", "))
+		return
+	}
+	items := make([]service.QRExportItem, len(links))
+	for i, link := range links {
+		_, url := shortURL(link, h.domain)
+		items[i] = service.QRExportItem{ShortCode: link.ShortCode, ShortURL: url}
+	}
+
+	export, err := h.qrService.PrepareQRExport(req.Output, items, opts)
+	if err != nil {
+		qrRenderError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Disposition", `attachment; filename="`+export.Filename()+`"`)
+	c.Header("Content-Type", export.ContentType())
+	c.Status(http.StatusOK)
+	if err := export.Write(newExportWriter(c.Writer)); err != nil {
+		// The status is sent already, so the export just ends early
+		_ = c.Error(err)
+		c.Abort()
+	}
+}
+
+// renderQRCode writes the QR image of a link's short URL, honoring If-None-Match
+func (h *LinkHandler) renderQRCode(c *gin.Context, link *models.Link, style *models.QRStyle, cacheControl string) {
+	opts, ok := qrOptions(c)
+	if !ok {
+		return
+	}
+	opts.Style = style
+
+	_, url := shortURL(link, h.domain)
+	img, err := h.qrService.Render(service.QRScanURL(url), opts)
+	if err != nil {
+		qrRenderError(c, err)
+		return
+	}
+	writeQRImage(c, img, cacheControl)
+}
+
+// shortURL returns the host serving a link and its full short URL
+func shortURL(link *models.Link, defaultDomain string) (string, string) {
+	domain := defaultDomain
+	if link.Domain != nil {
+		domain = link.Domain.Host
+	}
+	return domain, fmt.Sprintf("https://%s/%s", domain, link.ShortCode)
+}
+
+// toLinkResponse converts a link, embedding its QR code only if includeQR is set
+func (h *LinkHandler) toLinkResponse(link *models.Link, includeQR bool) dto.LinkResponse {
+	domain, shortURL := shortURL(link, h.domain)
+
+	var qrCode string
+	if includeQR {
+		qrCode, _ = h.qrService.GenerateQRCodeBase64(service.QRScanURL(shortURL))
+	}
 
 	return dto.LinkResponse{
 		ID:          link.ID,
 		ShortCode:   link.ShortCode,
 		ShortURL:    shortURL,
+		Domain:      domain,
+		Type:        link.Type,
 		OriginalURL: link.OriginalURL,
 		ClickCount:  link.ClickCount,
 		QRCode:      qrCode,
+		QRCodeURL:   shortURL + "/qr",
+		Paused:      link.Paused,
+		FallbackURL: link.FallbackURL,
 		ExpiresAt:   link.ExpiresAt,
 		CreatedAt:   link.CreatedAt,
 	}
@@ -258,6 +574,22 @@ func (h *LinkHandler) handleLinkError(c *gin.Context, err error) {
 		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidAlias, "invalid alias (3-20 alphanumeric characters)")
 	case service.ErrAliasAlreadyExists:
 		dto.Error(c, http.StatusConflict, dto.ErrCodeAliasExists, "alias already exists")
+	case service.ErrAliasReserved:
+		dto.Error(c, http.StatusConflict, dto.ErrCodeAliasReserved, "alias is reserved")
+	case service.ErrShortCodeExhausted:
+		dto.Error(c, http.StatusServiceUnavailable, dto.ErrCodeShortCodeExhausted, "could not generate a unique short code, please retry")
+	case service.ErrDomainNotFound:
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeDomainNotFound, "domain not found or not verified")
+	case service.ErrURLBlocked:
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeURLBlocked, "URL is flagged as malicious")
+	case service.ErrRedirectLoop:
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeRedirectLoop, "URL leads back to this link or through too many short links")
+	case service.ErrTargetLinkNotFound:
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeTargetLinkNotFound, "URL points to a short link that does not exist")
+	case service.ErrShortenerChain:
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeShortenerChain, "URL points to another URL shortener")
+	case service.ErrInvalidVCard:
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidVCard, vcardRules)
 	default:
 		dto.InternalServerError(c, "failed to create link")
 	}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page template names; operators override a page by placing <name>.html in the pages directory
+const (
+	PageWarning  = "warning"
+	PageNotFound = "not_found"
+	PageExpired  = "expired"
+	PagePaused   = "paused"
+)
+
+const pageLayout = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>%s</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 36rem; margin: 4rem auto; padding: 0 1rem; color: #222; }
+h1 { color: %s; }
+code { word-break: break-all; background: #f3f4f6; padding: 0.2rem 0.4rem; }
+a.button { display: inline-block; margin-top: 1rem; padding: 0.5rem 1rem; border: 1px solid #b45309; color: #b45309; text-decoration: none; }
+</style>
+</head>
+<body>
+%s
+</body>
+</html>
+`
+
+var defaultPages = map[string]string{
+	PageWarning: fmt.Sprintf(pageLayout, "Warning: suspicious link", "#b45309", `<h1>This link may be unsafe</h1>
+<p>The short link <strong>/{{.Code}}</strong> points to a destination that has been flagged as suspicious:</p>
+<p><code>{{.URL}}</code></p>
+<p>Only continue if you trust this site.</p>
+<a class="button" href="/{{.Code}}?confirm=1{{with .Source}}&src={{.}}{{end}}" rel="nofollow">Continue anyway</a>`),
+	PageNotFound: fmt.Sprintf(pageLayout, "Link not found", "#374151", `<h1>Link not found</h1>
+<p>The short link <strong>/{{.Code}}</strong> does not exist. Check that it was typed correctly.</p>`),
+	PageExpired: fmt.Sprintf(pageLayout, "Link expired", "#374151", `<h1>This link has expired</h1>
+<p>The short link <strong>/{{.Code}}</strong> is no longer available.</p>`),
+	PagePaused: fmt.Sprintf(pageLayout, "Link paused", "#374151", `<h1>This link is paused</h1>
+<p>The owner of the short link <strong>/{{.Code}}</strong> has temporarily disabled it. Try again later.</p>`),
+}
+
+// Pages renders the HTML pages shown to visitors of short links
+type Pages struct {
+	templates map[string]*template.Template
+}
+
+// NewPages loads the built-in pages, replacing any that have an override in dir.
+// An empty dir uses the built-in pages only.
+func NewPages(dir string) (*Pages, error) {
+	pages := &Pages{templates: make(map[string]*template.Template, len(defaultPages))}
+
+	for name, source := range defaultPages {
+		if dir != "" {
+			override, err := os.ReadFile(filepath.Join(dir, name+".html"))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			if err == nil {
+				source = string(override)
+			}
+		}
+
+		tmpl, err := template.New(name).Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s page: %w", name, err)
+		}
+		pages.templates[name] = tmpl
+	}
+	return pages, nil
+}
+
+// Render writes the named page with the given status code. If an operator's
+// template fails, the page falls back to plain text.
+func (p *Pages) Render(c *gin.Context, status int, name string, data gin.H) {
+	c.Header("Cache-Control", "no-store")
+	var page bytes.Buffer
+	if err := p.templates[name].Execute(&page, data); err != nil {
+		log.Printf("Failed to render %s page: %v", name, err)
+		c.String(status, http.StatusText(status))
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
+
+// wantsHTML reports whether the client prefers HTML over JSON, as browsers do
+func wantsHTML(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML
+}
@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"quocbui.dev/m/internal/dto"
+	"quocbui.dev/m/internal/middleware"
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/pkg/utils"
+)
+
+type QRHandler struct {
+	qrService *service.QRService
+}
+
+func NewQRHandler(qrService *service.QRService) *QRHandler {
+	return &QRHandler{
+		qrService: qrService,
+	}
+}
+
+// GetMyQRStyles godoc
+// @Summary      Get my QR styles
+// @Description  Get all QR code styles of the authenticated user
+// @Tags         qr
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} dto.ListQRStylesResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Router       /me/qr/styles [get]
+func (h *QRHandler) GetMyQRStyles(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	styles, err := h.qrService.ListStyles(userID)
+	if err != nil {
+		dto.InternalServerError(c, "failed to fetch QR styles")
+		return
+	}
+	responses := make([]dto.QRStyleResponse, len(styles))
+	for i, style := range styles {
+		responses[i] = toQRStyleResponse(style)
+	}
+	dto.Success(c, http.StatusOK, dto.ListQRStylesResponse{Styles: responses})
+}
+
+// CreateQRStyle godoc
+// @Summary      Create QR style
+// @Description  Save colors, module shape, error correction and logo usage for QR codes. Colors must be dark on light with a contrast ratio of at least 3, and a logo needs error correction Q or H.
+// @Tags         qr
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body dto.QRStyleRequest true "QR style"
+// @Success      201 {object} dto.QRStyleResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Router       /me/qr/styles [post]
+func (h *QRHandler) CreateQRStyle(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	var req dto.QRStyleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.ValidationError(c, err.Error())
+		return
+	}
+	style, err := h.qrService.CreateStyle(userID, toQRStyleInput(req))
+	if err != nil {
+		h.handleQRError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusCreated, toQRStyleResponse(style))
+}
+
+// UpdateQRStyle godoc
+// @Summary      Replace QR style
+// @Description  Replace all settings of a QR style owned by the authenticated user
+// @Tags         qr
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "Style ID"
+// @Param        request body dto.QRStyleRequest true "QR style"
+// @Success      200 {object} dto.QRStyleResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/qr/styles/{id} [put]
+func (h *QRHandler) UpdateQRStyle(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeQRStyleNotFound, "QR style not found")
+		return
+	}
+	var req dto.QRStyleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.ValidationError(c, err.Error())
+		return
+	}
+	style, err := h.qrService.UpdateStyle(userID, uint(id), toQRStyleInput(req))
+	if err != nil {
+		h.handleQRError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusOK, toQRStyleResponse(style))
+}
+
+// DeleteQRStyle godoc
+// @Summary      Delete QR style
+// @Description  Delete a QR style owned by the authenticated user
+// @Tags         qr
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "Style ID"
+// @Success      200 {object} dto.MessageResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/qr/styles/{id} [delete]
+func (h *QRHandler) DeleteQRStyle(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeQRStyleNotFound, "QR style not found")
+		return
+	}
+	if err := h.qrService.DeleteStyle(userID, uint(id)); err != nil {
+		h.handleQRError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusOK, dto.Message{Message: "QR style deleted successfully"})
+}
+
+// GetMyQRLogo godoc
+// @Summary      Get my QR logo
+// @Description  Get the logo the authenticated user uploaded for QR codes
+// @Tags         qr
+// @Produce      png
+// @Security     BearerAuth
+// @Success      200 {file} binary
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/qr/logo [get]
+func (h *QRHandler) GetMyQRLogo(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	data, err := h.qrService.GetLogo(userID)
+	if err != nil {
+		h.handleQRError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, "image/png", data)
+}
+
+// UploadQRLogo godoc
+// @Summary      Upload QR logo
+// @Description  Upload a PNG or JPEG logo (up to 512 KB and 1024x1024) drawn in the center of QR styles that use a logo
+// @Tags         qr
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        logo formData file true "Logo image"
+// @Success      200 {object} dto.MessageResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Router       /me/qr/logo [put]
+func (h *QRHandler) UploadQRLogo(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	header, err := c.FormFile("logo")
+	if err != nil {
+		dto.ValidationError(c, "logo file is required")
+		return
+	}
+	if header.Size > service.MaxQRLogoBytes {
+		h.handleQRError(c, service.ErrInvalidLogo)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		dto.InternalServerError(c, "failed to read logo")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, service.MaxQRLogoBytes+1))
+	if err != nil {
+		dto.InternalServerError(c, "failed to read logo")
+		return
+	}
+	if err := h.qrService.SetLogo(userID, data); err != nil {
+		h.handleQRError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusOK, dto.Message{Message: "logo uploaded successfully"})
+}
+
+// DeleteQRLogo godoc
+// @Summary      Delete QR logo
+// @Description  Delete the QR logo of the authenticated user; styles using it are drawn without a logo
+// @Tags         qr
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} dto.MessageResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Router       /me/qr/logo [delete]
+func (h *QRHandler) DeleteQRLogo(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	if err := h.qrService.DeleteLogo(userID); err != nil {
+		dto.InternalServerError(c, "failed to delete logo")
+		return
+	}
+	dto.Success(c, http.StatusOK, dto.Message{Message: "logo deleted successfully"})
+}
+
+// RenderPayload godoc
+// @Summary      Render QR payload
+// @Description  Render a QR code that joins a Wi-Fi network, drafts an email or drafts a text message. The content is encoded directly, so it can't be tracked or edited after printing.
+// @Tags         qr
+// @Accept       json
+// @Produce      png
+// @Produce      image/svg+xml
+// @Security     BearerAuth
+// @Param        request body dto.QRPayloadRequest true "QR payload"
+// @Param        format query string false "Image format (png or svg)" default(png)
+// @Param        size query int false "Image size in pixels (64-2048)" default(256)
+// @Param        margin query int false "Quiet zone in modules (0-16)" default(4)
+// @Param        style query int false "QR style ID, defaults to the user's default style"
+// @Success      200 {file} binary
+// @Success      304 "Not modified"
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/qr/payloads [post]
+func (h *QRHandler) RenderPayload(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	var req dto.QRPayloadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.ValidationError(c, err.Error())
+		return
+	}
+	opts, ok := qrOptions(c)
+	if !ok {
+		return
+	}
+	if opts.Style, ok = qrStyle(c, h.qrService, userID); !ok {
+		return
+	}
+
+	img, err := h.qrService.RenderPayload(toQRPayload(req), opts)
+	if err != nil {
+		qrRenderError(c, err)
+		return
+	}
+	// Payloads may hold Wi-Fi passwords
+	writeQRImage(c, img, "private, no-store")
+}
+
+// qrOptions reads the format, size and margin query parameters.
+// It responds with 400 and returns false if they are malformed.
+func qrOptions(c *gin.Context) (service.QROptions, bool) {
+	size, sizeErr := strconv.Atoi(c.DefaultQuery("size", strconv.Itoa(service.DefaultQRSize)))
+	margin, marginErr := strconv.Atoi(c.DefaultQuery("margin", strconv.Itoa(service.DefaultQRMargin)))
+	if sizeErr != nil || marginErr != nil {
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidQROptions, "size and margin must be integers")
+		return service.QROptions{}, false
+	}
+	return service.QROptions{
+		Format: c.DefaultQuery("format", service.QRFormatPNG),
+		Size:   size,
+		Margin: margin,
+	}, true
+}
+
+// qrStyle resolves the style query parameter of the user, falling back to their default style.
+// It responds with an error and returns false if the style can't be used.
+func qrStyle(c *gin.Context, qrService *service.QRService, userID uint) (*models.QRStyle, bool) {
+	styleID, err := strconv.ParseUint(c.DefaultQuery("style", "0"), 10, 64)
+	if err != nil {
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeQRStyleNotFound, "QR style not found")
+		return nil, false
+	}
+	style, err := qrService.ResolveStyle(userID, uint(styleID))
+	if err != nil {
+		if errors.Is(err, service.ErrQRStyleNotFound) || errors.Is(err, service.ErrUnauthorized) {
+			dto.Error(c, http.StatusNotFound, dto.ErrCodeQRStyleNotFound, "QR style not found")
+			return nil, false
+		}
+		dto.InternalServerError(c, "failed to generate QR code")
+		return nil, false
+	}
+	return style, true
+}
+
+// qrRenderError responds to a failed QR render
+func qrRenderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidQROptions):
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidQROptions, "format must be png or svg, size 64-2048 and margin 0-16")
+	case errors.Is(err, service.ErrInvalidQRPayload):
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidQRPayload, "invalid payload: check the Wi-Fi password length, email address or phone number")
+	default:
+		dto.InternalServerError(c, "failed to generate QR code")
+	}
+}
+
+// writeQRImage sends a rendered QR code, honoring If-None-Match
+func writeQRImage(c *gin.Context, img *service.QRImage, cacheControl string) {
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(img.Data))
+	c.Header("Cache-Control", cacheControl)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, img.ContentType, img.Data)
+}
+
+func toQRPayload(req dto.QRPayloadRequest) service.QRPayload {
+	payload := service.QRPayload{Type: req.Type}
+	if req.WiFi != nil {
+		payload.WiFi = &utils.WiFiPayload{
+			SSID:     req.WiFi.SSID,
+			Password: req.WiFi.Password,
+			Security: req.WiFi.Security,
+			Hidden:   req.WiFi.Hidden,
+		}
+	}
+	if req.Email != nil {
+		payload.Email = &utils.EmailPayload{
+			To:      req.Email.To,
+			Subject: req.Email.Subject,
+			Body:    req.Email.Body,
+		}
+	}
+	if req.SMS != nil {
+		payload.SMS = &utils.SMSPayload{
+			Phone:   req.SMS.Phone,
+			Message: req.SMS.Message,
+		}
+	}
+	return payload
+}
+
+func toQRStyleInput(req dto.QRStyleRequest) service.QRStyleInput {
+	return service.QRStyleInput{
+		Name:            req.Name,
+		Foreground:      req.Foreground,
+		Background:      req.Background,
+		Shape:           req.Shape,
+		ErrorCorrection: req.ErrorCorrection,
+		WithLogo:        req.WithLogo,
+		IsDefault:       req.IsDefault,
+	}
+}
+
+func toQRStyleResponse(style *models.QRStyle) dto.QRStyleResponse {
+	return dto.QRStyleResponse{
+		ID:              style.ID,
+		Name:            style.Name,
+		Foreground:      style.Foreground,
+		Background:      style.Background,
+		Shape:           style.Shape,
+		ErrorCorrection: style.ErrorCorrection,
+		WithLogo:        style.WithLogo,
+		IsDefault:       style.IsDefault,
+		CreatedAt:       style.CreatedAt,
+		UpdatedAt:       style.UpdatedAt,
+	}
+}
+
+func (h *QRHandler) handleQRError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidQRStyle):
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidQRStyle, "colors must be #rrggbb, shape square or circle, error correction L, M, Q or H (Q or H with a logo)")
+	case errors.Is(err, service.ErrLowQRContrast):
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeLowQRContrast, "foreground must be darker than background with a contrast ratio of at least 3")
+	case errors.Is(err, service.ErrInvalidLogo):
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidLogo, "logo must be a PNG or JPEG image up to 512 KB and 1024x1024")
+	case errors.Is(err, service.ErrLogoNotFound):
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeLogoNotFound, "logo not found")
+	case errors.Is(err, service.ErrQRStyleNotFound):
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeQRStyleNotFound, "QR style not found")
+	case errors.Is(err, service.ErrUnauthorized):
+		dto.Forbidden(c, "you don't own this QR style")
+	default:
+		dto.InternalServerError(c, "internal server error")
+	}
+}
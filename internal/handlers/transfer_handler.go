@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"quocbui.dev/m/internal/dto"
+	"quocbui.dev/m/internal/middleware"
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+)
+
+type TransferHandler struct {
+	transferService *service.TransferService
+	domain          string
+}
+
+func NewTransferHandler(transferService *service.TransferService, domain string) *TransferHandler {
+	return &TransferHandler{
+		transferService: transferService,
+		domain:          domain,
+	}
+}
+
+// CreateTransfer godoc
+// @Summary      Transfer links
+// @Description  Offer links to another user by email. Ownership moves when the recipient accepts. Links on a branded domain can only go to the owner of the domain.
+// @Tags         transfers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body dto.CreateTransferRequest true "Create transfer request"
+// @Success      201 {object} dto.TransferResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/transfers [post]
+func (h *TransferHandler) CreateTransfer(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	var req dto.CreateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.ValidationError(c, err.Error())
+		return
+	}
+	refs := make([]service.LinkRef, len(req.Links))
+	for i, link := range req.Links {
+		refs[i] = service.LinkRef{Domain: link.Domain, ShortCode: link.Code}
+	}
+	transfer, err := h.transferService.CreateTransfer(userID, req.Email, refs)
+	if err != nil {
+		h.handleTransferError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusCreated, h.toTransferResponse(transfer))
+}
+
+// GetMyTransfers godoc
+// @Summary      Get my transfers
+// @Description  Get pending link transfers sent or received by the authenticated user
+// @Tags         transfers
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} dto.ListTransfersResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Router       /me/transfers [get]
+func (h *TransferHandler) GetMyTransfers(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	transfers, err := h.transferService.ListTransfers(userID)
+	if err != nil {
+		dto.InternalServerError(c, "failed to fetch transfers")
+		return
+	}
+	resp := dto.ListTransfersResponse{
+		Incoming: []dto.TransferResponse{},
+		Outgoing: []dto.TransferResponse{},
+	}
+	for _, transfer := range transfers {
+		if transfer.ToUserID == userID {
+			resp.Incoming = append(resp.Incoming, h.toTransferResponse(transfer))
+		} else {
+			resp.Outgoing = append(resp.Outgoing, h.toTransferResponse(transfer))
+		}
+	}
+	dto.Success(c, http.StatusOK, resp)
+}
+
+// AcceptTransfer godoc
+// @Summary      Accept transfer
+// @Description  Take ownership of the links in a transfer, including their click history
+// @Tags         transfers
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "Transfer ID"
+// @Success      200 {object} dto.TransferResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Router       /me/transfers/{id}/accept [post]
+func (h *TransferHandler) AcceptTransfer(c *gin.Context) {
+	h.respond(c, h.transferService.AcceptTransfer)
+}
+
+// DeclineTransfer godoc
+// @Summary      Decline transfer
+// @Description  Refuse a transfer; the links stay with the sender
+// @Tags         transfers
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "Transfer ID"
+// @Success      200 {object} dto.TransferResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Router       /me/transfers/{id}/decline [post]
+func (h *TransferHandler) DeclineTransfer(c *gin.Context) {
+	h.respond(c, h.transferService.DeclineTransfer)
+}
+
+// CancelTransfer godoc
+// @Summary      Cancel transfer
+// @Description  Withdraw a transfer that the recipient has not answered yet
+// @Tags         transfers
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path int true "Transfer ID"
+// @Success      200 {object} dto.TransferResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Router       /me/transfers/{id} [delete]
+func (h *TransferHandler) CancelTransfer(c *gin.Context) {
+	h.respond(c, h.transferService.CancelTransfer)
+}
+
+// respond runs a recipient or sender action on the transfer in the path
+func (h *TransferHandler) respond(c *gin.Context, action func(userID, transferID uint) (*models.LinkTransfer, error)) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeTransferNotFound, "transfer not found")
+		return
+	}
+	transfer, err := action(userID, uint(id))
+	if err != nil {
+		h.handleTransferError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusOK, h.toTransferResponse(transfer))
+}
+
+func (h *TransferHandler) toTransferResponse(transfer *models.LinkTransfer) dto.TransferResponse {
+	resp := dto.TransferResponse{
+		ID:          transfer.ID,
+		Status:      transfer.Status,
+		Links:       make([]dto.TransferLink, len(transfer.Links)),
+		CreatedAt:   transfer.CreatedAt,
+		RespondedAt: transfer.RespondedAt,
+	}
+	if transfer.FromUser != nil {
+		resp.From = transfer.FromUser.Email
+	}
+	if transfer.ToUser != nil {
+		resp.To = transfer.ToUser.Email
+	}
+	for i := range transfer.Links {
+		link := &transfer.Links[i]
+		_, url := shortURL(link, h.domain)
+		resp.Links[i] = dto.TransferLink{
+			ShortCode:   link.ShortCode,
+			ShortURL:    url,
+			OriginalURL: link.OriginalURL,
+		}
+	}
+	return resp
+}
+
+func (h *TransferHandler) handleTransferError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTransfer):
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidTransfer, "links cannot be transferred to yourself")
+	case errors.Is(err, service.ErrRecipientNotFound):
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeRecipientNotFound, "recipient not found")
+	case errors.Is(err, service.ErrLinkNotFound):
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeLinkNotFound, "link not found")
+	case errors.Is(err, service.ErrTransferNotFound):
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeTransferNotFound, "transfer not found")
+	case errors.Is(err, service.ErrTransferNotPending):
+		dto.Error(c, http.StatusConflict, dto.ErrCodeTransferNotPending, "transfer is no longer pending")
+	case errors.Is(err, service.ErrTransferDomain):
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeTransferDomain, "links on a branded domain can only be transferred to the domain's owner")
+	case errors.Is(err, service.ErrUnauthorized):
+		dto.Forbidden(c, "you can't manage this transfer")
+	default:
+		dto.InternalServerError(c, "internal server error")
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"quocbui.dev/m/internal/dto"
+	"quocbui.dev/m/internal/middleware"
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+)
+
+const vcardRules = "a card needs a name or organization; phone, email and website must be valid"
+
+// CreateVCard godoc
+// @Summary      Create vCard link
+// @Description  Host a contact card behind a short link. Its QR code opens the card on phones, and the card can be edited after printing.
+// @Tags         links
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body dto.CreateVCardRequest true "Create vCard request"
+// @Success      201 {object} dto.VCardLinkResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Router       /me/vcards [post]
+func (h *LinkHandler) CreateVCard(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	var req dto.CreateVCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.ValidationError(c, err.Error())
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresIn != nil {
+		t := time.Now().Add(time.Duration(*req.ExpiresIn) * time.Hour)
+		expiresAt = &t
+	}
+
+	card := toVCardModel(req.Card)
+	link, err := h.linkService.CreateVCardLink(req.Domain, card, req.Alias, userID, expiresAt, h.shortCodeLength)
+	if err != nil {
+		h.handleLinkError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusCreated, dto.VCardLinkResponse{
+		Link: h.toLinkResponse(link, true),
+		Card: toVCardResponse(card),
+	})
+}
+
+// GetMyVCard godoc
+// @Summary      Get vCard
+// @Description  Get the contact card of a vCard link owned by authenticated user
+// @Tags         links
+// @Produce      json
+// @Security     BearerAuth
+// @Param        code path string true "Short code"
+// @Param        domain query string false "Branded domain of the link, empty for the shared domain"
+// @Success      200 {object} dto.VCardResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/links/{code}/vcard [get]
+func (h *LinkHandler) GetMyVCard(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	card, err := h.linkService.GetOwnedVCard(c.Query("domain"), c.Param("code"), userID)
+	if err != nil {
+		h.handleVCardError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusOK, toVCardResponse(card))
+}
+
+// UpdateMyVCard godoc
+// @Summary      Update vCard
+// @Description  Replace the contact card of a vCard link; printed QR codes show the new card
+// @Tags         links
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        code path string true "Short code"
+// @Param        domain query string false "Branded domain of the link, empty for the shared domain"
+// @Param        request body dto.VCardRequest true "Contact card"
+// @Success      200 {object} dto.VCardResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /me/links/{code}/vcard [put]
+func (h *LinkHandler) UpdateMyVCard(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		dto.Unauthorized(c, "unauthorized")
+		return
+	}
+	var req dto.VCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		dto.ValidationError(c, err.Error())
+		return
+	}
+	card, err := h.linkService.UpdateVCard(c.Query("domain"), c.Param("code"), userID, toVCardModel(req))
+	if err != nil {
+		h.handleVCardError(c, err)
+		return
+	}
+	dto.Success(c, http.StatusOK, toVCardResponse(card))
+}
+
+// DownloadVCard godoc
+// @Summary      Download vCard
+// @Description  Download the contact card of a vCard link; visitors of the short link are sent here
+// @Tags         redirect
+// @Produce      text/vcard
+// @Param        code path string true "Short code"
+// @Success      200 {file} binary
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      410 {object} dto.ErrorResponse
+// @Router       /{code}/vcard [get]
+func (h *LinkHandler) DownloadVCard(c *gin.Context) {
+	code := c.Param("code")
+	card, err := h.linkService.GetVCard(c.Request.Host, code)
+	if err != nil {
+		switch err {
+		case service.ErrLinkNotFound:
+			h.redirectError(c, code, http.StatusNotFound, PageNotFound, dto.ErrCodeLinkNotFound, "link not found")
+		case service.ErrLinkExpired:
+			h.redirectError(c, code, http.StatusGone, PageExpired, dto.ErrCodeLinkExpired, "link has expired")
+		case service.ErrLinkPaused:
+			h.redirectError(c, code, http.StatusForbidden, PagePaused, dto.ErrCodeLinkPaused, "link is paused")
+		default:
+			dto.InternalServerError(c, "internal server error")
+		}
+		return
+	}
+
+	// The card may be edited at any time
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": service.VCardFileName(card)}))
+	c.Data(http.StatusOK, "text/vcard; charset=utf-8", []byte(service.VCardText(card)))
+}
+
+func (h *LinkHandler) handleVCardError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrInvalidVCard:
+		dto.Error(c, http.StatusBadRequest, dto.ErrCodeInvalidVCard, vcardRules)
+	case service.ErrUnauthorized:
+		dto.Forbidden(c, "you don't own this link")
+	case service.ErrLinkNotFound:
+		dto.Error(c, http.StatusNotFound, dto.ErrCodeLinkNotFound, "vCard link not found")
+	default:
+		dto.InternalServerError(c, "internal server error")
+	}
+}
+
+func toVCardModel(req dto.VCardRequest) *models.VCard {
+	return &models.VCard{
+		FirstName:    req.FirstName,
+		LastName:     req.LastName,
+		Organization: req.Organization,
+		Title:        req.Title,
+		Phone:        req.Phone,
+		Email:        req.Email,
+		Website:      req.Website,
+		Address:      req.Address,
+		Note:         req.Note,
+	}
+}
+
+func toVCardResponse(card *models.VCard) dto.VCardResponse {
+	return dto.VCardResponse{
+		VCardRequest: dto.VCardRequest{
+			FirstName:    card.FirstName,
+			LastName:     card.LastName,
+			Organization: card.Organization,
+			Title:        card.Title,
+			Phone:        card.Phone,
+			Email:        card.Email,
+			Website:      card.Website,
+			Address:      card.Address,
+			Note:         card.Note,
+		},
+		UpdatedAt: card.UpdatedAt,
+	}
+}
@@ -8,8 +8,14 @@ import (
 	"quocbui.dev/m/pkg/utils"
 )
 
-// AuthMiddleware validates JWT tokens and sets user info in context
-func AuthMiddleware(secret string) gin.HandlerFunc {
+// GuestMaterializer turns an anonymous guest into a user row on first use
+type GuestMaterializer interface {
+	MaterializeGuest(guestID string) (uint, error)
+}
+
+// AuthMiddleware validates JWT tokens and sets user info in context.
+// Guest tokens are mapped to their user row through guests.
+func AuthMiddleware(secret string, guests GuestMaterializer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -30,8 +36,18 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
+		userID := claims.UserID
+		if claims.IsGuest() {
+			userID, err = guests.MaterializeGuest(claims.GuestID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load guest session"})
+				c.Abort()
+				return
+			}
+		}
+
 		// Set user info in context
-		c.Set("user_id", claims.UserID)
+		c.Set("user_id", userID)
 		c.Set("email", claims.Email)
 		c.Next()
 	}
@@ -2,6 +2,12 @@ package models
 
 import "time"
 
+// Click channels tell scans of printed QR codes apart from other visits
+const (
+	ClickChannelDirect = "direct"
+	ClickChannelQR     = "qr"
+)
+
 type Click struct {
 	ID            uint      `gorm:"primaryKey"`
 	LinkID        uint      `gorm:"index;not null"`
@@ -17,6 +23,9 @@ type Click struct {
 	Referer       string    `gorm:"size:2048"`
 	RefererSource string    `gorm:"size:50;index"` // Facebook, Google, Twitter, Direct, Other
 	RefererDomain string    `gorm:"size:255"`
+	Channel       string    `gorm:"size:10;not null;default:direct;index"` // direct, qr
+	VisitorHash   string    `gorm:"size:32"`                               // anonymous visitor ID, rotates daily
+	IsBot         bool      `gorm:"not null;default:false;index"`          // crawler or link preview, not counted as a visit
 	ClickedAt     time.Time `gorm:"autoCreateTime;index"`
 	Link          *Link     `gorm:"foreignKey:LinkID"`
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RollupTotal is the dimension of the rollup rows counting all clicks, with an empty value
+const RollupTotal = "total"
+
+// RollupDimensions are the click columns whose values are counted in rollups
+var RollupDimensions = []string{"browser", "os", "device", "country", "referer_source", "referer_domain", "channel"}
+
+// ClickRollup counts the clicks of a link on one UTC day that have a value in a
+// dimension. Rollups are updated with every click, so summaries don't have to
+// scan the raw clicks.
+type ClickRollup struct {
+	LinkID    uint      `gorm:"primaryKey;autoIncrement:false"`
+	Day       time.Time `gorm:"type:date;primaryKey;index"`
+	Dimension string    `gorm:"size:20;primaryKey"`
+	Value     string    `gorm:"size:255;primaryKey"`
+	IsBot     bool      `gorm:"primaryKey"`
+	Clicks    int64     `gorm:"not null"`
+}
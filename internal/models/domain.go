@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Domain is a branded domain claimed by a user that serves their short links once
+// verified. Several users may claim a host, but only one claim can be verified.
+type Domain struct {
+	ID                 uint       `gorm:"primaryKey"`
+	UserID             uint       `gorm:"index;not null;uniqueIndex:idx_domains_user_host,priority:1"`
+	Host               string     `gorm:"size:255;not null;uniqueIndex:idx_domains_user_host,priority:2"`
+	VerificationMethod string     `gorm:"size:10;not null"` // dns, http
+	VerificationToken  string     `gorm:"size:64;not null"`
+	VerifiedAt         *time.Time `gorm:"index"`
+	CreatedAt          time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt          time.Time  `gorm:"autoUpdateTime"`
+	User               *User      `gorm:"foreignKey:UserID"`
+}
+
+// IsVerified reports whether ownership of the domain has been proven
+func (d *Domain) IsVerified() bool {
+	return d.VerifiedAt != nil
+}
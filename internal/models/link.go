@@ -6,17 +6,41 @@ import (
 	"gorm.io/gorm"
 )
 
+// Link types
+const (
+	LinkTypeURL   = "url"   // redirects to OriginalURL
+	LinkTypeVCard = "vcard" // serves its VCard, OriginalURL is empty
+)
+
 type Link struct {
 	ID          uint           `gorm:"primaryKey"`
 	UserID      *uint          `gorm:"index"`
-	ShortCode   string         `gorm:"uniqueIndex;size:20;not null"`
+	GuestID     *string        `gorm:"size:32;index"`    // anonymous creator, kept until claimed
+	DomainID    *uint          `gorm:"index"`            // nil for the shared domain
+	ShortCode   string         `gorm:"size:20;not null"` // unique per domain
+	Type        string         `gorm:"size:10;not null;default:url"`
 	OriginalURL string         `gorm:"size:2048;not null"`
 	CustomAlias *string        `gorm:"size:20"`
 	ClickCount  int64          `gorm:"default:0"`
+	Suspicious  bool           `gorm:"default:false"` // flagged by URL reputation check
+	Paused      bool           `gorm:"default:false"` // disabled by the owner
+	FallbackURL *string        `gorm:"size:2048"`     // redirect target once the link has expired
 	ExpiresAt   *time.Time     `gorm:"index"`
 	CreatedAt   time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt   gorm.DeletedAt `gorm:"index"`
 	User        *User          `gorm:"foreignKey:UserID"`
+	Domain      *Domain        `gorm:"foreignKey:DomainID"`
 	Clicks      []Click        `gorm:"foreignKey:LinkID"`
+	VCard       *VCard         `gorm:"foreignKey:LinkID"`
+}
+
+// IsVCard reports whether the link hosts a contact card
+func (l *Link) IsVCard() bool {
+	return l.Type == LinkTypeVCard
+}
+
+// IsExpired reports whether the link is past its expiry time
+func (l *Link) IsExpired() bool {
+	return l.ExpiresAt != nil && l.ExpiresAt.Before(time.Now())
 }
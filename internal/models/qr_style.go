@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// QR module shapes
+const (
+	QRShapeSquare = "square"
+	QRShapeCircle = "circle"
+)
+
+// QRStyle is a named look for QR codes owned by a user
+type QRStyle struct {
+	ID              uint      `gorm:"primaryKey"`
+	UserID          uint      `gorm:"index;not null"`
+	Name            string    `gorm:"size:64;not null"`
+	Foreground      string    `gorm:"size:7;not null"`  // #rrggbb
+	Background      string    `gorm:"size:7;not null"`  // #rrggbb
+	Shape           string    `gorm:"size:10;not null"` // square, circle
+	ErrorCorrection string    `gorm:"size:1;not null"`  // L, M, Q, H
+	WithLogo        bool      `gorm:"not null;default:false"`
+	IsDefault       bool      `gorm:"not null;default:false"`
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime"`
+}
+
+// QRLogo is the logo a user uploaded for their QR codes, stored as PNG
+type QRLogo struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false"`
+	Data      []byte    `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Link transfer statuses
+const (
+	TransferPending  = "pending"
+	TransferAccepted = "accepted"
+	TransferDeclined = "declined"
+	TransferCanceled = "canceled"
+)
+
+// LinkTransfer is a request to move links to another user, pending until the recipient responds
+type LinkTransfer struct {
+	ID          uint       `gorm:"primaryKey"`
+	FromUserID  uint       `gorm:"index;not null"`
+	ToUserID    uint       `gorm:"index;not null"`
+	Status      string     `gorm:"size:20;not null;default:pending"`
+	RespondedAt *time.Time // when the transfer was accepted, declined or canceled
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime"`
+	FromUser    *User      `gorm:"foreignKey:FromUserID"`
+	ToUser      *User      `gorm:"foreignKey:ToUserID"`
+	Links       []Link     `gorm:"many2many:link_transfer_links"`
+}
+
+// IsPending reports whether the recipient has not responded yet
+func (t *LinkTransfer) IsPending() bool {
+	return t.Status == TransferPending
+}
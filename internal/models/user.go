@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+// GuestEmailDomain is the email domain of temporary guest accounts
+const GuestEmailDomain = "temp.local"
 
 type User struct {
 	ID           uint      `gorm:"primaryKey"`
@@ -11,3 +17,8 @@ type User struct {
 	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
 	Links        []Link    `gorm:"foreignKey:UserID"`
 }
+
+// IsGuest reports whether the user is a temporary account created for anonymous links
+func (u *User) IsGuest() bool {
+	return strings.HasSuffix(u.Email, "@"+GuestEmailDomain)
+}
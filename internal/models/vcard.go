@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// VCard is a contact card hosted behind a short link, so it can be edited
+// after its QR code has been printed
+type VCard struct {
+	LinkID       uint      `gorm:"primaryKey;autoIncrement:false"`
+	FirstName    string    `gorm:"size:100"`
+	LastName     string    `gorm:"size:100"`
+	Organization string    `gorm:"size:200"`
+	Title        string    `gorm:"size:100"`
+	Phone        string    `gorm:"size:32"`
+	Email        string    `gorm:"size:255"`
+	Website      string    `gorm:"size:2048"`
+	Address      string    `gorm:"size:512"`
+	Note         string    `gorm:"size:1000"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// VisitorSalt is the secret of one UTC day that visitor hashes are derived from.
+// It is deleted once the day is over, so hashes can't be traced back to visitors.
+type VisitorSalt struct {
+	Day       time.Time `gorm:"type:date;primaryKey"`
+	Salt      []byte    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -1,11 +1,14 @@
 package postgres
 
 import (
+	"fmt"
+
 	"gorm.io/gorm"
 
 	"quocbui.dev/m/internal/dto"
 	"quocbui.dev/m/internal/models"
 	"quocbui.dev/m/internal/repository"
+	"quocbui.dev/m/pkg/utils"
 )
 
 type clickRepository struct {
@@ -25,19 +28,19 @@ func (r *clickRepository) CreateWithTx(tx *gorm.DB, click *models.Click) error {
 	return tx.Create(click).Error
 }
 
-func (r *clickRepository) GetByLinkID(linkID uint, page, pageSize int) ([]*models.Click, int64, error) {
+func (r *clickRepository) GetByLinkID(linkID uint, filter repository.ClickFilter, page, pageSize int) ([]*models.Click, int64, error) {
 	var clicks []*models.Click
 	var total int64
 
 	offset := (page - 1) * pageSize
 
-	err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&total).Error
+	err := r.clicks(linkID, filter).Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
-	err = r.db.Where("link_id = ?", linkID).
-		Order("clicked_at DESC").
+	err = r.clicks(linkID, filter).
+		Order("clicked_at DESC, id DESC").
 		Offset(offset).
 		Limit(pageSize).
 		Find(&clicks).Error
@@ -45,117 +48,247 @@ func (r *clickRepository) GetByLinkID(linkID uint, page, pageSize int) ([]*model
 	return clicks, total, err
 }
 
-func (r *clickRepository) GetAnalytics(linkID uint) (*dto.AnalyticsSummary, error) {
-	var totalClicks int64
-	r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&totalClicks)
+// clicks scopes a query to the clicks of a link selected by filter
+func (r *clickRepository) clicks(linkID uint, filter repository.ClickFilter) *gorm.DB {
+	return filterClicks(r.db.Model(&models.Click{}).Where("link_id = ?", linkID), filter)
+}
+
+// userClicks scopes a query to the clicks of the links a user owns selected by filter
+func (r *clickRepository) userClicks(userID uint, filter repository.ClickFilter) *gorm.DB {
+	links := r.db.Model(&models.Link{}).Select("id").Where("user_id = ?", userID)
+	return filterClicks(r.db.Model(&models.Click{}).Where("link_id IN (?)", links), filter)
+}
 
-	summary := &dto.AnalyticsSummary{
-		TotalClicks: totalClicks,
+// filterClicks narrows a query on clicks to those selected by filter
+func filterClicks(query *gorm.DB, filter repository.ClickFilter) *gorm.DB {
+	if !filter.From.IsZero() {
+		query = query.Where("clicked_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("clicked_at < ?", filter.To)
+	}
+	switch filter.Bots {
+	case repository.ExcludeBots:
+		query = query.Where("NOT is_bot")
+	case repository.OnlyBots:
+		query = query.Where("is_bot")
+	}
+	if filter.Country != "" {
+		query = query.Where("country = ?", filter.Country)
+	}
+	if filter.Browser != "" {
+		query = query.Where("browser = ?", filter.Browser)
 	}
+	if filter.Device != "" {
+		query = query.Where("device = ?", filter.Device)
+	}
+	if filter.RefererSource != "" {
+		query = query.Where("referer_source = ?", filter.RefererSource)
+	}
+	return query
+}
+
+func (r *clickRepository) StreamByLinkID(linkID uint, filter repository.ClickFilter, fn func(*models.Click) error) error {
+	return streamClicks(r.clicks(linkID, filter), fn)
+}
+
+func (r *clickRepository) StreamByUserID(userID uint, filter repository.ClickFilter, fn func(*models.Click) error) error {
+	return streamClicks(r.userClicks(userID, filter), fn)
+}
 
-	// Browser stats
-	var browserResults []struct {
-		Browser string
-		Count   int64
+// streamClicks scans the clicks of query one row at a time
+func streamClicks(query *gorm.DB, fn func(*models.Click) error) error {
+	rows, err := query.Order("clicked_at, id").Rows()
+	if err != nil {
+		return err
 	}
-	r.db.Model(&models.Click{}).
-		Select("browser, count(*) as count").
-		Where("link_id = ?", linkID).
-		Group("browser").
-		Scan(&browserResults)
-	if len(browserResults) > 0 {
-		summary.Browsers = make(map[string]int64)
-		for _, b := range browserResults {
-			summary.Browsers[b.Browser] = b.Count
+	defer rows.Close()
+
+	for rows.Next() {
+		var click models.Click
+		if err := query.ScanRows(rows, &click); err != nil {
+			return err
+		}
+		if err := fn(&click); err != nil {
+			return err
 		}
 	}
+	return rows.Err()
+}
 
-	// OS stats
-	var osResults []struct {
-		OS    string
-		Count int64
+func (r *clickRepository) GetAnalytics(linkID uint, filter repository.ClickFilter) (*dto.AnalyticsSummary, error) {
+	return summarize(func() *gorm.DB { return r.clicks(linkID, filter) })
+}
+
+func (r *clickRepository) GetUserAnalytics(userID uint, filter repository.ClickFilter) (*dto.AnalyticsSummary, error) {
+	return summarize(func() *gorm.DB { return r.userClicks(userID, filter) })
+}
+
+// summarize aggregates the clicks selected by scope, which returns a new query per call
+func summarize(scope func() *gorm.DB) (*dto.AnalyticsSummary, error) {
+	summary := &dto.AnalyticsSummary{}
+	if err := scope().Count(&summary.TotalClicks).Error; err != nil {
+		return nil, err
 	}
-	r.db.Model(&models.Click{}).
-		Select("os, count(*) as count").
-		Where("link_id = ?", linkID).
-		Group("os").
-		Scan(&osResults)
-	if len(osResults) > 0 {
-		summary.OS = make(map[string]int64)
-		for _, o := range osResults {
-			summary.OS[o.OS] = o.Count
+
+	dimensions := []struct {
+		column string
+		counts *map[string]int64
+	}{
+		{"browser", &summary.Browsers},
+		{"os", &summary.OS},
+		{"device", &summary.Devices},
+		{"country", &summary.Countries},
+		{"referer_source", &summary.RefererSources}, // Facebook, Google, Direct...
+		{"channel", &summary.Channels},              // QR scans vs. other visits
+	}
+	for _, d := range dimensions {
+		counts, err := countClicksBy(scope(), d.column)
+		if err != nil {
+			return nil, err
 		}
+		*d.counts = counts
 	}
 
-	// Device stats
-	var deviceResults []struct {
-		Device string
-		Count  int64
-	}
-	r.db.Model(&models.Click{}).
-		Select("device, count(*) as count").
-		Where("link_id = ?", linkID).
-		Group("device").
-		Scan(&deviceResults)
-	if len(deviceResults) > 0 {
-		summary.Devices = make(map[string]int64)
-		for _, d := range deviceResults {
-			summary.Devices[d.Device] = d.Count
-		}
+	// Referer domain stats (chi tiết)
+	counts, err := countClicksBy(scope().Where("referer_domain != ''").Order("count DESC").Limit(10), "referer_domain")
+	if err != nil {
+		return nil, err
 	}
+	summary.RefererDomains = counts
 
-	// Country stats
-	var countryResults []struct {
-		Country string
-		Count   int64
-	}
-	r.db.Model(&models.Click{}).
-		Select("country, count(*) as count").
-		Where("link_id = ?", linkID).
-		Group("country").
-		Scan(&countryResults)
-	if len(countryResults) > 0 {
-		summary.Countries = make(map[string]int64)
-		for _, c := range countryResults {
-			summary.Countries[c.Country] = c.Count
-		}
+	return summary, nil
+}
+
+// countClicksBy counts the clicks of query per value of column, or returns nil if there are none
+func countClicksBy(query *gorm.DB, column string) (map[string]int64, error) {
+	var rows []struct {
+		Value string
+		Count int64
+	}
+	err := query.Select(column + " AS value, count(*) AS count").Group(column).Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Value] = row.Count
 	}
+	return counts, nil
+}
+
+// GetTimeSeries truncates click times in the given time zone and converts the
+// bucket starts back to absolute times
+func (r *clickRepository) GetTimeSeries(linkID uint, interval string, filter repository.ClickFilter, tz string) ([]dto.TimeSeriesPoint, error) {
+	return timeSeries(r.clicks(linkID, filter), interval, tz)
+}
+
+func (r *clickRepository) GetUserTimeSeries(userID uint, interval string, filter repository.ClickFilter, tz string) ([]dto.TimeSeriesPoint, error) {
+	return timeSeries(r.userClicks(userID, filter), interval, tz)
+}
+
+func timeSeries(query *gorm.DB, interval, tz string) ([]dto.TimeSeriesPoint, error) {
+	var points []dto.TimeSeriesPoint
+	err := query.
+		Select("date_trunc(?, clicked_at AT TIME ZONE ?) AT TIME ZONE ? AS time, count(*) AS clicks, "+
+			"count(DISTINCT NULLIF(visitor_hash, '')) AS visitors", interval, tz, tz).
+		Group("1").
+		Order("1").
+		Scan(&points).Error
+	return points, err
+}
+
+func (r *clickRepository) GetTopLinks(userID uint, filter repository.ClickFilter, limit int) ([]repository.LinkClicks, error) {
+	var links []repository.LinkClicks
+	err := r.userClicks(userID, filter).
+		Select("link_id, count(*) AS clicks").
+		Group("link_id").
+		Order("count(*) DESC, link_id").
+		Limit(limit).
+		Scan(&links).Error
+	return links, err
+}
+
+// hllRegister and hllRank split the first 64 bits of a visitor hash like utils.HyperLogLog.Add
+var (
+	hllRegister = fmt.Sprintf("('x' || substr(visitor_hash, 1, %d))::bit(%d)::int", utils.HLLPrecision/4, utils.HLLPrecision)
+	hllRank     = fmt.Sprintf("COALESCE(NULLIF(position(B'1' IN ('x' || substr(visitor_hash, %d, %d))::bit(%d)), 0), %d)",
+		utils.HLLPrecision/4+1, 16-utils.HLLPrecision/4, 64-utils.HLLPrecision, utils.HLLMaxRank)
+)
 
-	// Referer source stats (Facebook, Google, Direct...)
-	var sourceResults []struct {
-		RefererSource string
-		Count         int64
-	}
-	r.db.Model(&models.Click{}).
-		Select("referer_source, count(*) as count").
-		Where("link_id = ?", linkID).
-		Group("referer_source").
-		Scan(&sourceResults)
-	if len(sourceResults) > 0 {
-		summary.RefererSources = make(map[string]int64)
-		for _, s := range sourceResults {
-			summary.RefererSources[s.RefererSource] = s.Count
+// GetUniqueVisitors counts visitors in total and per dimension. The approximate
+// path lets Postgres reduce the hashes to HyperLogLog registers, which needs far
+// less memory than COUNT(DISTINCT) on links with millions of clicks.
+func (r *clickRepository) GetUniqueVisitors(linkID uint, filter repository.ClickFilter, approximate bool) (*dto.UniqueVisitors, error) {
+	visitors := &dto.UniqueVisitors{Approximate: approximate}
+	dimensions := []struct {
+		column string
+		counts *map[string]int64
+	}{
+		{"browser", &visitors.Browsers},
+		{"os", &visitors.OS},
+		{"device", &visitors.Devices},
+		{"country", &visitors.Countries},
+		{"referer_source", &visitors.RefererSources},
+		{"channel", &visitors.Channels},
+	}
+
+	total, err := r.countVisitors(linkID, filter, "''", approximate)
+	if err != nil {
+		return nil, err
+	}
+	visitors.Total = total[""]
+	for _, d := range dimensions {
+		counts, err := r.countVisitors(linkID, filter, d.column, approximate)
+		if err != nil {
+			return nil, err
+		}
+		if len(counts) > 0 {
+			*d.counts = counts
 		}
 	}
+	return visitors, nil
+}
 
-	// Referer domain stats (chi tiết)
-	var domainResults []struct {
-		RefererDomain string
-		Count         int64
-	}
-	r.db.Model(&models.Click{}).
-		Select("referer_domain, count(*) as count").
-		Where("link_id = ? AND referer_domain != ''", linkID).
-		Group("referer_domain").
-		Order("count DESC").
-		Limit(10).
-		Scan(&domainResults)
-	if len(domainResults) > 0 {
-		summary.RefererDomains = make(map[string]int64)
-		for _, d := range domainResults {
-			summary.RefererDomains[d.RefererDomain] = d.Count
+// countVisitors counts distinct visitor hashes per value of column
+func (r *clickRepository) countVisitors(linkID uint, filter repository.ClickFilter, column string, approximate bool) (map[string]int64, error) {
+	query := r.clicks(linkID, filter).Where("visitor_hash <> ''")
+	counts := make(map[string]int64)
+
+	if !approximate {
+		var rows []struct {
+			Value string
+			Count int64
+		}
+		err := query.Select(column + " AS value, count(DISTINCT visitor_hash) AS count").
+			Group("1").
+			Scan(&rows).Error
+		for _, row := range rows {
+			counts[row.Value] = row.Count
 		}
+		return counts, err
 	}
 
-	return summary, nil
+	var rows []struct {
+		Value    string
+		Register int
+		Rank     int
+	}
+	err := query.Select(column + " AS value, " + hllRegister + " AS register, max(" + hllRank + ") AS rank").
+		Group("1, 2").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	sketches := make(map[string]*utils.HyperLogLog)
+	for _, row := range rows {
+		if sketches[row.Value] == nil {
+			sketches[row.Value] = utils.NewHyperLogLog()
+		}
+		sketches[row.Value].Merge(row.Register, row.Rank)
+	}
+	for value, sketch := range sketches {
+		counts[value] = sketch.Estimate()
+	}
+	return counts, nil
 }
@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"quocbui.dev/m/internal/dto"
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/repository"
+)
+
+type clickRollupRepository struct {
+	db *gorm.DB
+}
+
+func NewClickRollupRepository(db *gorm.DB) repository.ClickRollupRepository {
+	return &clickRollupRepository{db: db}
+}
+
+// IncrementWithTx upserts one row per dimension, always in the same order so
+// concurrent clicks on a link lock its rows without deadlocking
+func (r *clickRollupRepository) IncrementWithTx(tx *gorm.DB, click *models.Click) error {
+	day := click.ClickedAt.UTC().Truncate(24 * time.Hour)
+	rows := []models.ClickRollup{{LinkID: click.LinkID, Day: day, Dimension: models.RollupTotal, IsBot: click.IsBot, Clicks: 1}}
+	for _, dimension := range models.RollupDimensions {
+		rows = append(rows, models.ClickRollup{
+			LinkID:    click.LinkID,
+			Day:       day,
+			Dimension: dimension,
+			Value:     rollupValue(click, dimension),
+			IsBot:     click.IsBot,
+			Clicks:    1,
+		})
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "link_id"}, {Name: "day"}, {Name: "dimension"}, {Name: "value"}, {Name: "is_bot"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"clicks": gorm.Expr("click_rollups.clicks + excluded.clicks")}),
+	}).Create(&rows).Error
+}
+
+// rollupValue returns the value of a click in one of models.RollupDimensions
+func rollupValue(click *models.Click, dimension string) string {
+	switch dimension {
+	case "browser":
+		return click.Browser
+	case "os":
+		return click.OS
+	case "device":
+		return click.Device
+	case "country":
+		return click.Country
+	case "referer_source":
+		return click.RefererSource
+	case "referer_domain":
+		return click.RefererDomain
+	case "channel":
+		return click.Channel
+	}
+	return ""
+}
+
+func (r *clickRollupRepository) GetAnalytics(linkID uint, filter repository.ClickFilter) (*dto.AnalyticsSummary, error) {
+	return sumRollups(func() *gorm.DB { return r.rollups(filter).Where("link_id = ?", linkID) })
+}
+
+func (r *clickRollupRepository) GetUserAnalytics(userID uint, filter repository.ClickFilter) (*dto.AnalyticsSummary, error) {
+	links := r.db.Model(&models.Link{}).Select("id").Where("user_id = ?", userID)
+	return sumRollups(func() *gorm.DB { return r.rollups(filter).Where("link_id IN (?)", links) })
+}
+
+// rollups scopes a query to the rollups of the days and traffic selected by filter
+func (r *clickRollupRepository) rollups(filter repository.ClickFilter) *gorm.DB {
+	query := r.db.Model(&models.ClickRollup{})
+	if !filter.From.IsZero() {
+		query = query.Where("day >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("day < ?", filter.To)
+	}
+	switch filter.Bots {
+	case repository.ExcludeBots:
+		query = query.Where("NOT is_bot")
+	case repository.OnlyBots:
+		query = query.Where("is_bot")
+	}
+	return query
+}
+
+// sumRollups adds up the rollups selected by scope into the same summary the raw
+// clicks give, with one query for all dimensions and one for the top referer domains
+func sumRollups(scope func() *gorm.DB) (*dto.AnalyticsSummary, error) {
+	var rows []struct {
+		Dimension string
+		Value     string
+		Clicks    int64
+	}
+	err := scope().
+		Select("dimension, value, sum(clicks) AS clicks").
+		Where("dimension <> ?", "referer_domain").
+		Group("dimension, value").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &dto.AnalyticsSummary{}
+	for _, row := range rows {
+		if row.Dimension == models.RollupTotal {
+			summary.TotalClicks = row.Clicks
+			continue
+		}
+		counts := summaryCounts(summary, row.Dimension)
+		if counts == nil {
+			continue
+		}
+		if *counts == nil {
+			*counts = make(map[string]int64)
+		}
+		(*counts)[row.Value] = row.Clicks
+	}
+
+	var domains []struct {
+		Value  string
+		Clicks int64
+	}
+	err = scope().
+		Select("value, sum(clicks) AS clicks").
+		Where("dimension = ? AND value <> ''", "referer_domain").
+		Group("value").
+		Order("sum(clicks) DESC").
+		Limit(10).
+		Scan(&domains).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(domains) > 0 {
+		summary.RefererDomains = make(map[string]int64)
+		for _, d := range domains {
+			summary.RefererDomains[d.Value] = d.Clicks
+		}
+	}
+	return summary, nil
+}
+
+// summaryCounts returns the map of a summary holding a rollup dimension
+func summaryCounts(summary *dto.AnalyticsSummary, dimension string) *map[string]int64 {
+	switch dimension {
+	case "browser":
+		return &summary.Browsers
+	case "os":
+		return &summary.OS
+	case "device":
+		return &summary.Devices
+	case "country":
+		return &summary.Countries
+	case "referer_source":
+		return &summary.RefererSources
+	case "channel":
+		return &summary.Channels
+	}
+	return nil
+}
+
+// RebuildDay locks the rollups while it recounts the day, so a click tracked
+// meanwhile is counted either by the recount or by its own increment, never twice
+func (r *clickRollupRepository) RebuildDay(day time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE click_rollups IN EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		if err := tx.Where("day = ?", day).Delete(&models.ClickRollup{}).Error; err != nil {
+			return err
+		}
+		return insertRollups(tx, "clicked_at >= ? AND clicked_at < ?", day, day.AddDate(0, 0, 1))
+	})
+}
+
+func (r *clickRollupRepository) FirstClickDay() (time.Time, error) {
+	var first sql.NullTime
+	if err := r.db.Model(&models.Click{}).Select("min(clicked_at)").Row().Scan(&first); err != nil {
+		return time.Time{}, err
+	}
+	if !first.Valid {
+		return time.Time{}, nil
+	}
+	return first.Time.UTC().Truncate(24 * time.Hour), nil
+}
+
+// insertRollups counts the clicks matching where into new rollup rows
+func insertRollups(tx *gorm.DB, where string, args ...interface{}) error {
+	for _, dimension := range append([]string{models.RollupTotal}, models.RollupDimensions...) {
+		value := "COALESCE(" + dimension + ", '')"
+		if dimension == models.RollupTotal {
+			value = "''"
+		}
+		err := tx.Exec("INSERT INTO click_rollups (link_id, day, dimension, value, is_bot, clicks) "+
+			"SELECT link_id, (clicked_at AT TIME ZONE 'UTC')::date, ?, "+value+", is_bot, count(*) "+
+			"FROM clicks WHERE "+where+" GROUP BY 1, 2, 4, 5",
+			append([]interface{}{dimension}, args...)...).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
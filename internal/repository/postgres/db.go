@@ -1,17 +1,30 @@
 package postgres
 
 import (
+	"errors"
 	"fmt"
 	"log"
 
 	"quocbui.dev/m/internal/config"
 	"quocbui.dev/m/internal/models"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+const linkCodeSequence = "link_code_seq"
+
+// pgUniqueViolation is the SQLSTATE of unique constraint violations
+const pgUniqueViolation = "23505"
+
+// isUniqueViolation reports whether err comes from a violated unique constraint
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
 func NewDB(cfg *config.DBConfig) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
@@ -32,15 +45,76 @@ func NewDB(cfg *config.DBConfig) (*gorm.DB, error) {
 func AutoMigrate(db *gorm.DB) error {
 	log.Println("Running database migrations...")
 
+	// Clicks recorded before bots were flagged are classified once by their device
+	flagBots := !db.Migrator().HasColumn(&models.Click{}, "IsBot")
+	// Rollups are filled from the clicks recorded so far when their table is created
+	buildRollups := !db.Migrator().HasTable(&models.ClickRollup{})
+
 	err := db.AutoMigrate(
 		&models.User{},
+		&models.Domain{},
 		&models.Link{},
+		&models.LinkTransfer{},
 		&models.Click{},
+		&models.ClickRollup{},
+		&models.VisitorSalt{},
+		&models.VCard{},
+		&models.QRStyle{},
+		&models.QRLogo{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	if flagBots {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.Click{}).Where("device = ?", "Bot").Update("is_bot", true).Error; err != nil {
+				return err
+			}
+			// Link click counts exclude bots, so take the flagged clicks back out
+			return tx.Exec("UPDATE links SET click_count = GREATEST(links.click_count - bots.clicks, 0) " +
+				"FROM (SELECT link_id, count(*) AS clicks FROM clicks WHERE is_bot GROUP BY link_id) AS bots " +
+				"WHERE links.id = bots.link_id").Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to flag bot clicks: %w", err)
+		}
+	}
+
+	if buildRollups {
+		err := db.Transaction(func(tx *gorm.DB) error { return insertRollups(tx, "TRUE") })
+		if err != nil {
+			return fmt.Errorf("failed to build click rollups, run with -rebuild-rollups: %w", err)
+		}
+	}
+
+	// Short codes are unique per domain; links on the shared domain have no domain_id
+	if db.Migrator().HasIndex(&models.Link{}, "idx_links_short_code") {
+		if err := db.Migrator().DropIndex(&models.Link{}, "idx_links_short_code"); err != nil {
+			return fmt.Errorf("failed to drop global short code index: %w", err)
+		}
+	}
+	err = db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_links_domain_short_code ON links ((COALESCE(domain_id, 0)), short_code)").Error
+	if err != nil {
+		return fmt.Errorf("failed to create short code index: %w", err)
+	}
+
+	// Unverified claims must not block the real owner, so only verified hosts are unique
+	if db.Migrator().HasIndex(&models.Domain{}, "idx_domains_host") {
+		if err := db.Migrator().DropIndex(&models.Domain{}, "idx_domains_host"); err != nil {
+			return fmt.Errorf("failed to drop domain host index: %w", err)
+		}
+	}
+	err = db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_verified_host ON domains (host) WHERE verified_at IS NOT NULL").Error
+	if err != nil {
+		return fmt.Errorf("failed to create verified domain index: %w", err)
+	}
+
+	// Sequence backing the sequential and hashids short code strategies
+	if err := db.Exec("CREATE SEQUENCE IF NOT EXISTS " + linkCodeSequence).Error; err != nil {
+		return fmt.Errorf("failed to create short code sequence: %w", err)
+	}
+
 	log.Println("Database migrations completed")
 	return nil
 }
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/repository"
+)
+
+type domainRepository struct {
+	db *gorm.DB
+}
+
+func NewDomainRepository(db *gorm.DB) repository.DomainRepository {
+	return &domainRepository{db: db}
+}
+
+func (r *domainRepository) Create(domain *models.Domain) error {
+	err := r.db.Create(domain).Error
+	if isUniqueViolation(err) {
+		return repository.ErrDuplicateKey
+	}
+	return err
+}
+
+func (r *domainRepository) GetByID(id uint) (*models.Domain, error) {
+	var domain models.Domain
+	err := r.db.First(&domain, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &domain, err
+}
+
+func (r *domainRepository) GetVerifiedByHost(host string) (*models.Domain, error) {
+	var domain models.Domain
+	err := r.db.Where("host = ? AND verified_at IS NOT NULL", host).First(&domain).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &domain, err
+}
+
+func (r *domainRepository) GetByUserID(userID uint) ([]*models.Domain, error) {
+	var domains []*models.Domain
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&domains).Error
+	return domains, err
+}
+
+func (r *domainRepository) MarkVerified(domain *models.Domain) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(domain).Update("verified_at", domain.VerifiedAt).Error
+		if isUniqueViolation(err) {
+			return repository.ErrDuplicateKey
+		}
+		if err != nil {
+			return err
+		}
+		// The losing claims can never be verified now
+		return tx.Where("host = ? AND id <> ? AND verified_at IS NULL", domain.Host, domain.ID).
+			Delete(&models.Domain{}).Error
+	})
+}
+
+func (r *domainRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Domain{}, id).Error
+}
+
+// CountLinks counts links on the domain, including soft-deleted ones
+func (r *domainRepository) CountLinks(id uint) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Link{}).Where("domain_id = ?", id).Count(&count).Error
+	return count, err
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -19,37 +20,52 @@ func NewLinkRepository(db *gorm.DB) repository.LinkRepository {
 }
 
 func (r *linkRepository) Create(link *models.Link) error {
-	return r.db.Create(link).Error
+	return r.CreateWithTx(r.db, link)
 }
 
 // CreateWithTx creates a link within a transaction
 func (r *linkRepository) CreateWithTx(tx *gorm.DB, link *models.Link) error {
-	return tx.Create(link).Error
+	err := tx.Create(link).Error
+	if isUniqueViolation(err) {
+		return repository.ErrDuplicateKey
+	}
+	return err
 }
 
 func (r *linkRepository) GetByID(id uint) (*models.Link, error) {
 	var link models.Link
-	err := r.db.First(&link, id).Error
+	err := r.db.Preload("Domain").First(&link, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
 	return &link, err
 }
 
-func (r *linkRepository) GetByShortCode(shortCode string) (*models.Link, error) {
+func (r *linkRepository) GetByShortCode(domainID uint, shortCode string) (*models.Link, error) {
 	var link models.Link
-	err := r.db.Where("short_code = ?", shortCode).First(&link).Error
+	err := r.db.Preload("Domain").
+		Where("COALESCE(domain_id, 0) = ? AND short_code = ?", domainID, shortCode).
+		First(&link).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
 	return &link, err
 }
 
+// GetByShortCodes gets the links with the given short codes on a domain
+func (r *linkRepository) GetByShortCodes(domainID uint, shortCodes []string) ([]*models.Link, error) {
+	var links []*models.Link
+	err := r.db.Preload("Domain").
+		Where("COALESCE(domain_id, 0) = ? AND short_code IN ?", domainID, shortCodes).
+		Find(&links).Error
+	return links, err
+}
+
 // GetByShortCodeForUpdate gets a link with row-level lock for update (SELECT ... FOR UPDATE)
-func (r *linkRepository) GetByShortCodeForUpdate(tx *gorm.DB, shortCode string) (*models.Link, error) {
+func (r *linkRepository) GetByShortCodeForUpdate(tx *gorm.DB, domainID uint, shortCode string) (*models.Link, error) {
 	var link models.Link
 	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-		Where("short_code = ?", shortCode).
+		Where("COALESCE(domain_id, 0) = ? AND short_code = ?", domainID, shortCode).
 		First(&link).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
@@ -68,7 +84,8 @@ func (r *linkRepository) GetByUserID(userID uint, page, pageSize int) ([]*models
 		return nil, 0, err
 	}
 
-	err = r.db.Where("user_id = ?", userID).
+	err = r.db.Preload("Domain").
+		Where("user_id = ?", userID).
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(pageSize).
@@ -88,6 +105,83 @@ func (r *linkRepository) IncrementClickCountWithTx(tx *gorm.DB, id uint) error {
 		UpdateColumn("click_count", gorm.Expr("click_count + ?", 1)).Error
 }
 
+// Update writes the owner-editable settings of a link. Other columns, like the
+// click count incremented concurrently, are left alone.
+func (r *linkRepository) Update(link *models.Link) error {
+	return r.db.Model(link).Select("paused", "fallback_url", "updated_at").Updates(link).Error
+}
+
+func (r *linkRepository) GetVCard(linkID uint) (*models.VCard, error) {
+	var card models.VCard
+	err := r.db.Where("link_id = ?", linkID).First(&card).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &card, err
+}
+
+func (r *linkRepository) SaveVCard(card *models.VCard) error {
+	return r.db.Save(card).Error
+}
+
+func (r *linkRepository) AdoptGuestLinks(guestID string, userID uint) (int64, error) {
+	return r.AdoptGuestLinksWithTx(r.db, guestID, userID)
+}
+
+// AdoptGuestLinksWithTx gives unowned guest links to a user within a transaction
+func (r *linkRepository) AdoptGuestLinksWithTx(tx *gorm.DB, guestID string, userID uint) (int64, error) {
+	result := tx.Unscoped().Model(&models.Link{}).
+		Where("guest_id = ? AND user_id IS NULL", guestID).
+		Update("user_id", userID)
+	return result.RowsAffected, result.Error
+}
+
+func (r *linkRepository) GetAbandonedGuests(createdBefore, activeSince time.Time, limit int) ([]repository.AbandonedGuest, error) {
+	var guests []repository.AbandonedGuest
+	err := r.db.Unscoped().Model(&models.Link{}).
+		Select("guest_id, count(*) FILTER (WHERE expires_at < NOW() AND deleted_at IS NULL) AS expired_links").
+		Where("user_id IS NULL AND guest_id IS NOT NULL").
+		Group("guest_id").
+		Having("max(created_at) < ?", createdBefore).
+		Having(`NOT EXISTS (
+			SELECT 1 FROM links AS guest_links JOIN clicks ON clicks.link_id = guest_links.id
+			WHERE guest_links.guest_id = links.guest_id AND clicks.clicked_at >= ?)`, activeSince).
+		Order("guest_id").
+		Limit(limit).
+		Scan(&guests).Error
+	return guests, err
+}
+
+func (r *linkRepository) ArchiveGuestLinksWithTx(tx *gorm.DB, guestID string) (int64, error) {
+	result := tx.Where("guest_id = ? AND user_id IS NULL AND expires_at < NOW()", guestID).Delete(&models.Link{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	err := tx.Unscoped().Model(&models.Link{}).Where("guest_id = ? AND user_id IS NULL", guestID).
+		Update("guest_id", nil).Error
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected, nil
+}
+
+// TransferOwnershipWithTx moves links to another user within a transaction.
+// Clicks reference the link, so their history moves with it.
+func (r *linkRepository) TransferOwnershipWithTx(tx *gorm.DB, linkIDs []uint, fromUserID, toUserID uint) (int64, error) {
+	result := tx.Model(&models.Link{}).
+		Where("id IN ? AND user_id = ?", linkIDs, fromUserID).
+		Where("domain_id IS NULL OR domain_id IN (SELECT id FROM domains WHERE user_id = ? AND verified_at IS NOT NULL)", toUserID).
+		Update("user_id", toUserID)
+	return result.RowsAffected, result.Error
+}
+
 func (r *linkRepository) Delete(id uint) error {
 	return r.db.Delete(&models.Link{}, id).Error
 }
+
+// NextCodeSequence returns the next value of the short code sequence
+func (r *linkRepository) NextCodeSequence() (uint64, error) {
+	var next uint64
+	err := r.db.Raw("SELECT nextval('" + linkCodeSequence + "')").Scan(&next).Error
+	return next, err
+}
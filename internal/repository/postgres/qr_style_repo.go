@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/repository"
+)
+
+type qrStyleRepository struct {
+	db *gorm.DB
+}
+
+func NewQRStyleRepository(db *gorm.DB) repository.QRStyleRepository {
+	return &qrStyleRepository{db: db}
+}
+
+func (r *qrStyleRepository) Create(style *models.QRStyle) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(style).Error; err != nil {
+			return err
+		}
+		return clearOtherDefaults(tx, style)
+	})
+}
+
+func (r *qrStyleRepository) Update(style *models.QRStyle) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(style).Error; err != nil {
+			return err
+		}
+		return clearOtherDefaults(tx, style)
+	})
+}
+
+// clearOtherDefaults keeps at most one default style per user
+func clearOtherDefaults(tx *gorm.DB, style *models.QRStyle) error {
+	if !style.IsDefault {
+		return nil
+	}
+	return tx.Model(&models.QRStyle{}).
+		Where("user_id = ? AND id <> ? AND is_default", style.UserID, style.ID).
+		Update("is_default", false).Error
+}
+
+func (r *qrStyleRepository) GetByID(id uint) (*models.QRStyle, error) {
+	var style models.QRStyle
+	err := r.db.First(&style, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &style, err
+}
+
+func (r *qrStyleRepository) GetByUserID(userID uint) ([]*models.QRStyle, error) {
+	var styles []*models.QRStyle
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&styles).Error
+	return styles, err
+}
+
+func (r *qrStyleRepository) GetDefault(userID uint) (*models.QRStyle, error) {
+	var style models.QRStyle
+	err := r.db.Where("user_id = ? AND is_default", userID).First(&style).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &style, nil
+}
+
+func (r *qrStyleRepository) Delete(id uint) error {
+	return r.db.Delete(&models.QRStyle{}, id).Error
+}
+
+func (r *qrStyleRepository) GetLogo(userID uint) (*models.QRLogo, error) {
+	var logo models.QRLogo
+	err := r.db.Where("user_id = ?", userID).First(&logo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &logo, nil
+}
+
+func (r *qrStyleRepository) SaveLogo(logo *models.QRLogo) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "updated_at"}),
+	}).Create(logo).Error
+}
+
+func (r *qrStyleRepository) DeleteLogo(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.QRLogo{}).Error
+}
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/repository"
+)
+
+type linkTransferRepository struct {
+	db *gorm.DB
+}
+
+func NewLinkTransferRepository(db *gorm.DB) repository.LinkTransferRepository {
+	return &linkTransferRepository{db: db}
+}
+
+func (r *linkTransferRepository) Create(transfer *models.LinkTransfer) error {
+	return r.db.Create(transfer).Error
+}
+
+func (r *linkTransferRepository) GetByID(id uint) (*models.LinkTransfer, error) {
+	var transfer models.LinkTransfer
+	err := r.preload().First(&transfer, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &transfer, err
+}
+
+func (r *linkTransferRepository) GetPendingByUserID(userID uint) ([]*models.LinkTransfer, error) {
+	var transfers []*models.LinkTransfer
+	err := r.preload().
+		Where("status = ? AND (from_user_id = ? OR to_user_id = ?)", models.TransferPending, userID, userID).
+		Order("created_at DESC").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+func (r *linkTransferRepository) UpdateStatusWithTx(tx *gorm.DB, transfer *models.LinkTransfer) (bool, error) {
+	result := tx.Model(&models.LinkTransfer{}).
+		Where("id = ? AND status = ?", transfer.ID, models.TransferPending).
+		Updates(map[string]interface{}{
+			"status":       transfer.Status,
+			"responded_at": transfer.RespondedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *linkTransferRepository) preload() *gorm.DB {
+	return r.db.Preload("FromUser").Preload("ToUser").Preload("Links.Domain")
+}
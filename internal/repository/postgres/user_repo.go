@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -42,3 +43,94 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 func (r *userRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
+
+// MergeWithTx reassigns links (including soft-deleted ones), domains and
+// transfers of fromUserID to toUserID, then deletes fromUserID
+func (r *userRepository) MergeWithTx(tx *gorm.DB, fromUserID, toUserID uint) (int64, error) {
+	result := tx.Unscoped().Model(&models.Link{}).
+		Where("user_id = ?", fromUserID).
+		Update("user_id", toUserID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	moved := result.RowsAffected
+
+	if err := tx.Model(&models.Domain{}).Where("user_id = ?", fromUserID).Update("user_id", toUserID).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.LinkTransfer{}).Where("from_user_id = ?", fromUserID).Update("from_user_id", toUserID).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.LinkTransfer{}).Where("to_user_id = ?", fromUserID).Update("to_user_id", toUserID).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.QRStyle{}).Where("user_id = ?", fromUserID).
+		Updates(map[string]interface{}{"user_id": toUserID, "is_default": false}).Error; err != nil {
+		return 0, err
+	}
+	// The target user's own logo wins over the merged one
+	if err := tx.Model(&models.QRLogo{}).
+		Where("user_id = ? AND NOT EXISTS (SELECT 1 FROM qr_logos WHERE user_id = ?)", fromUserID, toUserID).
+		Update("user_id", toUserID).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("user_id = ?", fromUserID).Delete(&models.QRLogo{}).Error; err != nil {
+		return 0, err
+	}
+
+	if err := tx.Delete(&models.User{}, fromUserID).Error; err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+func (r *userRepository) GetInactiveGuests(createdBefore, activeSince time.Time, limit int) ([]*models.User, error) {
+	var users []*models.User
+	err := r.db.
+		Where("email LIKE ? AND created_at < ?", "guest\\_%@"+models.GuestEmailDomain, createdBefore).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM links JOIN clicks ON clicks.link_id = links.id
+			WHERE links.user_id = users.id AND clicks.clicked_at >= ?)`, activeSince).
+		Where("NOT EXISTS (SELECT 1 FROM domains WHERE domains.user_id = users.id)").
+		Where("NOT EXISTS (SELECT 1 FROM link_transfers WHERE link_transfers.from_user_id = users.id OR link_transfers.to_user_id = users.id)").
+		Order("id").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// expiredLinks selects the expired links of a user that are not archived yet
+func expiredLinks(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&models.Link{}).Where("user_id = ? AND expires_at < NOW()", userID)
+}
+
+func (r *userRepository) CountExpiredLinks(userID uint) (int64, error) {
+	var count int64
+	err := expiredLinks(r.db, userID).Count(&count).Error
+	return count, err
+}
+
+func (r *userRepository) ArchiveWithTx(tx *gorm.DB, userID uint) (int64, error) {
+	result := expiredLinks(tx, userID).Delete(&models.Link{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	// The guest is gone for good, so its links must not be collected again as
+	// links of a guest without a user row
+	err := tx.Unscoped().Model(&models.Link{}).Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"user_id": nil, "guest_id": nil}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.QRStyle{}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.QRLogo{}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Delete(&models.User{}, userID).Error; err != nil {
+		return 0, err
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/repository"
+)
+
+type visitorSaltRepository struct {
+	db *gorm.DB
+}
+
+func NewVisitorSaltRepository(db *gorm.DB) repository.VisitorSaltRepository {
+	return &visitorSaltRepository{db: db}
+}
+
+// GetOrCreate lets the first instance to reach a new day pick its salt
+func (r *visitorSaltRepository) GetOrCreate(day time.Time, salt []byte) ([]byte, error) {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.VisitorSalt{Day: day, Salt: salt}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var stored models.VisitorSalt
+	if err := r.db.Where("day = ?", day).First(&stored).Error; err != nil {
+		return nil, err
+	}
+	return stored.Salt, nil
+}
+
+func (r *visitorSaltRepository) DeleteBefore(day time.Time) error {
+	return r.db.Where("day < ?", day).Delete(&models.VisitorSalt{}).Error
+}
@@ -1,40 +1,191 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"quocbui.dev/m/internal/dto"
 	"quocbui.dev/m/internal/models"
 
 	"gorm.io/gorm"
 )
 
+// ErrDuplicateKey is returned when an insert violates a unique constraint
+var ErrDuplicateKey = errors.New("duplicate key")
+
 // TransactionManager handles database transactions
 type TransactionManager interface {
 	// ExecuteInTransaction runs the given function within a transaction
 	ExecuteInTransaction(fn func(tx *gorm.DB) error) error
 }
 
+// BotFilter selects human or bot traffic in a ClickFilter
+type BotFilter int
+
+const (
+	ExcludeBots BotFilter = iota // human clicks only
+	IncludeBots
+	OnlyBots
+)
+
+// ClickFilter selects clicks of a link; zero fields don't filter
+type ClickFilter struct {
+	From          time.Time // clicked_at >= From
+	To            time.Time // clicked_at < To
+	Bots          BotFilter
+	Country       string
+	Browser       string
+	Device        string
+	RefererSource string
+}
+
+// LinkClicks is the number of selected clicks of a link
+type LinkClicks struct {
+	LinkID uint
+	Clicks int64
+}
+
+// AbandonedGuest is an anonymous guest without a user row whose links can be collected
+type AbandonedGuest struct {
+	GuestID      string
+	ExpiredLinks int64 // links ArchiveGuestLinksWithTx would archive
+}
+
 type UserRepository interface {
 	Create(user *models.User) error
 	GetByID(id uint) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
 	Update(user *models.User) error
+	// MergeWithTx moves everything owned by fromUserID to toUserID, deletes
+	// fromUserID and returns the number of links moved
+	MergeWithTx(tx *gorm.DB, fromUserID, toUserID uint) (int64, error)
+	// GetInactiveGuests returns up to limit guest users created before createdBefore
+	// whose links have no clicks since activeSince
+	GetInactiveGuests(createdBefore, activeSince time.Time, limit int) ([]*models.User, error)
+	// CountExpiredLinks counts the links ArchiveWithTx would archive for the user
+	CountExpiredLinks(userID uint) (int64, error)
+	// ArchiveWithTx soft-deletes the user's expired links, detaches all their
+	// links from the user and its guest and deletes the user and their QR styles;
+	// unexpired links keep working and click history is kept. It returns the
+	// links newly archived.
+	ArchiveWithTx(tx *gorm.DB, userID uint) (int64, error)
 }
 
 type LinkRepository interface {
+	// Create and CreateWithTx return ErrDuplicateKey if the short code is taken
 	Create(link *models.Link) error
 	CreateWithTx(tx *gorm.DB, link *models.Link) error
 	GetByID(id uint) (*models.Link, error)
-	GetByShortCode(shortCode string) (*models.Link, error)
-	GetByShortCodeForUpdate(tx *gorm.DB, shortCode string) (*models.Link, error)
+	// domainID 0 selects links on the shared domain
+	GetByShortCode(domainID uint, shortCode string) (*models.Link, error)
+	GetByShortCodeForUpdate(tx *gorm.DB, domainID uint, shortCode string) (*models.Link, error)
+	// GetByShortCodes returns the links found among shortCodes, in no particular order
+	GetByShortCodes(domainID uint, shortCodes []string) ([]*models.Link, error)
 	GetByUserID(userID uint, page, pageSize int) ([]*models.Link, int64, error)
+	Update(link *models.Link) error
+	// GetVCard returns the contact card of a vCard link
+	GetVCard(linkID uint) (*models.VCard, error)
+	SaveVCard(card *models.VCard) error
+	// AdoptGuestLinks gives the guest's links that have no owner yet to userID
+	AdoptGuestLinks(guestID string, userID uint) (int64, error)
+	AdoptGuestLinksWithTx(tx *gorm.DB, guestID string, userID uint) (int64, error)
+	// GetAbandonedGuests returns up to limit guests whose unowned links were all
+	// created before createdBefore and have no clicks since activeSince
+	GetAbandonedGuests(createdBefore, activeSince time.Time, limit int) ([]AbandonedGuest, error)
+	// ArchiveGuestLinksWithTx soft-deletes the expired unowned links of a guest and
+	// detaches all of them from the guest, so they can no longer be claimed.
+	// It returns the links newly archived.
+	ArchiveGuestLinksWithTx(tx *gorm.DB, guestID string) (int64, error)
+	// TransferOwnershipWithTx moves the given links still owned by fromUserID to
+	// toUserID. Links on a branded domain only move if toUserID owns the domain.
+	TransferOwnershipWithTx(tx *gorm.DB, linkIDs []uint, fromUserID, toUserID uint) (int64, error)
 	IncrementClickCount(id uint) error
 	IncrementClickCountWithTx(tx *gorm.DB, id uint) error
 	Delete(id uint) error
+	// NextCodeSequence returns the next value of the short code sequence
+	NextCodeSequence() (uint64, error)
+}
+
+type DomainRepository interface {
+	// Create returns ErrDuplicateKey if the user already claimed the host
+	Create(domain *models.Domain) error
+	GetByID(id uint) (*models.Domain, error)
+	// GetVerifiedByHost returns the verified domain of a host
+	GetVerifiedByHost(host string) (*models.Domain, error)
+	GetByUserID(userID uint) ([]*models.Domain, error)
+	// MarkVerified saves the domain's verification time and deletes the other
+	// unverified claims of its host. It returns ErrDuplicateKey if another
+	// claim of the host is already verified.
+	MarkVerified(domain *models.Domain) error
+	Delete(id uint) error
+	// CountLinks counts links on the domain, including soft-deleted ones
+	CountLinks(id uint) (int64, error)
 }
 
 type ClickRepository interface {
 	Create(click *models.Click) error
 	CreateWithTx(tx *gorm.DB, click *models.Click) error
-	GetByLinkID(linkID uint, page, pageSize int) ([]*models.Click, int64, error)
-	GetAnalytics(linkID uint) (*dto.AnalyticsSummary, error)
+	// GetByLinkID returns a page of the selected clicks, newest first, and their total count
+	GetByLinkID(linkID uint, filter ClickFilter, page, pageSize int) ([]*models.Click, int64, error)
+	GetAnalytics(linkID uint, filter ClickFilter) (*dto.AnalyticsSummary, error)
+	// GetUserAnalytics aggregates the selected clicks of all links the user owns like GetAnalytics
+	GetUserAnalytics(userID uint, filter ClickFilter) (*dto.AnalyticsSummary, error)
+	// GetTimeSeries counts clicks per interval (a date_trunc field) aligned to the
+	// IANA time zone tz; only buckets with clicks are returned, in order
+	GetTimeSeries(linkID uint, interval string, filter ClickFilter, tz string) ([]dto.TimeSeriesPoint, error)
+	GetUserTimeSeries(userID uint, interval string, filter ClickFilter, tz string) ([]dto.TimeSeriesPoint, error)
+	// GetTopLinks returns up to limit of the user's links with the most selected clicks, most clicked first
+	GetTopLinks(userID uint, filter ClickFilter, limit int) ([]LinkClicks, error)
+	// GetUniqueVisitors counts distinct visitor hashes, estimating them with HyperLogLog if approximate is set
+	GetUniqueVisitors(linkID uint, filter ClickFilter, approximate bool) (*dto.UniqueVisitors, error)
+	// StreamByLinkID calls fn with each selected click, oldest first, reading rows
+	// from a cursor instead of loading them all; an error from fn stops the stream
+	StreamByLinkID(linkID uint, filter ClickFilter, fn func(*models.Click) error) error
+	// StreamByUserID streams the selected clicks of all links the user owns like StreamByLinkID
+	StreamByUserID(userID uint, filter ClickFilter, fn func(*models.Click) error) error
+}
+
+type ClickRollupRepository interface {
+	// IncrementWithTx counts a created click in the rollups of its link and day
+	IncrementWithTx(tx *gorm.DB, click *models.Click) error
+	// GetAnalytics sums the rollups of a link like ClickRepository.GetAnalytics.
+	// From and To must be UTC midnights; only they and Bots filter.
+	GetAnalytics(linkID uint, filter ClickFilter) (*dto.AnalyticsSummary, error)
+	// GetUserAnalytics sums the rollups of all links the user owns like GetAnalytics
+	GetUserAnalytics(userID uint, filter ClickFilter) (*dto.AnalyticsSummary, error)
+	// RebuildDay recomputes the rollups of the UTC day starting at day from its clicks
+	RebuildDay(day time.Time) error
+	// FirstClickDay returns the UTC day of the oldest click, or the zero time if there are none
+	FirstClickDay() (time.Time, error)
+}
+
+type VisitorSaltRepository interface {
+	// GetOrCreate stores salt for day unless one exists and returns the stored salt
+	GetOrCreate(day time.Time, salt []byte) ([]byte, error)
+	DeleteBefore(day time.Time) error
+}
+
+type LinkTransferRepository interface {
+	Create(transfer *models.LinkTransfer) error
+	GetByID(id uint) (*models.LinkTransfer, error)
+	// GetPendingByUserID returns pending transfers sent or received by the user
+	GetPendingByUserID(userID uint) ([]*models.LinkTransfer, error)
+	// UpdateStatusWithTx saves Status and RespondedAt if the transfer is still pending.
+	// It returns false if the transfer was already answered.
+	UpdateStatusWithTx(tx *gorm.DB, transfer *models.LinkTransfer) (bool, error)
+}
+
+type QRStyleRepository interface {
+	// Create and Update clear IsDefault on the user's other styles when saving a default style
+	Create(style *models.QRStyle) error
+	Update(style *models.QRStyle) error
+	GetByID(id uint) (*models.QRStyle, error)
+	GetByUserID(userID uint) ([]*models.QRStyle, error)
+	// GetDefault returns the user's default style, or nil if they have none
+	GetDefault(userID uint) (*models.QRStyle, error)
+	Delete(id uint) error
+	// GetLogo returns the user's uploaded logo, or nil if they have none
+	GetLogo(userID uint) (*models.QRLogo, error)
+	SaveLogo(logo *models.QRLogo) error
+	DeleteLogo(userID uint) error
 }
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"quocbui.dev/m/internal/dto"
+	"quocbui.dev/m/internal/models"
+)
+
+// MaxTopLinks limits the links ranked in the account overview
+const MaxTopLinks = 100
+
+// LinkClicks is a link with its clicks in the overview range
+type LinkClicks struct {
+	Link   *models.Link
+	Clicks int64
+}
+
+// AccountAnalytics is the overview of the human clicks on all of a user's links
+type AccountAnalytics struct {
+	Summary  *dto.AnalyticsSummary
+	Series   *dto.TimeSeriesResponse
+	TopLinks []LinkClicks
+}
+
+// GetAccountAnalytics aggregates the clicks of all links the user owns over a
+// time range, with clicks per interval and the topLinks most clicked links.
+// Open bounds default like GetTimeSeries, and the summary covers the same range.
+func (s *AnalyticsService) GetAccountAnalytics(userID uint, interval string, r TimeRange, topLinks int) (*AccountAnalytics, error) {
+	r, buckets, err := seriesBuckets(interval, r)
+	if err != nil {
+		return nil, err
+	}
+	filter := r.filter()
+
+	counts, err := s.clickRepo.GetUserTimeSeries(userID, interval, filter, r.Location.String())
+	if err != nil {
+		return nil, err
+	}
+	summary, err := aggregate(userID, filter, s.rollupRepo.GetUserAnalytics, s.clickRepo.GetUserAnalytics)
+	if err != nil {
+		return nil, err
+	}
+	summary.From, summary.To = &filter.From, &filter.To
+
+	top, err := s.clickRepo.GetTopLinks(userID, filter, min(max(topLinks, 1), MaxTopLinks))
+	if err != nil {
+		return nil, err
+	}
+	overview := &AccountAnalytics{
+		Summary:  summary,
+		Series:   zeroFill(interval, r, buckets, counts),
+		TopLinks: make([]LinkClicks, 0, len(top)),
+	}
+	for _, t := range top {
+		link, err := s.linkRepo.GetByID(t.LinkID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Deleted since the clicks were counted
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		overview.TopLinks = append(overview.TopLinks, LinkClicks{Link: link, Clicks: t.Clicks})
+	}
+	return overview, nil
+}
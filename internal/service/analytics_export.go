@@ -0,0 +1,217 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/repository"
+)
+
+// Click export formats
+const (
+	ClickExportCSV    = "csv"
+	ClickExportNDJSON = "ndjson"
+)
+
+// ClickExportOptions selects the clicks written by a ClickExport
+type ClickExportOptions struct {
+	Format      string
+	Range       TimeRange
+	IncludeBots bool
+	OmitPII     bool // leave out IP addresses and full user agents
+}
+
+// ClickExport writes the clicks of one link, or of all of a user's links, oldest first
+type ClickExport struct {
+	clickRepo repository.ClickRepository
+	linkID    uint // 0 exports all links of userID
+	userID    uint
+	codes     map[uint]string // short codes by link ID
+	filter    repository.ClickFilter
+	opts      ClickExportOptions
+}
+
+// clickRecord is one exported click
+type clickRecord struct {
+	ClickedAt     string `json:"clicked_at"`
+	LinkID        uint   `json:"link_id"`
+	ShortCode     string `json:"short_code"`
+	IPAddress     string `json:"ip_address,omitempty"`
+	UserAgent     string `json:"user_agent,omitempty"`
+	Browser       string `json:"browser"`
+	BrowserVer    string `json:"browser_version"`
+	OS            string `json:"os"`
+	Device        string `json:"device"`
+	Country       string `json:"country"`
+	CountryCode   string `json:"country_code"`
+	City          string `json:"city"`
+	Referer       string `json:"referer"`
+	RefererSource string `json:"referer_source"`
+	RefererDomain string `json:"referer_domain"`
+	Channel       string `json:"channel"`
+	VisitorHash   string `json:"visitor_hash"`
+	IsBot         bool   `json:"is_bot"`
+}
+
+// exportPageSize is the page size used to collect the short codes of a user's links
+const exportPageSize = 1000
+
+// PrepareClickExport checks that the user owns the link, or collects all their
+// links if linkID is 0, so that errors surface before anything is written
+func (s *AnalyticsService) PrepareClickExport(linkID, userID uint, opts ClickExportOptions) (*ClickExport, error) {
+	if opts.Format != ClickExportCSV && opts.Format != ClickExportNDJSON {
+		return nil, ErrInvalidExportFormat
+	}
+
+	codes := make(map[uint]string)
+	if linkID != 0 {
+		link, err := s.getOwnedLink(linkID, userID)
+		if err != nil {
+			return nil, err
+		}
+		codes[link.ID] = link.ShortCode
+	} else {
+		for page := 1; ; page++ {
+			links, total, err := s.linkRepo.GetByUserID(userID, page, exportPageSize)
+			if err != nil {
+				return nil, err
+			}
+			for _, link := range links {
+				codes[link.ID] = link.ShortCode
+			}
+			if len(links) == 0 || int64(page*exportPageSize) >= total {
+				break
+			}
+		}
+	}
+
+	filter := opts.Range.filter()
+	if opts.IncludeBots {
+		filter.Bots = repository.IncludeBots
+	}
+	return &ClickExport{
+		clickRepo: s.clickRepo,
+		linkID:    linkID,
+		userID:    userID,
+		codes:     codes,
+		filter:    filter,
+		opts:      opts,
+	}, nil
+}
+
+// ContentType returns the MIME type of the export
+func (e *ClickExport) ContentType() string {
+	if e.opts.Format == ClickExportNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Write streams the clicks to w as they are read from the database
+func (e *ClickExport) Write(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	write, flush := e.ndjsonWriter(buf)
+	if e.opts.Format == ClickExportCSV {
+		write, flush = e.csvWriter(buf)
+	}
+
+	fn := func(click *models.Click) error {
+		return write(e.record(click))
+	}
+	var err error
+	if e.linkID != 0 {
+		err = e.clickRepo.StreamByLinkID(e.linkID, e.filter, fn)
+	} else {
+		err = e.clickRepo.StreamByUserID(e.userID, e.filter, fn)
+	}
+	if err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+func (e *ClickExport) record(click *models.Click) *clickRecord {
+	record := &clickRecord{
+		ClickedAt:     click.ClickedAt.UTC().Format(time.RFC3339Nano),
+		LinkID:        click.LinkID,
+		ShortCode:     e.codes[click.LinkID],
+		Browser:       click.Browser,
+		BrowserVer:    click.BrowserVer,
+		OS:            click.OS,
+		Device:        click.Device,
+		Country:       click.Country,
+		CountryCode:   click.CountryCode,
+		City:          click.City,
+		Referer:       click.Referer,
+		RefererSource: click.RefererSource,
+		RefererDomain: click.RefererDomain,
+		Channel:       click.Channel,
+		VisitorHash:   click.VisitorHash,
+		IsBot:         click.IsBot,
+	}
+	if !e.opts.OmitPII {
+		record.IPAddress = click.IPAddress
+		record.UserAgent = click.UserAgent
+	}
+	return record
+}
+
+func (e *ClickExport) ndjsonWriter(w io.Writer) (func(*clickRecord) error, func() error) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	write := func(record *clickRecord) error { return enc.Encode(record) }
+	return write, func() error { return nil }
+}
+
+// csvWriter writes the header row at once, leaving out the PII columns when asked to
+func (e *ClickExport) csvWriter(w io.Writer) (func(*clickRecord) error, func() error) {
+	cw := csv.NewWriter(w)
+	header := []string{"clicked_at", "link_id", "short_code", "ip_address", "user_agent",
+		"browser", "browser_version", "os", "device", "country", "country_code", "city",
+		"referer", "referer_source", "referer_domain", "channel", "visitor_hash", "is_bot"}
+	if e.opts.OmitPII {
+		header = append(header[:3:3], header[5:]...)
+	}
+	headerErr := cw.Write(header)
+
+	write := func(record *clickRecord) error {
+		if headerErr != nil {
+			return headerErr
+		}
+		row := []string{record.ClickedAt, strconv.FormatUint(uint64(record.LinkID), 10), record.ShortCode}
+		if !e.opts.OmitPII {
+			row = append(row, csvText(record.IPAddress), csvText(record.UserAgent))
+		}
+		row = append(row, csvText(record.Browser), csvText(record.BrowserVer), csvText(record.OS),
+			record.Device, csvText(record.Country), record.CountryCode, csvText(record.City),
+			csvText(record.Referer), record.RefererSource, csvText(record.RefererDomain),
+			record.Channel, record.VisitorHash, strconv.FormatBool(record.IsBot))
+		return cw.Write(row)
+	}
+	flush := func() error {
+		if headerErr != nil {
+			return headerErr
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return write, flush
+}
+
+// csvText quotes values that spreadsheets would run as formulas, since visitors
+// control user agents and referers
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"quocbui.dev/m/internal/dto"
+	"quocbui.dev/m/internal/repository"
+)
+
+// maxRefererDomains is the number of referer domains a summary lists
+const maxRefererDomains = 10
+
+// summarizer aggregates the clicks of a link or a user selected by a filter
+type summarizer func(id uint, filter repository.ClickFilter) (*dto.AnalyticsSummary, error)
+
+// aggregate sums the selected clicks, reading whole UTC days from the rollups and
+// only the partial days at either end of the range from the raw clicks
+func aggregate(id uint, filter repository.ClickFilter, rollups, clicks summarizer) (*dto.AnalyticsSummary, error) {
+	days := filter
+	if !filter.From.IsZero() {
+		days.From = startOfDay(filter.From)
+		if days.From.Before(filter.From) {
+			days.From = days.From.AddDate(0, 0, 1)
+		}
+	}
+	if !filter.To.IsZero() {
+		days.To = startOfDay(filter.To)
+	}
+	if !days.From.IsZero() && !days.To.IsZero() && !days.From.Before(days.To) {
+		return clicks(id, filter)
+	}
+
+	summary, err := rollups(id, days)
+	if err != nil {
+		return nil, err
+	}
+	var edges []repository.ClickFilter
+	if filter.From.Before(days.From) {
+		head := filter
+		head.To = days.From
+		edges = append(edges, head)
+	}
+	if days.To.Before(filter.To) {
+		tail := filter
+		tail.From = days.To
+		edges = append(edges, tail)
+	}
+	for _, edge := range edges {
+		part, err := clicks(id, edge)
+		if err != nil {
+			return nil, err
+		}
+		mergeSummary(summary, part)
+	}
+	return summary, nil
+}
+
+// startOfDay returns the UTC midnight starting the day of t
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// mergeSummary adds the counts of src to dst. Each part lists its top referer
+// domains only, so the merged top list is trimmed again.
+func mergeSummary(dst, src *dto.AnalyticsSummary) {
+	dst.TotalClicks += src.TotalClicks
+	mergeCounts(&dst.Browsers, src.Browsers)
+	mergeCounts(&dst.OS, src.OS)
+	mergeCounts(&dst.Devices, src.Devices)
+	mergeCounts(&dst.Countries, src.Countries)
+	mergeCounts(&dst.RefererSources, src.RefererSources)
+	mergeCounts(&dst.RefererDomains, src.RefererDomains)
+	mergeCounts(&dst.Channels, src.Channels)
+
+	if len(dst.RefererDomains) > maxRefererDomains {
+		domains := make([]string, 0, len(dst.RefererDomains))
+		for domain := range dst.RefererDomains {
+			domains = append(domains, domain)
+		}
+		sort.Slice(domains, func(i, j int) bool {
+			a, b := dst.RefererDomains[domains[i]], dst.RefererDomains[domains[j]]
+			return a > b || a == b && domains[i] < domains[j]
+		})
+		for _, domain := range domains[maxRefererDomains:] {
+			delete(dst.RefererDomains, domain)
+		}
+	}
+}
+
+func mergeCounts(dst *map[string]int64, src map[string]int64) {
+	if len(src) == 0 {
+		return
+	}
+	if *dst == nil {
+		*dst = make(map[string]int64, len(src))
+	}
+	for value, n := range src {
+		(*dst)[value] += n
+	}
+}
+
+// RebuildRollups recomputes the daily rollups from the clicks, one UTC day at a
+// time from the day of since, or of the first click if since is zero, through
+// today. It returns the number of days rebuilt.
+func (s *AnalyticsService) RebuildRollups(since time.Time) (int, error) {
+	if since.IsZero() {
+		first, err := s.rollupRepo.FirstClickDay()
+		if err != nil {
+			return 0, err
+		}
+		if first.IsZero() {
+			return 0, nil
+		}
+		since = first
+	}
+
+	today := startOfDay(time.Now())
+	days := 0
+	for day := startOfDay(since); !day.After(today); day = day.AddDate(0, 0, 1) {
+		if err := s.rollupRepo.RebuildDay(day); err != nil {
+			return days, fmt.Errorf("failed to rebuild rollups of %s: %w", day.Format(time.DateOnly), err)
+		}
+		days++
+	}
+	return days, nil
+}
@@ -6,38 +6,105 @@ import (
 	"quocbui.dev/m/internal/repository"
 )
 
+// ExactVisitorCountLimit is the click count above which unique visitors of a
+// link are estimated with HyperLogLog instead of counted exactly
+const ExactVisitorCountLimit = 1_000_000
+
 // AnalyticsService handles analytics-related operations
 type AnalyticsService struct {
-	clickRepo repository.ClickRepository
-	linkRepo  repository.LinkRepository
+	clickRepo  repository.ClickRepository
+	rollupRepo repository.ClickRollupRepository
+	linkRepo   repository.LinkRepository
 }
 
 // NewAnalyticsService creates a new analytics service
-func NewAnalyticsService(clickRepo repository.ClickRepository, linkRepo repository.LinkRepository) *AnalyticsService {
+func NewAnalyticsService(clickRepo repository.ClickRepository, rollupRepo repository.ClickRollupRepository, linkRepo repository.LinkRepository) *AnalyticsService {
 	return &AnalyticsService{
-		clickRepo: clickRepo,
-		linkRepo:  linkRepo,
+		clickRepo:  clickRepo,
+		rollupRepo: rollupRepo,
+		linkRepo:   linkRepo,
 	}
 }
 
-// GetClicksByLinkID returns clicks for a specific link with pagination
-func (s *AnalyticsService) GetClicksByLinkID(linkID uint, userID uint, page, pageSize int) ([]*models.Click, int64, error) {
-	// Verify ownership
-	link, err := s.linkRepo.GetByID(linkID)
+// ClickLogFilter narrows the click log of a link; empty fields don't filter
+type ClickLogFilter struct {
+	Range         TimeRange
+	Country       string
+	Browser       string
+	Device        string
+	RefererSource string
+	IncludeBots   bool
+}
+
+// GetClicksByLinkID returns the selected clicks of a link, newest first, with pagination
+func (s *AnalyticsService) GetClicksByLinkID(linkID uint, userID uint, filter ClickLogFilter, page, pageSize int) ([]*models.Click, int64, error) {
+	if _, err := s.getOwnedLink(linkID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	clickFilter := filter.Range.filter()
+	clickFilter.Country = filter.Country
+	clickFilter.Browser = filter.Browser
+	clickFilter.Device = filter.Device
+	clickFilter.RefererSource = filter.RefererSource
+	if filter.IncludeBots {
+		clickFilter.Bots = repository.IncludeBots
+	}
+	return s.clickRepo.GetByLinkID(linkID, clickFilter, page, pageSize)
+}
+
+// GetAnalyticsSummary returns aggregated analytics for the clicks of a link in a time range.
+// Bot clicks are left out unless includeBots is set.
+func (s *AnalyticsService) GetAnalyticsSummary(linkID uint, userID uint, r TimeRange, includeBots bool) (*dto.AnalyticsSummary, error) {
+	link, err := s.getOwnedLink(linkID, userID)
 	if err != nil {
-		return nil, 0, ErrLinkNotFound
+		return nil, err
 	}
 
-	if link.UserID == nil || *link.UserID != userID {
-		return nil, 0, ErrUnauthorized
+	filter := r.filter()
+	if includeBots {
+		filter.Bots = repository.IncludeBots
+	}
+	summary, err := s.summarize(linkID, filter)
+	if err != nil {
+		return nil, err
 	}
+	summary.Visitors, err = s.clickRepo.GetUniqueVisitors(linkID, filter, link.ClickCount > ExactVisitorCountLimit)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
 
-	return s.clickRepo.GetByLinkID(linkID, page, pageSize)
+// GetBotTraffic returns aggregated analytics for the bot clicks of a link in a
+// time range; browsers name the crawlers and link preview fetchers
+func (s *AnalyticsService) GetBotTraffic(linkID uint, userID uint, r TimeRange) (*dto.AnalyticsSummary, error) {
+	if _, err := s.getOwnedLink(linkID, userID); err != nil {
+		return nil, err
+	}
+
+	filter := r.filter()
+	filter.Bots = repository.OnlyBots
+	return s.summarize(linkID, filter)
+}
+
+// summarize aggregates the selected clicks, echoing the time range
+func (s *AnalyticsService) summarize(linkID uint, filter repository.ClickFilter) (*dto.AnalyticsSummary, error) {
+	summary, err := aggregate(linkID, filter, s.rollupRepo.GetAnalytics, s.clickRepo.GetAnalytics)
+	if err != nil {
+		return nil, err
+	}
+	if !filter.From.IsZero() {
+		summary.From = &filter.From
+	}
+	if !filter.To.IsZero() {
+		summary.To = &filter.To
+	}
+	return summary, nil
 }
 
-// GetAnalyticsSummary returns aggregated analytics for a link
-func (s *AnalyticsService) GetAnalyticsSummary(linkID uint, userID uint) (*dto.AnalyticsSummary, error) {
-	// Verify ownership
+// getOwnedLink returns a link if the user owns it
+func (s *AnalyticsService) getOwnedLink(linkID, userID uint) (*models.Link, error) {
 	link, err := s.linkRepo.GetByID(linkID)
 	if err != nil {
 		return nil, ErrLinkNotFound
@@ -47,5 +114,5 @@ func (s *AnalyticsService) GetAnalyticsSummary(linkID uint, userID uint) (*dto.A
 		return nil, ErrUnauthorized
 	}
 
-	return s.clickRepo.GetAnalytics(linkID)
+	return link, nil
 }
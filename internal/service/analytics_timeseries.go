@@ -0,0 +1,142 @@
+package service
+
+import (
+	"time"
+
+	"quocbui.dev/m/internal/dto"
+)
+
+// Time series intervals, named after the Postgres date_trunc fields
+const (
+	IntervalHour  = "hour"
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// MaxTimeSeriesBuckets limits the points of one time series
+const MaxTimeSeriesBuckets = 1000
+
+// GetTimeSeries returns the clicks and unique visitors of a link per interval over a time range.
+// Open bounds default to now and to a span fitting the interval; buckets
+// without clicks are included with zero clicks.
+func (s *AnalyticsService) GetTimeSeries(linkID, userID uint, interval string, r TimeRange) (*dto.TimeSeriesResponse, error) {
+	if _, err := s.getOwnedLink(linkID, userID); err != nil {
+		return nil, err
+	}
+	r, buckets, err := seriesBuckets(interval, r)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.clickRepo.GetTimeSeries(linkID, interval, r.filter(), r.Location.String())
+	if err != nil {
+		return nil, err
+	}
+	return zeroFill(interval, r, buckets, counts), nil
+}
+
+// seriesBuckets fills in the open bounds of r and returns the bucket starts covering it
+func seriesBuckets(interval string, r TimeRange) (TimeRange, []time.Time, error) {
+	if !validInterval(interval) {
+		return r, nil, ErrInvalidInterval
+	}
+	if r.Location == nil {
+		r.Location = time.UTC
+	}
+	if r.To.IsZero() {
+		r.To = time.Now()
+	}
+	if r.From.IsZero() {
+		r.From = defaultSeriesStart(r.To, interval)
+	}
+	if !r.From.Before(r.To) {
+		return r, nil, ErrInvalidTimeRange
+	}
+
+	var buckets []time.Time
+	for t := truncateToInterval(r.From.In(r.Location), interval); t.Before(r.To); t = nextInterval(t, interval) {
+		if len(buckets) == MaxTimeSeriesBuckets {
+			return r, nil, ErrInvalidTimeRange
+		}
+		buckets = append(buckets, t)
+	}
+	return r, buckets, nil
+}
+
+// zeroFill lays the counted buckets out over all buckets of the series
+func zeroFill(interval string, r TimeRange, buckets []time.Time, counts []dto.TimeSeriesPoint) *dto.TimeSeriesResponse {
+	byBucket := make(map[int64]dto.TimeSeriesPoint, len(counts))
+	for _, point := range counts {
+		byBucket[point.Time.Unix()] = point
+	}
+
+	series := &dto.TimeSeriesResponse{
+		Interval: interval,
+		Timezone: r.Location.String(),
+		From:     r.From.In(r.Location),
+		To:       r.To.In(r.Location),
+		Points:   make([]dto.TimeSeriesPoint, len(buckets)),
+	}
+	for i, t := range buckets {
+		point := byBucket[t.Unix()]
+		series.Points[i] = dto.TimeSeriesPoint{Time: t, Clicks: point.Clicks, Visitors: point.Visitors}
+		series.Total += point.Clicks
+	}
+	return series
+}
+
+func validInterval(interval string) bool {
+	switch interval {
+	case IntervalHour, IntervalDay, IntervalWeek, IntervalMonth:
+		return true
+	}
+	return false
+}
+
+// defaultSeriesStart returns the start of the default range ending at to
+func defaultSeriesStart(to time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return to.Add(-48 * time.Hour)
+	case IntervalWeek:
+		return to.AddDate(0, 0, -7*12)
+	case IntervalMonth:
+		return to.AddDate(-1, 0, 0)
+	default:
+		return to.AddDate(0, 0, -30)
+	}
+}
+
+// truncateToInterval returns the start of the bucket holding t, matching
+// date_trunc in t's time zone; weeks start on Monday
+func truncateToInterval(t time.Time, interval string) time.Time {
+	y, m, d := t.Date()
+	switch interval {
+	case IntervalHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, t.Location())
+	case IntervalWeek:
+		return time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case IntervalMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// nextInterval returns the start of the bucket after the one starting at t
+func nextInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		// The hour repeated when clocks go back truncates to its first instance
+		if next := truncateToInterval(t.Add(time.Hour), interval); next.After(t) {
+			return next
+		}
+		return t.Add(time.Hour)
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
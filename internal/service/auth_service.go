@@ -1,14 +1,19 @@
 package service
 
 import (
+	"errors"
 	"fmt"
-	"time"
+
+	"gorm.io/gorm"
 
 	"quocbui.dev/m/internal/models"
 	"quocbui.dev/m/internal/repository"
 	"quocbui.dev/m/pkg/utils"
 )
 
+// guestIDLength is the length of random anonymous guest identifiers
+const guestIDLength = 24
+
 // AuthService handles authentication logic
 type AuthService struct {
 	userRepo  repository.UserRepository
@@ -91,26 +96,38 @@ func (s *AuthService) ValidateToken(tokenString string) (uint, error) {
 	return claims.UserID, nil
 }
 
-// CreateGuestUser creates a temporary guest user account
-func (s *AuthService) CreateGuestUser() (*models.User, string, error) {
-	guestEmail := fmt.Sprintf("guest_%d@temp.local", time.Now().UnixNano())
-	guestPass := fmt.Sprintf("guest_%d", time.Now().UnixNano())
-
-	user, err := s.Register(guestEmail, guestPass, "Guest")
+// CreateGuestToken creates a new anonymous guest identity.
+// Nothing is stored; the guest only exists in the signed token.
+func (s *AuthService) CreateGuestToken() (string, string, error) {
+	guestID, err := utils.GenerateShortCode(guestIDLength)
 	if err != nil {
-		return nil, "", err
+		return "", "", err
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Email, s.jwtSecret, s.jwtExpiry)
+	token, err := utils.GenerateGuestToken(guestID, s.jwtSecret, s.jwtExpiry)
 	if err != nil {
-		return nil, "", err
+		return "", "", err
 	}
 
-	return user, token, nil
+	return guestID, token, nil
+}
+
+// GuestUserID returns the ID of the user row materialized for a guest, or nil
+// if the guest only exists in its token
+func (s *AuthService) GuestUserID(guestID string) (*uint, error) {
+	user, err := s.userRepo.GetByEmail(GuestEmail(guestID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user.ID, nil
 }
 
-// GetUserFromToken extracts and validates user from authorization header
-func (s *AuthService) GetUserFromToken(authHeader string) (*uint, error) {
+// ParseAuthHeader validates the token in an authorization header and returns its claims.
+// An empty header returns nil claims and no error.
+func (s *AuthService) ParseAuthHeader(authHeader string) (*utils.Claims, error) {
 	if authHeader == "" {
 		return nil, nil
 	}
@@ -121,10 +138,15 @@ func (s *AuthService) GetUserFromToken(authHeader string) (*uint, error) {
 		tokenString = authHeader[7:]
 	}
 
-	userID, err := s.ValidateToken(tokenString)
+	claims, err := utils.ValidateToken(tokenString, s.jwtSecret)
 	if err != nil {
-		return nil, err
+		return nil, ErrInvalidToken
 	}
 
-	return &userID, nil
+	return claims, nil
+}
+
+// GuestEmail returns the email of the user row materialized for a guest
+func GuestEmail(guestID string) string {
+	return fmt.Sprintf("guest_%s@%s", guestID, models.GuestEmailDomain)
 }
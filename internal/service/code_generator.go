@@ -0,0 +1,117 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+
+	"quocbui.dev/m/internal/repository"
+	"quocbui.dev/m/pkg/utils"
+)
+
+// Short code generation strategies
+const (
+	CodeStrategyRandom     = "random"
+	CodeStrategySequential = "sequential"
+	CodeStrategyHashids    = "hashids"
+	CodeStrategyWords      = "words"
+)
+
+// CodeGenerator produces candidate short codes for new links
+type CodeGenerator interface {
+	// Generate returns a new code; length is a hint that some strategies ignore
+	Generate(length int) (string, error)
+}
+
+// NewCodeGenerator creates the code generator for the given strategy
+func NewCodeGenerator(strategy, alphabet, salt string, linkRepo repository.LinkRepository) (CodeGenerator, error) {
+	if !utils.ValidateAlphabet(alphabet) {
+		return nil, fmt.Errorf("invalid short code alphabet %q", alphabet)
+	}
+
+	switch strategy {
+	case "", CodeStrategyRandom:
+		return NewRandomCodeGenerator(alphabet), nil
+	case CodeStrategySequential:
+		return NewSequentialCodeGenerator(alphabet, linkRepo), nil
+	case CodeStrategyHashids:
+		return NewHashidsCodeGenerator(alphabet, salt, linkRepo), nil
+	case CodeStrategyWords:
+		return NewWordCodeGenerator(), nil
+	default:
+		return nil, fmt.Errorf("unknown short code strategy %q", strategy)
+	}
+}
+
+// RandomCodeGenerator picks every character at random from the alphabet
+type RandomCodeGenerator struct {
+	alphabet string
+}
+
+// NewRandomCodeGenerator creates a random code generator
+func NewRandomCodeGenerator(alphabet string) *RandomCodeGenerator {
+	return &RandomCodeGenerator{alphabet: alphabet}
+}
+
+// Generate returns a random code of exactly length characters
+func (g *RandomCodeGenerator) Generate(length int) (string, error) {
+	return utils.GenerateShortCodeFromAlphabet(g.alphabet, length)
+}
+
+// SequentialCodeGenerator encodes the next database sequence value in base-N.
+// Codes are as short as possible and grow with the number of links; length is ignored.
+type SequentialCodeGenerator struct {
+	alphabet string
+	linkRepo repository.LinkRepository
+}
+
+// NewSequentialCodeGenerator creates a sequential code generator
+func NewSequentialCodeGenerator(alphabet string, linkRepo repository.LinkRepository) *SequentialCodeGenerator {
+	return &SequentialCodeGenerator{alphabet: alphabet, linkRepo: linkRepo}
+}
+
+// Generate returns the base-N encoding of the next sequence value
+func (g *SequentialCodeGenerator) Generate(length int) (string, error) {
+	n, err := g.linkRepo.NextCodeSequence()
+	if err != nil {
+		return "", err
+	}
+	return utils.EncodeBaseN(new(big.Int).SetUint64(n), g.alphabet, 0), nil
+}
+
+// HashidsCodeGenerator obfuscates the next database sequence value so codes
+// are unique without collisions but do not reveal how many links exist.
+// length is the minimum code length.
+type HashidsCodeGenerator struct {
+	alphabet string
+	salt     string
+	linkRepo repository.LinkRepository
+}
+
+// NewHashidsCodeGenerator creates an obfuscated ID code generator
+func NewHashidsCodeGenerator(alphabet, salt string, linkRepo repository.LinkRepository) *HashidsCodeGenerator {
+	return &HashidsCodeGenerator{alphabet: alphabet, salt: salt, linkRepo: linkRepo}
+}
+
+// Generate returns the obfuscated form of the next sequence value
+func (g *HashidsCodeGenerator) Generate(length int) (string, error) {
+	n, err := g.linkRepo.NextCodeSequence()
+	if err != nil {
+		return "", err
+	}
+	return utils.ObfuscateID(n, g.alphabet, g.salt, length), nil
+}
+
+// WordCodeGenerator produces readable codes like "brave-otter-42". The number
+// has length-4 digits (2 to 5), so the code space grows with the code length
+// when collisions become frequent.
+type WordCodeGenerator struct{}
+
+// NewWordCodeGenerator creates a word-based code generator
+func NewWordCodeGenerator() *WordCodeGenerator {
+	return &WordCodeGenerator{}
+}
+
+// Generate returns a random adjective-noun-number code
+func (g *WordCodeGenerator) Generate(length int) (string, error) {
+	return utils.GenerateReadableCodeWithDigits(length - 4)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"log"
+	"sync"
+)
+
+const (
+	// collisionRateAlpha is the weight of the newest sample in the moving average
+	collisionRateAlpha = 0.05
+	// collisionRateThreshold is the recent collision rate that triggers longer codes
+	collisionRateThreshold = 0.1
+	// minCollisionSamples is the number of inserts observed before growing again
+	minCollisionSamples = 20
+	// maxShortCodeLength matches the size of links.short_code
+	maxShortCodeLength = 20
+)
+
+// CollisionStats is a snapshot of short code collision metrics
+type CollisionStats struct {
+	Attempts    int64   `json:"attempts"`
+	Collisions  int64   `json:"collisions"`
+	RecentRate  float64 `json:"recent_collision_rate"`
+	ExtraLength int     `json:"extra_length"`
+}
+
+// CollisionTracker records the outcome of generated short code inserts and
+// grows the code length when the recent collision rate shows that the
+// keyspace is filling up. State is kept in memory and relearned after a restart.
+type CollisionTracker struct {
+	mu          sync.Mutex
+	attempts    int64
+	collisions  int64
+	rate        float64 // exponentially weighted moving average of collisions
+	samples     int     // inserts observed since the length last grew
+	extraLength int
+}
+
+// NewCollisionTracker creates an empty collision tracker
+func NewCollisionTracker() *CollisionTracker {
+	return &CollisionTracker{}
+}
+
+// Record registers one insert attempt and whether it collided
+func (t *CollisionTracker) Record(collided bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sample := 0.0
+	t.attempts++
+	if collided {
+		t.collisions++
+		sample = 1
+	}
+	t.rate = t.rate*(1-collisionRateAlpha) + sample*collisionRateAlpha
+	t.samples++
+
+	if t.samples >= minCollisionSamples && t.rate > collisionRateThreshold && t.extraLength < maxShortCodeLength {
+		t.extraLength++
+		log.Printf("Short code collision rate %.2f above %.2f, growing code length by %d", t.rate, collisionRateThreshold, t.extraLength)
+		t.rate = 0
+		t.samples = 0
+	}
+}
+
+// Length returns the code length to use for a configured base length
+func (t *CollisionTracker) Length(base int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return min(base+t.extraLength, maxShortCodeLength)
+}
+
+// Stats returns a snapshot of the collision metrics
+func (t *CollisionTracker) Stats() CollisionStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return CollisionStats{
+		Attempts:    t.attempts,
+		Collisions:  t.collisions,
+		RecentRate:  t.rate,
+		ExtraLength: t.extraLength,
+	}
+}
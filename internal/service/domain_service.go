@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/repository"
+	"quocbui.dev/m/pkg/utils"
+)
+
+// Domain verification methods
+const (
+	DomainVerificationDNS  = "dns"
+	DomainVerificationHTTP = "http"
+)
+
+const (
+	domainTXTPrefix        = "_shorten-verify."
+	domainVerificationPath = "/.well-known/shorten-verify.txt"
+	domainTokenAlphabet    = "0123456789abcdef"
+	domainTokenLength      = 32
+)
+
+// DomainTXTRecordName returns the DNS name that must hold the verification token
+func DomainTXTRecordName(host string) string {
+	return domainTXTPrefix + host
+}
+
+// DomainVerificationURL returns the URL that must serve the verification token
+func DomainVerificationURL(host string) string {
+	return "http://" + host + domainVerificationPath
+}
+
+// DomainVerifier proves that the owner of a domain controls it
+type DomainVerifier interface {
+	// Verify returns nil if the domain's verification token is published
+	Verify(domain *models.Domain) error
+}
+
+// TXTResolver looks up DNS TXT records; *net.Resolver implements it
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DNSDomainVerifier looks for the token in a TXT record on _shorten-verify.<host>
+type DNSDomainVerifier struct {
+	resolver TXTResolver
+}
+
+// NewDNSDomainVerifier creates a DNS TXT record verifier
+func NewDNSDomainVerifier(resolver TXTResolver) *DNSDomainVerifier {
+	return &DNSDomainVerifier{resolver: resolver}
+}
+
+// Verify checks the TXT records of the domain
+func (v *DNSDomainVerifier) Verify(domain *models.Domain) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	records, err := v.resolver.LookupTXT(ctx, DomainTXTRecordName(domain.Host))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDomainVerificationFailed, err)
+	}
+	for _, record := range records {
+		if strings.TrimSpace(record) == domain.VerificationToken {
+			return nil
+		}
+	}
+	return ErrDomainVerificationFailed
+}
+
+// HTTPDomainVerifier fetches the token from /.well-known/shorten-verify.txt on the domain
+type HTTPDomainVerifier struct {
+	scheme string
+	client *http.Client
+}
+
+// NewHTTPDomainVerifier creates an HTTP token verifier using the given URL scheme.
+// Hosts are user supplied, so only public addresses are dialed.
+func NewHTTPDomainVerifier(scheme string) *HTTPDomainVerifier {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: dialPublicOnly,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return NewHTTPDomainVerifierWithClient(scheme, &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: transport,
+	})
+}
+
+// NewHTTPDomainVerifierWithClient creates an HTTP token verifier sending its
+// requests with client. Redirects are never followed.
+func NewHTTPDomainVerifierWithClient(scheme string, client *http.Client) *HTTPDomainVerifier {
+	c := *client
+	c.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &HTTPDomainVerifier{scheme: scheme, client: &c}
+}
+
+// dialPublicOnly rejects connections to loopback, private and link-local
+// addresses after the host name has been resolved
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !utils.IsPublicIP(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
+
+// Verify requests the token file from the domain
+func (v *HTTPDomainVerifier) Verify(domain *models.Domain) error {
+	resp, err := v.client.Get(v.scheme + "://" + domain.Host + domainVerificationPath)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDomainVerificationFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ErrDomainVerificationFailed
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil || strings.TrimSpace(string(body)) != domain.VerificationToken {
+		return ErrDomainVerificationFailed
+	}
+	return nil
+}
+
+// DomainService manages branded domains owned by users
+type DomainService struct {
+	domainRepo repository.DomainRepository
+	verifiers  map[string]DomainVerifier
+	ownDomains []string
+}
+
+// NewDomainService creates a new domain service
+// verifiers maps a verification method (dns, http) to its verifier
+func NewDomainService(domainRepo repository.DomainRepository, verifiers map[string]DomainVerifier, ownDomains []string) *DomainService {
+	return &DomainService{
+		domainRepo: domainRepo,
+		verifiers:  verifiers,
+		ownDomains: ownDomains,
+	}
+}
+
+// AddDomain registers an unverified claim of a domain for a user. Other users
+// may claim the same host until one of them verifies it.
+func (s *DomainService) AddDomain(userID uint, host, method string) (*models.Domain, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if !utils.ValidateHostname(host) || utils.HostMatchesAny(host, s.ownDomains) {
+		return nil, ErrInvalidDomain
+	}
+	if _, err := s.domainRepo.GetVerifiedByHost(host); err == nil {
+		return nil, ErrDomainAlreadyExists
+	}
+
+	if method == "" {
+		method = DomainVerificationDNS
+	}
+	if _, ok := s.verifiers[method]; !ok {
+		return nil, ErrInvalidDomain
+	}
+
+	token, err := utils.GenerateShortCodeFromAlphabet(domainTokenAlphabet, domainTokenLength)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := &models.Domain{
+		UserID:             userID,
+		Host:               host,
+		VerificationMethod: method,
+		VerificationToken:  token,
+	}
+	if err := s.domainRepo.Create(domain); err != nil {
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return nil, ErrDomainAlreadyExists
+		}
+		return nil, err
+	}
+	return domain, nil
+}
+
+// VerifyDomain checks that the user published the verification token.
+// The other pending claims of the host are removed once it is verified.
+func (s *DomainService) VerifyDomain(userID, domainID uint) (*models.Domain, error) {
+	domain, err := s.getOwnedDomain(userID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if domain.IsVerified() {
+		return domain, nil
+	}
+
+	if _, err := s.domainRepo.GetVerifiedByHost(domain.Host); err == nil {
+		return nil, ErrDomainAlreadyExists
+	}
+
+	verifier, ok := s.verifiers[domain.VerificationMethod]
+	if !ok {
+		return nil, ErrDomainVerificationFailed
+	}
+	if err := verifier.Verify(domain); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	domain.VerifiedAt = &now
+	if err := s.domainRepo.MarkVerified(domain); err != nil {
+		domain.VerifiedAt = nil
+		if errors.Is(err, repository.ErrDuplicateKey) {
+			return nil, ErrDomainAlreadyExists
+		}
+		return nil, err
+	}
+	return domain, nil
+}
+
+// ListDomains returns all domains of a user
+func (s *DomainService) ListDomains(userID uint) ([]*models.Domain, error) {
+	return s.domainRepo.GetByUserID(userID)
+}
+
+// DeleteDomain removes a domain that no longer has links
+func (s *DomainService) DeleteDomain(userID, domainID uint) error {
+	if _, err := s.getOwnedDomain(userID, domainID); err != nil {
+		return err
+	}
+
+	count, err := s.domainRepo.CountLinks(domainID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDomainInUse
+	}
+	return s.domainRepo.Delete(domainID)
+}
+
+// ResolveHost returns the verified domain serving a host.
+// The shared domains (and an empty host) resolve to nil.
+func (s *DomainService) ResolveHost(host string) (*models.Domain, error) {
+	if host == "" || utils.HostMatchesAny(host, s.ownDomains) {
+		return nil, nil
+	}
+
+	domain, err := s.domainRepo.GetVerifiedByHost(strings.ToLower(utils.StripPort(host)))
+	if err != nil || domain == nil {
+		return nil, ErrDomainNotFound
+	}
+	return domain, nil
+}
+
+// ResolveForUser returns the verified domain a user may create links on.
+// An empty host selects the shared domain and resolves to nil.
+func (s *DomainService) ResolveForUser(userID *uint, host string) (*models.Domain, error) {
+	domain, err := s.ResolveHost(host)
+	if err != nil || domain == nil {
+		return nil, err
+	}
+	if userID == nil || domain.UserID != *userID {
+		return nil, ErrDomainNotFound
+	}
+	return domain, nil
+}
+
+func (s *DomainService) getOwnedDomain(userID, domainID uint) (*models.Domain, error) {
+	domain, err := s.domainRepo.GetByID(domainID)
+	if err != nil {
+		return nil, ErrDomainNotFound
+	}
+	if domain.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	return domain, nil
+}
@@ -9,7 +9,43 @@ var (
 	ErrInvalidURL         = errors.New("invalid URL")
 	ErrInvalidAlias       = errors.New("invalid alias")
 	ErrAliasAlreadyExists = errors.New("alias already exists")
+	ErrAliasReserved      = errors.New("alias is reserved")
+	ErrShortCodeExhausted = errors.New("could not generate a unique short code")
 	ErrUnauthorized       = errors.New("unauthorized")
 	ErrLinkExpired        = errors.New("link has expired")
+	ErrLinkPaused         = errors.New("link is paused")
 	ErrInvalidToken       = errors.New("invalid token")
+	ErrInvalidGuestToken  = errors.New("invalid guest token")
+	ErrURLBlocked         = errors.New("URL is flagged as malicious")
+	ErrLinkSuspicious     = errors.New("link destination is flagged as suspicious")
+	ErrRedirectLoop       = errors.New("URL leads back to the short link or through too many short links")
+	ErrTargetLinkNotFound = errors.New("URL points to a short link that does not exist")
+	ErrShortenerChain     = errors.New("URL points to another URL shortener")
+	ErrInvalidQROptions   = errors.New("invalid QR code options")
+
+	ErrInvalidQRStyle  = errors.New("invalid QR style")
+	ErrQRStyleNotFound = errors.New("QR style not found")
+	ErrLowQRContrast   = errors.New("QR colors do not have enough contrast to scan")
+	ErrInvalidLogo     = errors.New("logo must be a PNG or JPEG image")
+	ErrLogoNotFound    = errors.New("logo not found")
+
+	ErrInvalidQRPayload = errors.New("invalid QR payload")
+	ErrInvalidVCard     = errors.New("invalid vCard")
+
+	ErrInvalidTimeRange    = errors.New("invalid time range")
+	ErrInvalidTimezone     = errors.New("invalid time zone")
+	ErrInvalidInterval     = errors.New("invalid interval")
+	ErrInvalidExportFormat = errors.New("invalid export format")
+
+	ErrInvalidTransfer    = errors.New("invalid transfer")
+	ErrRecipientNotFound  = errors.New("recipient not found")
+	ErrTransferNotFound   = errors.New("transfer not found")
+	ErrTransferNotPending = errors.New("transfer is no longer pending")
+	ErrTransferDomain     = errors.New("links on a branded domain can only be transferred to the domain's owner")
+
+	ErrInvalidDomain            = errors.New("invalid domain")
+	ErrDomainNotFound           = errors.New("domain not found")
+	ErrDomainAlreadyExists      = errors.New("domain already exists")
+	ErrDomainVerificationFailed = errors.New("domain verification failed")
+	ErrDomainInUse              = errors.New("domain still has links")
 )
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/repository"
+)
+
+// GuestGCPolicy selects which abandoned guest accounts and guest links are removed
+type GuestGCPolicy struct {
+	TokenLifetime time.Duration // guests older than this can no longer use their token
+	InactiveFor   time.Duration // guests with clicks within this window are kept
+	BatchSize     int           // maximum guests of each kind removed per run
+	DryRun        bool          // report without removing anything
+}
+
+// GuestGCReport summarizes a guest cleanup run
+type GuestGCReport struct {
+	DryRun    bool
+	Guests    int   // guest accounts removed, or that would be removed
+	Anonymous int   // guests without an account whose links were collected, or would be
+	Links     int64 // expired links archived, or that would be archived
+}
+
+// Guest is an anonymous identity from a guest token.
+// User is set once the guest has been materialized as a user row.
+type Guest struct {
+	ID   string
+	User *models.User
+}
+
+// GuestService manages anonymous guests. Guests live only in signed tokens
+// until they use an authenticated endpoint, which materializes a user row.
+type GuestService struct {
+	userRepo    repository.UserRepository
+	linkRepo    repository.LinkRepository
+	txManager   repository.TransactionManager
+	authService *AuthService
+}
+
+// NewGuestService creates a new guest service
+func NewGuestService(
+	userRepo repository.UserRepository,
+	linkRepo repository.LinkRepository,
+	txManager repository.TransactionManager,
+	authService *AuthService,
+) *GuestService {
+	return &GuestService{
+		userRepo:    userRepo,
+		linkRepo:    linkRepo,
+		txManager:   txManager,
+		authService: authService,
+	}
+}
+
+// MaterializeGuest returns the user row of a guest, creating it on first use.
+// The links the guest created until then are adopted once, with the row; later
+// links get the user when they are created. No password is set, so the account
+// can only be used with the guest token.
+func (s *GuestService) MaterializeGuest(guestID string) (uint, error) {
+	email := GuestEmail(guestID)
+	if user, err := s.userRepo.GetByEmail(email); err == nil {
+		return user.ID, nil
+	}
+
+	user := &models.User{Email: email, Name: "Guest"}
+	if err := s.userRepo.Create(user); err != nil {
+		// A concurrent request may have created it first, and adopts the links
+		existing, getErr := s.userRepo.GetByEmail(email)
+		if getErr != nil {
+			return 0, err
+		}
+		return existing.ID, nil
+	}
+
+	if _, err := s.linkRepo.AdoptGuestLinks(guestID, user.ID); err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// ResolveGuest returns the guest a token was issued to.
+// Tokens of registered users are rejected so real accounts are never merged.
+func (s *GuestService) ResolveGuest(guestToken string) (*Guest, error) {
+	claims, err := s.authService.ParseAuthHeader(guestToken)
+	if err != nil || claims == nil {
+		return nil, ErrInvalidGuestToken
+	}
+
+	if claims.IsGuest() {
+		guest := &Guest{ID: claims.GuestID}
+		if user, err := s.userRepo.GetByEmail(GuestEmail(claims.GuestID)); err == nil {
+			guest.User = user
+		}
+		return guest, nil
+	}
+
+	// Tokens issued before guests became stateless point to a guest user row
+	user, err := s.userRepo.GetByID(claims.UserID)
+	if err != nil || !user.IsGuest() {
+		return nil, ErrInvalidGuestToken
+	}
+	return &Guest{User: user}, nil
+}
+
+// ClaimLinks moves all links of the guest to the user and deletes the guest's
+// user row, if any, in one transaction. It returns the number of links claimed.
+func (s *GuestService) ClaimLinks(guest *Guest, userID uint) (int64, error) {
+	if guest.User != nil && guest.User.ID == userID {
+		return 0, nil
+	}
+
+	var claimed int64
+	err := s.txManager.ExecuteInTransaction(func(tx *gorm.DB) error {
+		if guest.User != nil {
+			merged, err := s.userRepo.MergeWithTx(tx, guest.User.ID, userID)
+			if err != nil {
+				return err
+			}
+			claimed += merged
+		}
+		if guest.ID != "" {
+			adopted, err := s.linkRepo.AdoptGuestLinksWithTx(tx, guest.ID, userID)
+			if err != nil {
+				return err
+			}
+			claimed += adopted
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return claimed, nil
+}
+
+// CollectGarbage archives guest accounts whose token has expired and whose
+// links have not been clicked recently, and then the links of such guests that
+// never got an account. Expired links are soft-deleted and the others keep
+// working without an owner; click history is kept. Each guest is removed in
+// its own transaction.
+func (s *GuestService) CollectGarbage(policy GuestGCPolicy) (*GuestGCReport, error) {
+	now := time.Now()
+	guests, err := s.userRepo.GetInactiveGuests(now.Add(-policy.TokenLifetime), now.Add(-policy.InactiveFor), policy.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &GuestGCReport{DryRun: policy.DryRun}
+	for _, guest := range guests {
+		if !guest.IsGuest() {
+			continue
+		}
+
+		if policy.DryRun {
+			expired, err := s.userRepo.CountExpiredLinks(guest.ID)
+			if err != nil {
+				return report, err
+			}
+			log.Printf("Guest GC (dry run): would remove guest %d created %s with %d expired links",
+				guest.ID, guest.CreatedAt.Format(time.RFC3339), expired)
+			report.Guests++
+			report.Links += expired
+			continue
+		}
+
+		var archived int64
+		err := s.txManager.ExecuteInTransaction(func(tx *gorm.DB) error {
+			var err error
+			archived, err = s.userRepo.ArchiveWithTx(tx, guest.ID)
+			return err
+		})
+		if err != nil {
+			return report, err
+		}
+		report.Guests++
+		report.Links += archived
+	}
+
+	if err := s.collectGuestLinks(policy, now, report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// collectGuestLinks archives the links of guests that never got a user row,
+// with the same rules as guest accounts
+func (s *GuestService) collectGuestLinks(policy GuestGCPolicy, now time.Time, report *GuestGCReport) error {
+	guests, err := s.linkRepo.GetAbandonedGuests(now.Add(-policy.TokenLifetime), now.Add(-policy.InactiveFor), policy.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, guest := range guests {
+		if policy.DryRun {
+			log.Printf("Guest GC (dry run): would collect links of guest %s with %d expired links",
+				guest.GuestID, guest.ExpiredLinks)
+			report.Anonymous++
+			report.Links += guest.ExpiredLinks
+			continue
+		}
+
+		var archived int64
+		err := s.txManager.ExecuteInTransaction(func(tx *gorm.DB) error {
+			var err error
+			archived, err = s.linkRepo.ArchiveGuestLinksWithTx(tx, guest.GuestID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		report.Anonymous++
+		report.Links += archived
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package service
 
 import (
+	"errors"
 	"log"
+	"net/url"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,8 +18,44 @@ type ClickInfo struct {
 	IPAddress string
 	UserAgent string
 	Referer   string
+	// Host the request was made to, selects the link's domain
+	Host string
+	// Source is the marker carried by the short URL, see QRScanURL
+	Source string
+
+	// WarningAccepted is set once the visitor confirmed the suspicious link interstitial
+	WarningAccepted bool
+}
+
+// LinkServiceConfig holds policies applied when creating and resolving links
+type LinkServiceConfig struct {
+	// Domains that serve our short links (primary and alias domains)
+	OwnDomains []string
+	// Third-party URL shorteners that links may not point to
+	ShortenerDomains []string
+	// Re-run the URL reputation check on every redirect
+	ReputationCheckOnRedirect bool
+	// Codes that neither custom aliases nor generated codes may use
+	Reserved *utils.ReservedWords
+	// Strategy for generated short codes, random with the default alphabet if nil
+	CodeGenerator CodeGenerator
+	// Branded user domains, only the shared domains are served if nil
+	CustomDomains *DomainService
+	// Anonymous visitor IDs for unique visitor counts, none are recorded if nil
+	Visitors *VisitorHasher
+	// Daily click rollups that analytics summaries are read from, updated with every click
+	Rollups repository.ClickRollupRepository
 }
 
+// defaultAlphabet is used when no code generator is configured
+const defaultAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// maxCreateAttempts is how many generated codes are tried before giving up
+const maxCreateAttempts = 5
+
+// maxChainDepth limits how many of our own short links are followed when flattening
+const maxChainDepth = 5
+
 // LinkService handles link-related business logic
 type LinkService struct {
 	linkRepo    repository.LinkRepository
@@ -24,6 +63,9 @@ type LinkService struct {
 	txManager   repository.TransactionManager
 	geoIP       *GeoIPService
 	authService *AuthService
+	reputation  URLReputationChecker
+	config      LinkServiceConfig
+	collisions  *CollisionTracker
 }
 
 // NewLinkService creates a new link service
@@ -33,51 +75,107 @@ func NewLinkService(
 	txManager repository.TransactionManager,
 	geoIP *GeoIPService,
 	authService *AuthService,
+	reputation URLReputationChecker,
+	config LinkServiceConfig,
 ) *LinkService {
+	if config.CodeGenerator == nil {
+		config.CodeGenerator = NewRandomCodeGenerator(defaultAlphabet)
+	}
 	return &LinkService{
 		linkRepo:    linkRepo,
 		clickRepo:   clickRepo,
 		txManager:   txManager,
 		geoIP:       geoIP,
 		authService: authService,
+		reputation:  reputation,
+		config:      config,
+		collisions:  NewCollisionTracker(),
 	}
 }
 
-// CreateLink creates a new shortened link with transaction support
-// Uses SELECT FOR UPDATE to prevent race conditions on custom aliases
+// CreateLink creates a new shortened link on the shared domain
 func (s *LinkService) CreateLink(originalURL string, customAlias *string, userID *uint, expiresAt *time.Time, shortCodeLength int) (*models.Link, error) {
+	return s.CreateLinkOnDomain("", originalURL, customAlias, userID, expiresAt, shortCodeLength)
+}
+
+// CreateLinkOnDomain creates a new shortened link with transaction support
+// domainHost selects one of the user's verified domains, empty for the shared domain
+// Uses SELECT FOR UPDATE to prevent race conditions on custom aliases
+func (s *LinkService) CreateLinkOnDomain(domainHost string, originalURL string, customAlias *string, userID *uint, expiresAt *time.Time, shortCodeLength int) (*models.Link, error) {
+	return s.createLink(domainHost, originalURL, customAlias, userID, nil, expiresAt, shortCodeLength)
+}
+
+// createLink creates a link owned by a user or, when guestID is set, by an anonymous guest
+func (s *LinkService) createLink(domainHost string, originalURL string, customAlias *string, userID *uint, guestID *string, expiresAt *time.Time, shortCodeLength int) (*models.Link, error) {
 	// Validate URL
 	if !utils.ValidateURL(originalURL) {
 		return nil, ErrInvalidURL
 	}
 
-	var shortCode string
-	var err error
-	var link *models.Link
+	domain, err := s.resolveDomainForUser(userID, domainHost)
+	if err != nil {
+		return nil, err
+	}
+
+	// Links to our own short links are flattened to their final destination
+	self := linkRef{domainID: domainKey(domain)}
+	if customAlias != nil {
+		self.code = *customAlias
+	}
+	originalURL, err = s.resolveChain(originalURL, self)
+	if err != nil {
+		return nil, err
+	}
+
+	suspicious, err := s.checkReputation(originalURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storeLink(&models.Link{
+		UserID:      userID,
+		GuestID:     guestID,
+		DomainID:    domainIDPtr(domain),
+		Domain:      domain,
+		Type:        models.LinkTypeURL,
+		OriginalURL: originalURL,
+		ExpiresAt:   expiresAt,
+		Suspicious:  suspicious,
+	}, customAlias, shortCodeLength)
+}
+
+// storeLink saves a new link under the custom alias if given, otherwise under a generated short code
+func (s *LinkService) storeLink(link *models.Link, customAlias *string, shortCodeLength int) (*models.Link, error) {
+	domainID := domainKey(link.Domain)
+	link.CustomAlias = customAlias
 
 	// Use custom alias if provided - needs transaction to prevent race condition
 	if customAlias != nil && *customAlias != "" {
 		if !utils.ValidateAlias(*customAlias) {
 			return nil, ErrInvalidAlias
 		}
+		if s.config.Reserved.IsReservedAlias(*customAlias) {
+			return nil, ErrAliasReserved
+		}
+		link.ShortCode = *customAlias
 
 		// Use transaction with row-level locking to prevent duplicate aliases
-		err = s.txManager.ExecuteInTransaction(func(tx *gorm.DB) error {
+		err := s.txManager.ExecuteInTransaction(func(tx *gorm.DB) error {
 			// Check if alias already exists with FOR UPDATE lock
-			existing, _ := s.linkRepo.GetByShortCodeForUpdate(tx, *customAlias)
+			existing, err := s.linkRepo.GetByShortCodeForUpdate(tx, domainID, *customAlias)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
 			if existing != nil {
 				return ErrAliasAlreadyExists
 			}
 
-			link = &models.Link{
-				UserID:      userID,
-				ShortCode:   *customAlias,
-				OriginalURL: originalURL,
-				CustomAlias: customAlias,
-				ExpiresAt:   expiresAt,
+			// Soft-deleted links still hold their code in the unique index
+			err = s.linkRepo.CreateWithTx(tx, link)
+			if errors.Is(err, repository.ErrDuplicateKey) {
+				return ErrAliasAlreadyExists
 			}
-
-			return s.linkRepo.CreateWithTx(tx, link)
+			return err
 		})
 
 		if err != nil {
@@ -86,50 +184,271 @@ func (s *LinkService) CreateLink(originalURL string, customAlias *string, userID
 		return link, nil
 	}
 
-	// Generate random short code - retry on collision
-	for i := 0; i < 5; i++ {
-		shortCode, err = utils.GenerateShortCode(shortCodeLength)
+	// Generate short code - retry on collision
+	length := s.collisions.Length(shortCodeLength)
+	for i := 0; i < maxCreateAttempts; i++ {
+		shortCode, err := s.config.CodeGenerator.Generate(length)
 		if err != nil {
 			return nil, err
 		}
-
-		link = &models.Link{
-			UserID:      userID,
-			ShortCode:   shortCode,
-			OriginalURL: originalURL,
-			CustomAlias: customAlias,
-			ExpiresAt:   expiresAt,
+		if s.config.Reserved.IsReserved(shortCode) {
+			continue
 		}
+		link.ShortCode = shortCode
 
-		// Try to create - unique constraint will catch collisions
-		if err := s.linkRepo.Create(link); err == nil {
+		// Unique constraint catches collisions; any other error is surfaced
+		err = s.linkRepo.Create(link)
+		s.collisions.Record(errors.Is(err, repository.ErrDuplicateKey))
+		if err == nil {
 			return link, nil
 		}
-		// If error is not unique violation, return it
-		// Otherwise retry with new short code
+		if !errors.Is(err, repository.ErrDuplicateKey) {
+			return nil, err
+		}
 	}
 
-	return nil, ErrAliasAlreadyExists // All retries failed
+	return nil, ErrShortCodeExhausted
+}
+
+// CollisionStats returns metrics about generated short code collisions
+func (s *LinkService) CollisionStats() CollisionStats {
+	return s.collisions.Stats()
 }
 
 // Redirect gets the original URL and tracks the click
+// Suspicious links return the URL together with ErrLinkSuspicious until the warning is accepted
 func (s *LinkService) Redirect(shortCode string, clickInfo *ClickInfo) (string, error) {
-	link, err := s.linkRepo.GetByShortCode(shortCode)
+	link, err := s.GetLink(clickInfo.Host, shortCode)
 	if err != nil {
-		return "", ErrLinkNotFound
+		return "", err
+	}
+
+	if link.Paused {
+		return "", ErrLinkPaused
 	}
 
-	// Check if link has expired
-	if link.ExpiresAt != nil && link.ExpiresAt.Before(time.Now()) {
+	// Expired links send visitors to the owner's fallback URL, if any
+	if link.IsExpired() {
+		if link.FallbackURL != nil {
+			return *link.FallbackURL, ErrLinkExpired
+		}
 		return "", ErrLinkExpired
 	}
 
+	// Contact cards are downloaded from the link's own card path
+	if link.IsVCard() {
+		go s.trackClick(link.ID, clickInfo)
+		return VCardPath(link.ShortCode), nil
+	}
+
+	suspicious := link.Suspicious
+	if s.config.ReputationCheckOnRedirect {
+		suspicious, err = s.checkReputation(link.OriginalURL)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Suspicious destinations are only followed after the visitor confirms
+	if suspicious && !clickInfo.WarningAccepted {
+		return link.OriginalURL, ErrLinkSuspicious
+	}
+
 	// Track click asynchronously
 	go s.trackClick(link.ID, clickInfo)
 
 	return link.OriginalURL, nil
 }
 
+// GetLink returns the link served under a short code on a request host.
+// Unknown hosts fall back to the shared domain.
+func (s *LinkService) GetLink(host, shortCode string) (*models.Link, error) {
+	domainID, err := s.domainIDForHost(host)
+	if err != nil {
+		domainID = 0
+	}
+
+	link, err := s.linkRepo.GetByShortCode(domainID, shortCode)
+	if err != nil {
+		return nil, ErrLinkNotFound
+	}
+	return link, nil
+}
+
+// linkRef identifies a short link by its domain key and code
+type linkRef struct {
+	domainID uint
+	code     string
+}
+
+// resolveChain rejects links to other URL shorteners and follows links
+// pointing to our own short links until a foreign destination is reached.
+// self is the link the URL is for; a chain reaching it or any link twice is a
+// loop. Other pages of our domains, like the home page, are kept as they are.
+func (s *LinkService) resolveChain(originalURL string, self linkRef) (string, error) {
+	path := map[linkRef]bool{self: true}
+	for depth := 0; ; depth++ {
+		ref, ok, err := s.ownLinkRef(originalURL)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return originalURL, nil
+		}
+		if path[ref] || depth >= maxChainDepth {
+			return "", ErrRedirectLoop
+		}
+		path[ref] = true
+
+		target, err := s.getOwnLink(ref)
+		if err != nil {
+			return "", err
+		}
+		// Cards can be edited later and paused or expired links may come back,
+		// so links to them are kept as they are unless they lead back here
+		if target.IsVCard() {
+			return originalURL, nil
+		}
+		if target.IsExpired() || target.Paused {
+			if err := s.checkKeptLink(target, path, depth+1); err != nil {
+				return "", err
+			}
+			return originalURL, nil
+		}
+		originalURL = target.OriginalURL
+	}
+}
+
+// checkKeptLink follows everywhere a link kept in a chain may redirect later,
+// its destination and its fallback, and returns ErrRedirectLoop if that leads
+// back into path. Links that no longer exist end the chain.
+func (s *LinkService) checkKeptLink(link *models.Link, path map[linkRef]bool, depth int) error {
+	next := []string{link.OriginalURL}
+	if link.FallbackURL != nil {
+		next = append(next, *link.FallbackURL)
+	}
+	for _, rawURL := range next {
+		ref, ok, err := s.ownLinkRef(rawURL)
+		if err != nil || !ok {
+			continue
+		}
+		if path[ref] || depth >= maxChainDepth {
+			return ErrRedirectLoop
+		}
+		target, err := s.getOwnLink(ref)
+		if errors.Is(err, ErrTargetLinkNotFound) || err == nil && target.IsVCard() {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		path[ref] = true
+		err = s.checkKeptLink(target, path, depth+1)
+		delete(path, ref)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownLinkRef returns the short link a URL on one of our domains names.
+// ok is false for foreign URLs and for pages of our domains that are not
+// short links.
+func (s *LinkService) ownLinkRef(rawURL string) (ref linkRef, ok bool, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return linkRef{}, false, ErrInvalidURL
+	}
+	if utils.HostMatchesAny(u.Host, s.config.ShortenerDomains) {
+		return linkRef{}, false, ErrShortenerChain
+	}
+	domainID, err := s.domainIDForHost(u.Host)
+	if err != nil {
+		return linkRef{}, false, nil
+	}
+
+	// Routes are reserved, so they can never be short codes
+	code := strings.Trim(u.Path, "/")
+	if code == "" || strings.Contains(code, "/") || s.config.Reserved.IsReservedAlias(code) {
+		return linkRef{}, false, nil
+	}
+	return linkRef{domainID: domainID, code: code}, true, nil
+}
+
+// getOwnLink looks up a short link named in a URL.
+// Missing and deleted links return ErrTargetLinkNotFound.
+func (s *LinkService) getOwnLink(ref linkRef) (*models.Link, error) {
+	link, err := s.linkRepo.GetByShortCode(ref.domainID, ref.code)
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && link == nil {
+		return nil, ErrTargetLinkNotFound
+	}
+	return link, err
+}
+
+// domainIDForHost maps a request host to the domain key of its links.
+// The shared domains map to 0, unknown hosts return ErrDomainNotFound.
+func (s *LinkService) domainIDForHost(host string) (uint, error) {
+	if host == "" || utils.HostMatchesAny(host, s.config.OwnDomains) {
+		return 0, nil
+	}
+	if s.config.CustomDomains == nil {
+		return 0, ErrDomainNotFound
+	}
+
+	domain, err := s.config.CustomDomains.ResolveHost(host)
+	if err != nil {
+		return 0, err
+	}
+	return domainKey(domain), nil
+}
+
+// resolveDomainForUser returns the verified domain a user may create links on
+func (s *LinkService) resolveDomainForUser(userID *uint, host string) (*models.Domain, error) {
+	if host == "" {
+		return nil, nil
+	}
+	if s.config.CustomDomains == nil {
+		return nil, ErrDomainNotFound
+	}
+	return s.config.CustomDomains.ResolveForUser(userID, host)
+}
+
+// domainKey returns the ID used to scope short codes, 0 for the shared domain
+func domainKey(domain *models.Domain) uint {
+	if domain == nil {
+		return 0
+	}
+	return domain.ID
+}
+
+func domainIDPtr(domain *models.Domain) *uint {
+	if domain == nil {
+		return nil
+	}
+	return &domain.ID
+}
+
+// checkReputation consults the URL reputation checker if one is configured.
+// Malicious URLs return ErrURLBlocked; lookup failures are logged and allowed.
+func (s *LinkService) checkReputation(originalURL string) (bool, error) {
+	if s.reputation == nil {
+		return false, nil
+	}
+
+	verdict, err := s.reputation.Check(originalURL)
+	if err != nil {
+		log.Printf("URL reputation check failed for %s: %v", originalURL, err)
+		return false, nil
+	}
+
+	if verdict.IsMalicious() {
+		return false, ErrURLBlocked
+	}
+	return verdict.IsSuspicious(), nil
+}
+
 // trackClick records a click event with transaction support
 // Ensures click record and click_count are updated atomically
 func (s *LinkService) trackClick(linkID uint, info *ClickInfo) {
@@ -151,14 +470,32 @@ func (s *LinkService) trackClick(linkID uint, info *ClickInfo) {
 		Referer:       info.Referer,
 		RefererSource: refInfo.Source,
 		RefererDomain: refInfo.Domain,
+		Channel:       clickChannel(info.Source),
+		IsBot:         uaInfo.IsBot,
+	}
+	if s.config.Visitors != nil {
+		hash, err := s.config.Visitors.Hash(info.IPAddress, info.UserAgent, time.Now())
+		if err != nil {
+			log.Printf("Failed to hash visitor for link %d: %v", linkID, err)
+		}
+		click.VisitorHash = hash
 	}
 
 	// Use transaction to ensure atomicity:
-	// Both click record and click_count update succeed or both fail
+	// Both click record and click_count update succeed or both fail.
+	// Bot clicks are kept for the bot traffic report but not counted.
 	err := s.txManager.ExecuteInTransaction(func(tx *gorm.DB) error {
 		if err := s.clickRepo.CreateWithTx(tx, click); err != nil {
 			return err
 		}
+		if s.config.Rollups != nil {
+			if err := s.config.Rollups.IncrementWithTx(tx, click); err != nil {
+				return err
+			}
+		}
+		if click.IsBot {
+			return nil
+		}
 		return s.linkRepo.IncrementClickCountWithTx(tx, linkID)
 	})
 
@@ -167,68 +504,176 @@ func (s *LinkService) trackClick(linkID uint, info *ClickInfo) {
 	}
 }
 
+// clickChannel maps the source marker of a visit to its channel
+func clickChannel(source string) string {
+	if source == models.ClickChannelQR {
+		return models.ClickChannelQR
+	}
+	return models.ClickChannelDirect
+}
+
 // GetUserLinks returns all links for a user with pagination
 func (s *LinkService) GetUserLinks(userID uint, page, pageSize int) ([]*models.Link, int64, error) {
 	return s.linkRepo.GetByUserID(userID, page, pageSize)
 }
 
 // GetLinkWithAnalytics returns a link with its analytics if the user owns it
-func (s *LinkService) GetLinkWithAnalytics(shortCode string, userID uint) (*models.Link, error) {
-	link, err := s.linkRepo.GetByShortCode(shortCode)
+// domain is the host the link is served on, empty for the shared domain
+func (s *LinkService) GetLinkWithAnalytics(domain, shortCode string, userID uint) (*models.Link, error) {
+	return s.getOwnedLink(domain, shortCode, userID)
+}
+
+// GetOwnedLinks returns the user's links with the given short codes in request order,
+// skipping duplicates. Codes that don't exist or belong to someone else are returned as missing.
+func (s *LinkService) GetOwnedLinks(domain string, shortCodes []string, userID uint) ([]*models.Link, []string, error) {
+	domainID, err := s.domainIDForHost(domain)
 	if err != nil {
-		return nil, ErrLinkNotFound
+		return nil, shortCodes, nil
 	}
 
-	// Check ownership
-	if link.UserID == nil || *link.UserID != userID {
-		return nil, ErrUnauthorized
+	found, err := s.linkRepo.GetByShortCodes(domainID, shortCodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	byCode := make(map[string]*models.Link, len(found))
+	for _, link := range found {
+		if link.UserID != nil && *link.UserID == userID {
+			byCode[link.ShortCode] = link
+		}
+	}
+
+	links := make([]*models.Link, 0, len(byCode))
+	var missing []string
+	seen := make(map[string]bool, len(shortCodes))
+	for _, code := range shortCodes {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		if link, ok := byCode[code]; ok {
+			links = append(links, link)
+		} else {
+			missing = append(missing, code)
+		}
+	}
+	return links, missing, nil
+}
+
+// LinkUpdate holds the owner-editable settings of a link; nil fields are left unchanged
+type LinkUpdate struct {
+	Paused      *bool
+	FallbackURL *string // an empty string removes the fallback
+}
+
+// UpdateLink changes the settings of a link if the user owns it
+func (s *LinkService) UpdateLink(domain, shortCode string, userID uint, update LinkUpdate) (*models.Link, error) {
+	link, err := s.getOwnedLink(domain, shortCode, userID)
+	if err != nil {
+		return nil, err
 	}
 
+	if update.Paused != nil {
+		link.Paused = *update.Paused
+	}
+
+	if update.FallbackURL != nil {
+		if *update.FallbackURL == "" {
+			link.FallbackURL = nil
+		} else {
+			fallbackURL := *update.FallbackURL
+			if !utils.ValidateURL(fallbackURL) {
+				return nil, ErrInvalidURL
+			}
+			// Same rules as destinations, so a fallback can't loop back to us
+			fallbackURL, err = s.resolveChain(fallbackURL, linkRef{domainID: domainKey(link.Domain), code: link.ShortCode})
+			if err != nil {
+				return nil, err
+			}
+			if _, err := s.checkReputation(fallbackURL); err != nil {
+				return nil, err
+			}
+			link.FallbackURL = &fallbackURL
+		}
+	}
+
+	if err := s.linkRepo.Update(link); err != nil {
+		return nil, err
+	}
 	return link, nil
 }
 
 // DeleteLink deletes a link if the user owns it
-func (s *LinkService) DeleteLink(shortCode string, userID uint) error {
-	link, err := s.linkRepo.GetByShortCode(shortCode)
+func (s *LinkService) DeleteLink(domain, shortCode string, userID uint) error {
+	link, err := s.getOwnedLink(domain, shortCode, userID)
 	if err != nil {
-		return ErrLinkNotFound
+		return err
+	}
+
+	return s.linkRepo.Delete(link.ID)
+}
+
+// getOwnedLink finds a link by domain and short code and checks ownership
+func (s *LinkService) getOwnedLink(domain, shortCode string, userID uint) (*models.Link, error) {
+	domainID, err := s.domainIDForHost(domain)
+	if err != nil {
+		return nil, ErrLinkNotFound
+	}
+
+	link, err := s.linkRepo.GetByShortCode(domainID, shortCode)
+	if err != nil {
+		return nil, ErrLinkNotFound
 	}
 
 	// Check ownership
 	if link.UserID == nil || *link.UserID != userID {
-		return ErrUnauthorized
+		return nil, ErrUnauthorized
 	}
 
-	return s.linkRepo.Delete(link.ID)
+	return link, nil
 }
 
 // CreateLinkWithAuth creates a link with authentication handling
 // If authHeader is provided and valid, link belongs to that user
-// Otherwise creates a guest user and returns token
+// A guest token keeps creating links for the same guest; without a valid token
+// a new guest identity is issued and its token returned. Guests are not stored
+// until they use an authenticated endpoint, see GuestService.MaterializeGuest.
 func (s *LinkService) CreateLinkWithAuth(
 	originalURL string,
 	customAlias *string,
+	domain string,
 	expiresAt *time.Time,
 	authHeader string,
 	shortCodeLength int,
 ) (*models.Link, string, error) {
-	// Try to get user from token
-	userID, err := s.authService.GetUserFromToken(authHeader)
+	// Try to get user or guest from token
+	claims, err := s.authService.ParseAuthHeader(authHeader)
 
+	var userID *uint
+	var guestID *string
 	var token string
 
-	// If no valid token, create guest user
-	if err != nil || userID == nil {
-		guestUser, guestToken, err := s.authService.CreateGuestUser()
+	switch {
+	case err != nil || claims == nil:
+		// No valid token, issue a new guest identity
+		newGuestID, guestToken, err := s.authService.CreateGuestToken()
 		if err != nil {
 			return nil, "", err
 		}
-		userID = &guestUser.ID
+		guestID = &newGuestID
 		token = guestToken
+	case claims.IsGuest():
+		guestID = &claims.GuestID
+		// Links of a materialized guest are owned by its user row right away
+		userID, err = s.authService.GuestUserID(claims.GuestID)
+		if err != nil {
+			return nil, "", err
+		}
+	default:
+		userID = &claims.UserID
 	}
 
 	// Create link
-	link, err := s.CreateLink(originalURL, customAlias, userID, expiresAt, shortCodeLength)
+	link, err := s.createLink(domain, originalURL, customAlias, userID, guestID, expiresAt, shortCodeLength)
 	if err != nil {
 		return nil, "", err
 	}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QRCache keeps rendered QR images in memory, evicting the least recently
+// used ones beyond maxBytes. With a directory set, images are also written
+// to disk so they survive restarts, evicting the least recently used files
+// beyond maxDiskBytes. A nil *QRCache caches nothing.
+type QRCache struct {
+	maxBytes     int
+	dir          string
+	maxDiskBytes int
+
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+
+	diskSize    int
+	diskOrder   *list.List // front is most recently used
+	diskEntries map[string]*list.Element
+}
+
+type qrCacheEntry struct {
+	key  string
+	data []byte
+}
+
+type qrDiskEntry struct {
+	key  string
+	size int
+}
+
+// NewQRCache creates a QR image cache holding up to maxBytes in memory,
+// backed by up to maxDiskBytes of files in dir if it is not empty
+func NewQRCache(maxBytes int, dir string, maxDiskBytes int) (*QRCache, error) {
+	c := &QRCache{
+		maxBytes:     maxBytes,
+		dir:          dir,
+		maxDiskBytes: maxDiskBytes,
+		order:        list.New(),
+		entries:      make(map[string]*list.Element),
+		diskOrder:    list.New(),
+		diskEntries:  make(map[string]*list.Element),
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create QR cache directory: %w", err)
+		}
+		if err := c.loadDisk(); err != nil {
+			return nil, fmt.Errorf("failed to read QR cache directory: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// loadDisk indexes the files left by a previous run, oldest first, and removes
+// what no longer fits
+func (c *QRCache) loadDisk() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	type file struct {
+		key     string
+		size    int
+		modTime time.Time
+	}
+	var files []file
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, ".tmp") {
+			// Left by an interrupted write
+			os.Remove(filepath.Join(c.dir, name))
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !strings.HasSuffix(name, ".qr") {
+			continue
+		}
+		files = append(files, file{strings.TrimSuffix(name, ".qr"), int(info.Size()), info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	for _, f := range files {
+		c.diskEntries[f.key] = c.diskOrder.PushFront(&qrDiskEntry{key: f.key, size: f.size})
+		c.diskSize += f.size
+	}
+	c.removeFiles(c.trimDisk())
+	return nil
+}
+
+// Get returns a cached image, loading it from disk into memory on a memory miss
+func (c *QRCache) Get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		data := elem.Value.(*qrCacheEntry).data
+		c.mu.Unlock()
+		return data, true
+	}
+	elem, onDisk := c.diskEntries[key]
+	if onDisk {
+		c.diskOrder.MoveToFront(elem)
+	}
+	c.mu.Unlock()
+
+	if !onDisk {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		// Removed behind our back, let the next Add write it again
+		c.mu.Lock()
+		if elem, ok := c.diskEntries[key]; ok {
+			c.diskSize -= elem.Value.(*qrDiskEntry).size
+			c.diskOrder.Remove(elem)
+			delete(c.diskEntries, key)
+		}
+		c.mu.Unlock()
+		return nil, false
+	}
+	c.remember(key, data)
+	return data, true
+}
+
+// Add stores an image in memory and, if configured, on disk.
+// Disk errors are logged; the image is still served from memory.
+func (c *QRCache) Add(key string, data []byte) {
+	if c == nil {
+		return
+	}
+	c.remember(key, data)
+
+	if c.dir == "" || len(data) > c.maxDiskBytes {
+		return
+	}
+	c.mu.Lock()
+	_, onDisk := c.diskEntries[key]
+	c.mu.Unlock()
+	if onDisk {
+		return
+	}
+
+	// Write to a temporary file first so readers never see a partial image
+	tmp, err := os.CreateTemp(c.dir, "qr-*.tmp")
+	if err != nil {
+		log.Printf("QR cache: %v", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Printf("QR cache: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.diskEntries[key]; ok {
+		entry := elem.Value.(*qrDiskEntry)
+		c.diskSize += len(data) - entry.size
+		entry.size = len(data)
+		c.diskOrder.MoveToFront(elem)
+	} else {
+		c.diskEntries[key] = c.diskOrder.PushFront(&qrDiskEntry{key: key, size: len(data)})
+		c.diskSize += len(data)
+	}
+	evicted := c.trimDisk()
+	c.mu.Unlock()
+	c.removeFiles(evicted)
+}
+
+// trimDisk drops the least recently used files beyond maxDiskBytes from the
+// index and returns their keys; c.mu must be held
+func (c *QRCache) trimDisk() []string {
+	var evicted []string
+	for c.diskSize > c.maxDiskBytes {
+		oldest := c.diskOrder.Back()
+		entry := oldest.Value.(*qrDiskEntry)
+		c.diskOrder.Remove(oldest)
+		delete(c.diskEntries, entry.key)
+		c.diskSize -= entry.size
+		evicted = append(evicted, entry.key)
+	}
+	return evicted
+}
+
+func (c *QRCache) removeFiles(keys []string) {
+	for _, key := range keys {
+		if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+			log.Printf("QR cache: %v", err)
+		}
+	}
+}
+
+// remember adds an image to the in-memory LRU
+func (c *QRCache) remember(key string, data []byte) {
+	if len(data) > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*qrCacheEntry)
+		c.size += len(data) - len(entry.data)
+		entry.data = data
+		c.order.MoveToFront(elem)
+	} else {
+		c.entries[key] = c.order.PushFront(&qrCacheEntry{key: key, data: data})
+		c.size += len(data)
+	}
+
+	for c.size > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*qrCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.data)
+	}
+}
+
+// Len returns the number of images held in memory
+func (c *QRCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// DiskLen returns the number of images kept on disk
+func (c *QRCache) DiskLen() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.diskOrder.Len()
+}
+
+func (c *QRCache) path(key string) string {
+	return filepath.Join(c.dir, key+".qr")
+}
+
+// qrCacheKey derives a file-name safe cache key from everything that affects a QR image
+func qrCacheKey(parts ...any) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%#v", parts)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// QR export formats
+const (
+	QRExportZIP = "zip"
+	QRExportPDF = "pdf"
+)
+
+// MaxQRExportLinks limits the links in one export
+const MaxQRExportLinks = 1000
+
+// Label sheet layout in millimeters (A4 portrait, 4 x 5 codes per page)
+const (
+	qrSheetMargin  = 10.0
+	qrSheetColumns = 4
+	qrSheetRows    = 5
+	qrSheetCode    = 40.0
+	qrSheetLabel   = 6.0
+)
+
+// QRExportItem is one short link in an export
+type QRExportItem struct {
+	ShortCode string // names the file in a ZIP
+	ShortURL  string // encoded for scans and printed on label sheets
+}
+
+// QRExport writes the QR codes of a batch of links as a ZIP of images or a PDF label sheet.
+// Codes are rendered one at a time and bypass the QR cache, so large exports
+// don't push frequently served images out. A ZIP is streamed image by image;
+// a PDF is buffered until its last page, holding every code in memory.
+type QRExport struct {
+	output string
+	items  []QRExportItem
+	drawer *qrDrawer
+}
+
+// PrepareQRExport checks the options and loads the style once, so that errors
+// surface before anything is written. The PDF always embeds PNG codes, with
+// opts.Size setting their resolution.
+func (s *QRService) PrepareQRExport(output string, items []QRExportItem, opts QROptions) (*QRExport, error) {
+	if output != QRExportPDF {
+		output = QRExportZIP
+	} else {
+		opts.Format = QRFormatPNG
+	}
+	drawer, err := s.newDrawer(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &QRExport{output: output, items: items, drawer: drawer}, nil
+}
+
+// ContentType returns the MIME type of the export
+func (e *QRExport) ContentType() string {
+	if e.output == QRExportPDF {
+		return "application/pdf"
+	}
+	return "application/zip"
+}
+
+// Filename returns the name the export is downloaded as
+func (e *QRExport) Filename() string {
+	return "qr-codes." + e.output
+}
+
+// Write renders the export to w
+func (e *QRExport) Write(w io.Writer) error {
+	if e.output == QRExportPDF {
+		return e.writePDF(w)
+	}
+	return e.writeZIP(w)
+}
+
+// writeZIP streams a ZIP archive with one QR image per item, named by short code
+func (e *QRExport) writeZIP(w io.Writer) error {
+	format := e.drawer.opts.Format
+	// PNG is already compressed
+	method := zip.Deflate
+	if format == QRFormatPNG {
+		method = zip.Store
+	}
+
+	archive := zip.NewWriter(w)
+	for _, item := range e.items {
+		data, err := e.drawer.draw(QRScanURL(item.ShortURL))
+		if err != nil {
+			return err
+		}
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: item.ShortCode + "." + format, Method: method})
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// writePDF writes a printable A4 label sheet with the short URL under each QR code.
+// fpdf keeps every registered image and the whole document until Output.
+func (e *QRExport) writePDF(w io.Writer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(qrSheetMargin, qrSheetMargin, qrSheetMargin)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetFont("Helvetica", "", 9)
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	cellWidth := (pageWidth - 2*qrSheetMargin) / qrSheetColumns
+	cellHeight := (pageHeight - 2*qrSheetMargin) / qrSheetRows
+
+	for i, item := range e.items {
+		slot := i % (qrSheetColumns * qrSheetRows)
+		if slot == 0 {
+			pdf.AddPage()
+		}
+		data, err := e.drawer.draw(QRScanURL(item.ShortURL))
+		if err != nil {
+			return err
+		}
+
+		x := qrSheetMargin + float64(slot%qrSheetColumns)*cellWidth
+		y := qrSheetMargin + float64(slot/qrSheetColumns)*cellHeight
+		top := y + (cellHeight-qrSheetCode-qrSheetLabel)/2
+
+		imageOpts := fpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader(item.ShortURL, imageOpts, bytes.NewReader(data))
+		pdf.ImageOptions(item.ShortURL, x+(cellWidth-qrSheetCode)/2, top, qrSheetCode, qrSheetCode, false, imageOpts, 0, "")
+		pdf.SetXY(x, top+qrSheetCode)
+		pdf.CellFormat(cellWidth, qrSheetLabel, translate(strings.TrimPrefix(item.ShortURL, "https://")), "", 0, "C", false, 0, "")
+
+		if err := pdf.Error(); err != nil {
+			return err
+		}
+	}
+	return pdf.Output(w)
+}
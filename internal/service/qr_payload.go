@@ -0,0 +1,89 @@
+package service
+
+import (
+	"unicode/utf8"
+
+	"quocbui.dev/m/pkg/utils"
+)
+
+// QR payload types that are encoded directly into the code.
+// Contact cards are hosted behind a short link instead, see CreateVCardLink.
+const (
+	QRPayloadWiFi  = "wifi"
+	QRPayloadEmail = "email"
+	QRPayloadSMS   = "sms"
+)
+
+// Payload field limits keep the encoded content scannable at every error correction level
+const (
+	maxWiFiSSID     = 32
+	maxEmailSubject = 200
+	maxPayloadText  = 500
+)
+
+// QRPayload is non-URL content for a QR code; the field matching Type is set
+type QRPayload struct {
+	Type  string
+	WiFi  *utils.WiFiPayload
+	Email *utils.EmailPayload
+	SMS   *utils.SMSPayload
+}
+
+// RenderPayload validates a payload and draws it as a QR code
+func (s *QRService) RenderPayload(payload QRPayload, opts QROptions) (*QRImage, error) {
+	content, err := payload.encode()
+	if err != nil {
+		return nil, err
+	}
+	return s.Render(content, opts)
+}
+
+// encode validates the payload and returns the text to put in the QR code
+func (p QRPayload) encode() (string, error) {
+	switch {
+	case p.Type == QRPayloadWiFi && p.WiFi != nil:
+		wifi := *p.WiFi
+		if wifi.Security == "" {
+			wifi.Security = utils.WiFiSecurityWPA
+		}
+		if wifi.SSID == "" || len(wifi.SSID) > maxWiFiSSID {
+			return "", ErrInvalidQRPayload
+		}
+		switch wifi.Security {
+		case utils.WiFiSecurityWPA:
+			if len(wifi.Password) < 8 || len(wifi.Password) > 63 {
+				return "", ErrInvalidQRPayload
+			}
+		case utils.WiFiSecurityWEP:
+			if wifi.Password == "" || len(wifi.Password) > 26 {
+				return "", ErrInvalidQRPayload
+			}
+		case utils.WiFiSecurityOpen:
+			wifi.Password = ""
+		default:
+			return "", ErrInvalidQRPayload
+		}
+		return wifi.String(), nil
+
+	case p.Type == QRPayloadEmail && p.Email != nil:
+		email := *p.Email
+		if !utils.ValidateEmail(email.To) ||
+			utf8.RuneCountInString(email.Subject) > maxEmailSubject ||
+			utf8.RuneCountInString(email.Body) > maxPayloadText {
+			return "", ErrInvalidQRPayload
+		}
+		return email.String(), nil
+
+	case p.Type == QRPayloadSMS && p.SMS != nil:
+		sms := *p.SMS
+		phone, ok := utils.NormalizePhone(sms.Phone)
+		if !ok || utf8.RuneCountInString(sms.Message) > maxPayloadText {
+			return "", ErrInvalidQRPayload
+		}
+		sms.Phone = phone
+		return sms.String(), nil
+
+	default:
+		return "", ErrInvalidQRPayload
+	}
+}
@@ -1,29 +1,392 @@
 package service
 
 import (
+	"bytes"
 	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"strings"
 
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/repository"
 	"quocbui.dev/m/pkg/utils"
 )
 
-// QRService handles QR code generation
+// QR image formats
+const (
+	QRFormatPNG = "png"
+	QRFormatSVG = "svg"
+)
+
+// QR image limits; size is in pixels, margin in modules
+const (
+	DefaultQRSize   = 256
+	MinQRSize       = 64
+	MaxQRSize       = 2048
+	DefaultQRMargin = 4
+	MaxQRMargin     = 16
+)
+
+// QRSourceParam is the query parameter marking short URLs encoded in QR codes.
+// Redirect only records it as the click channel; it is not passed on to the destination.
+const QRSourceParam = "src"
+
+// QRScanURL returns the URL a QR code for shortURL encodes, so scans can be
+// told apart from visits through the plain short URL
+func QRScanURL(shortURL string) string {
+	return shortURL + "?" + QRSourceParam + "=" + models.ClickChannelQR
+}
+
+// QR style limits
+const (
+	// MinQRContrast is the lowest WCAG contrast ratio between the module and
+	// background colors that phone cameras reliably read
+	MinQRContrast   = 3.0
+	MaxQRStyleName  = 64
+	MaxQRLogoBytes  = 512 << 10
+	MaxQRLogoPixels = 1024 // width and height
+)
+
+// QROptions controls how a QR image is rendered
+type QROptions struct {
+	Format string
+	Size   int
+	Margin int
+	Style  *models.QRStyle // nil for black on white
+}
+
+// QRImage is a rendered QR code
+type QRImage struct {
+	Data        []byte
+	ContentType string
+}
+
+// QRStyleInput holds the user-editable fields of a QR style; empty fields take defaults
+type QRStyleInput struct {
+	Name            string
+	Foreground      string
+	Background      string
+	Shape           string
+	ErrorCorrection string
+	WithLogo        bool
+	IsDefault       bool
+}
+
+// QRService handles QR code generation and the QR styles of users
 type QRService struct {
-	logoPath string
+	appLogo   image.Image // drawn on inline QR codes, nil if the logo file is missing
+	styleRepo repository.QRStyleRepository
+	cache     *QRCache
 }
 
-// NewQRService creates a new QR service
-func NewQRService(logoPath string) *QRService {
+// NewQRService creates a new QR service. The app logo is loaded once;
+// cache may be nil to render every image.
+func NewQRService(logoPath string, styleRepo repository.QRStyleRepository, cache *QRCache) *QRService {
+	var appLogo image.Image
+	if data, err := os.ReadFile(logoPath); err == nil {
+		appLogo, _ = utils.DecodeImage(data, 0)
+	}
 	return &QRService{
-		logoPath: logoPath,
+		appLogo:   appLogo,
+		styleRepo: styleRepo,
+		cache:     cache,
 	}
 }
 
-// GenerateQRCodeBase64 generates a QR code with logo and returns base64 encoded string
+// GenerateQRCodeBase64 generates a QR code with the app logo and returns it as a PNG data URL
 func (s *QRService) GenerateQRCodeBase64(url string) (string, error) {
-	qrBytes, err := utils.GenerateQRCode(url, s.logoPath)
+	key := qrCacheKey("inline", url)
+	data, err := s.cached(key, func() ([]byte, error) {
+		modules, err := utils.QRBitmap(url, utils.QRCorrectionHighest)
+		if err != nil {
+			return nil, err
+		}
+		style := utils.DefaultQRStyle
+		style.Logo = s.appLogo
+		return utils.RenderQRCodePNG(modules, DefaultQRSize, DefaultQRMargin, style)
+	})
 	if err != nil {
 		return "", err
 	}
 
-	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrBytes), nil
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// Render draws a QR code for content in the requested format and style
+func (s *QRService) Render(content string, opts QROptions) (*QRImage, error) {
+	drawer, err := s.newDrawer(opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.cached(drawer.cacheKey(content), func() ([]byte, error) {
+		return drawer.draw(content)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &QRImage{Data: data, ContentType: drawer.contentType}, nil
+}
+
+// qrDrawer renders QR codes with options checked, and the style's logo loaded, once
+type qrDrawer struct {
+	opts        QROptions
+	contentType string
+	logo        *models.QRLogo
+	variant     string // cache key of everything but the content
+
+	style *utils.QRStyle // converted on first draw
+	level string
+}
+
+func (s *QRService) newDrawer(opts QROptions) (*qrDrawer, error) {
+	if opts.Format == "" {
+		opts.Format = QRFormatPNG
+	}
+	if opts.Size < MinQRSize || opts.Size > MaxQRSize || opts.Margin < 0 || opts.Margin > MaxQRMargin {
+		return nil, ErrInvalidQROptions
+	}
+	d := &qrDrawer{opts: opts, level: utils.QRCorrectionHighest}
+	switch opts.Format {
+	case QRFormatPNG:
+		d.contentType = "image/png"
+	case QRFormatSVG:
+		d.contentType = "image/svg+xml"
+	default:
+		return nil, ErrInvalidQROptions
+	}
+
+	// The logo is part of the key so replacing it yields new images
+	d.variant = qrCacheKey("render", opts.Format, opts.Size, opts.Margin)
+	if opts.Style != nil {
+		if opts.Style.WithLogo {
+			var err error
+			if d.logo, err = s.styleRepo.GetLogo(opts.Style.UserID); err != nil {
+				return nil, err
+			}
+		}
+		var logoVersion int64
+		if d.logo != nil {
+			logoVersion = d.logo.UpdatedAt.UnixNano()
+		}
+		d.variant = qrCacheKey(d.variant, opts.Style.Foreground, opts.Style.Background, opts.Style.Shape,
+			opts.Style.ErrorCorrection, d.logo != nil, logoVersion)
+		d.level = opts.Style.ErrorCorrection
+	}
+	return d, nil
+}
+
+func (d *qrDrawer) cacheKey(content string) string {
+	return qrCacheKey(d.variant, content)
+}
+
+func (d *qrDrawer) draw(content string) ([]byte, error) {
+	if d.style == nil {
+		style := utils.DefaultQRStyle
+		if d.opts.Style != nil {
+			var err error
+			if style, err = drawingStyle(d.opts.Style, d.logo); err != nil {
+				return nil, err
+			}
+		}
+		d.style = &style
+	}
+
+	modules, err := utils.QRBitmap(content, d.level)
+	if err != nil {
+		return nil, err
+	}
+	if d.opts.Format == QRFormatSVG {
+		return utils.RenderQRCodeSVG(modules, d.opts.Size, d.opts.Margin, *d.style)
+	}
+	return utils.RenderQRCodePNG(modules, d.opts.Size, d.opts.Margin, *d.style)
+}
+
+// cached returns the image stored under key, rendering and storing it on a miss
+func (s *QRService) cached(key string, render func() ([]byte, error)) ([]byte, error) {
+	if data, ok := s.cache.Get(key); ok {
+		return data, nil
+	}
+	data, err := render()
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Add(key, data)
+	return data, nil
+}
+
+// drawingStyle converts a stored style and its owner's logo for drawing.
+// A style whose logo was deleted is drawn without it.
+func drawingStyle(style *models.QRStyle, logo *models.QRLogo) (utils.QRStyle, error) {
+	fg, err := utils.ParseHexColor(style.Foreground)
+	if err != nil {
+		return utils.QRStyle{}, err
+	}
+	bg, err := utils.ParseHexColor(style.Background)
+	if err != nil {
+		return utils.QRStyle{}, err
+	}
+	result := utils.QRStyle{
+		Foreground:   fg,
+		Background:   bg,
+		RoundModules: style.Shape == models.QRShapeCircle,
+	}
+
+	if logo != nil {
+		if result.Logo, err = utils.DecodeImage(logo.Data, 0); err != nil {
+			return utils.QRStyle{}, err
+		}
+	}
+	return result, nil
+}
+
+// ListStyles returns all QR styles of a user
+func (s *QRService) ListStyles(userID uint) ([]*models.QRStyle, error) {
+	return s.styleRepo.GetByUserID(userID)
+}
+
+// CreateStyle validates and saves a new QR style for a user
+func (s *QRService) CreateStyle(userID uint, input QRStyleInput) (*models.QRStyle, error) {
+	style := &models.QRStyle{UserID: userID}
+	if err := applyQRStyleInput(style, input); err != nil {
+		return nil, err
+	}
+	if err := s.styleRepo.Create(style); err != nil {
+		return nil, err
+	}
+	return style, nil
+}
+
+// UpdateStyle replaces the settings of a QR style owned by the user
+func (s *QRService) UpdateStyle(userID, styleID uint, input QRStyleInput) (*models.QRStyle, error) {
+	style, err := s.getOwnedStyle(userID, styleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyQRStyleInput(style, input); err != nil {
+		return nil, err
+	}
+	if err := s.styleRepo.Update(style); err != nil {
+		return nil, err
+	}
+	return style, nil
+}
+
+// DeleteStyle removes a QR style owned by the user
+func (s *QRService) DeleteStyle(userID, styleID uint) error {
+	if _, err := s.getOwnedStyle(userID, styleID); err != nil {
+		return err
+	}
+	return s.styleRepo.Delete(styleID)
+}
+
+// ResolveStyle returns the style a user's QR codes are drawn with: the given
+// style if styleID is set, otherwise their default style or nil if they have none
+func (s *QRService) ResolveStyle(userID, styleID uint) (*models.QRStyle, error) {
+	if styleID == 0 {
+		return s.styleRepo.GetDefault(userID)
+	}
+	return s.getOwnedStyle(userID, styleID)
+}
+
+// SetLogo validates an uploaded PNG or JPEG logo and stores it re-encoded as PNG
+func (s *QRService) SetLogo(userID uint, data []byte) error {
+	if len(data) == 0 || len(data) > MaxQRLogoBytes {
+		return ErrInvalidLogo
+	}
+	img, err := utils.DecodeImage(data, MaxQRLogoPixels)
+	if err != nil {
+		return ErrInvalidLogo
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return s.styleRepo.SaveLogo(&models.QRLogo{UserID: userID, Data: buf.Bytes()})
+}
+
+// GetLogo returns the user's logo as PNG
+func (s *QRService) GetLogo(userID uint) ([]byte, error) {
+	logo, err := s.styleRepo.GetLogo(userID)
+	if err != nil {
+		return nil, err
+	}
+	if logo == nil {
+		return nil, ErrLogoNotFound
+	}
+	return logo.Data, nil
+}
+
+// DeleteLogo removes the user's logo; styles using it are drawn without a logo
+func (s *QRService) DeleteLogo(userID uint) error {
+	return s.styleRepo.DeleteLogo(userID)
+}
+
+func (s *QRService) getOwnedStyle(userID, styleID uint) (*models.QRStyle, error) {
+	style, err := s.styleRepo.GetByID(styleID)
+	if err != nil {
+		return nil, ErrQRStyleNotFound
+	}
+	if style.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	return style, nil
+}
+
+// applyQRStyleInput validates input and copies it onto style. Scanners need
+// dark modules on a light background with enough contrast, and a logo hides
+// modules that only error correction level Q or H can restore.
+func applyQRStyleInput(style *models.QRStyle, input QRStyleInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > MaxQRStyleName {
+		return ErrInvalidQRStyle
+	}
+
+	fgHex := strings.ToLower(defaultString(input.Foreground, "#000000"))
+	bgHex := strings.ToLower(defaultString(input.Background, "#ffffff"))
+	fg, err := utils.ParseHexColor(fgHex)
+	if err != nil {
+		return ErrInvalidQRStyle
+	}
+	bg, err := utils.ParseHexColor(bgHex)
+	if err != nil {
+		return ErrInvalidQRStyle
+	}
+	if utils.RelativeLuminance(fg) >= utils.RelativeLuminance(bg) || utils.ContrastRatio(fg, bg) < MinQRContrast {
+		return ErrLowQRContrast
+	}
+
+	shape := defaultString(input.Shape, models.QRShapeSquare)
+	if shape != models.QRShapeSquare && shape != models.QRShapeCircle {
+		return ErrInvalidQRStyle
+	}
+
+	level := utils.QRCorrectionMedium
+	if input.WithLogo {
+		level = utils.QRCorrectionHighest
+	}
+	level = strings.ToUpper(defaultString(input.ErrorCorrection, level))
+	if !utils.ValidQRCorrectionLevel(level) {
+		return ErrInvalidQRStyle
+	}
+	if input.WithLogo && level != utils.QRCorrectionQuart && level != utils.QRCorrectionHighest {
+		return ErrInvalidQRStyle
+	}
+
+	style.Name = name
+	style.Foreground = fgHex
+	style.Background = bgHex
+	style.Shape = shape
+	style.ErrorCorrection = level
+	style.WithLogo = input.WithLogo
+	style.IsDefault = input.IsDefault
+	return nil
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
 }
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reputation verdicts
+const (
+	VerdictSafe       = "safe"
+	VerdictSuspicious = "suspicious"
+	VerdictMalicious  = "malicious"
+)
+
+// ReputationVerdict is the result of a URL reputation lookup
+type ReputationVerdict struct {
+	Verdict    string `json:"verdict"`               // safe, suspicious, malicious
+	ThreatType string `json:"threat_type,omitempty"` // MALWARE, SOCIAL_ENGINEERING...
+}
+
+// IsMalicious reports whether the URL must be blocked
+func (v *ReputationVerdict) IsMalicious() bool {
+	return v != nil && v.Verdict == VerdictMalicious
+}
+
+// IsSuspicious reports whether the URL should be shown behind a warning
+func (v *ReputationVerdict) IsSuspicious() bool {
+	return v != nil && v.Verdict == VerdictSuspicious
+}
+
+// URLReputationChecker looks up the reputation of a destination URL
+type URLReputationChecker interface {
+	Check(rawURL string) (*ReputationVerdict, error)
+}
+
+// FileReputationChecker matches URLs against a local list of hosts.
+// Each line has the form "<verdict> <host> [threat_type]"; blank lines
+// and lines starting with # are ignored. A host also matches its subdomains.
+type FileReputationChecker struct {
+	entries map[string]*ReputationVerdict
+}
+
+// NewFileReputationChecker loads a reputation list from disk
+func NewFileReputationChecker(path string) (*FileReputationChecker, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	checker := &FileReputationChecker{entries: make(map[string]*ReputationVerdict)}
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("reputation file %s:%d: expected \"<verdict> <host>\"", path, lineNo)
+		}
+
+		verdict := strings.ToLower(fields[0])
+		if verdict != VerdictSafe && verdict != VerdictSuspicious && verdict != VerdictMalicious {
+			return nil, fmt.Errorf("reputation file %s:%d: unknown verdict %q", path, lineNo, fields[0])
+		}
+
+		entry := &ReputationVerdict{Verdict: verdict}
+		if len(fields) > 2 {
+			entry.ThreatType = fields[2]
+		}
+		checker.entries[strings.ToLower(fields[1])] = entry
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return checker, nil
+}
+
+// Check returns the verdict of the most specific matching host
+func (c *FileReputationChecker) Check(rawURL string) (*ReputationVerdict, error) {
+	host := reputationHost(rawURL)
+	for host != "" {
+		if entry, ok := c.entries[host]; ok {
+			return entry, nil
+		}
+		dot := strings.Index(host, ".")
+		if dot < 0 {
+			break
+		}
+		host = host[dot+1:]
+	}
+	return &ReputationVerdict{Verdict: VerdictSafe}, nil
+}
+
+// HTTPReputationChecker queries a Safe Browsing-style lookup endpoint.
+// It POSTs {"url": "..."} and expects a ReputationVerdict as JSON.
+type HTTPReputationChecker struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+// NewHTTPReputationChecker creates a checker backed by a remote endpoint
+func NewHTTPReputationChecker(endpoint, apiKey string) *HTTPReputationChecker {
+	return &HTTPReputationChecker{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+// Check asks the remote endpoint for a verdict
+func (c *HTTPReputationChecker) Check(rawURL string) (*ReputationVerdict, error) {
+	body, err := json.Marshal(map[string]string{"url": rawURL})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reputation endpoint returned status %d", resp.StatusCode)
+	}
+
+	var verdict ReputationVerdict
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return nil, err
+	}
+	if verdict.Verdict == "" {
+		verdict.Verdict = VerdictSafe
+	}
+	return &verdict, nil
+}
+
+// CachedReputationChecker memoizes verdicts of another checker for a TTL,
+// evicting the least recently used ones beyond MaxCachedVerdicts.
+// Lookup errors are not cached.
+type CachedReputationChecker struct {
+	checker URLReputationChecker
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+// MaxCachedVerdicts bounds the number of verdicts a CachedReputationChecker holds
+const MaxCachedVerdicts = 10000
+
+type cachedVerdict struct {
+	url       string
+	verdict   *ReputationVerdict
+	expiresAt time.Time
+}
+
+// NewCachedReputationChecker wraps a checker with an in-memory verdict cache
+func NewCachedReputationChecker(checker URLReputationChecker, ttl time.Duration) *CachedReputationChecker {
+	return &CachedReputationChecker{
+		checker: checker,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Check returns a cached verdict or delegates to the wrapped checker
+func (c *CachedReputationChecker) Check(rawURL string) (*ReputationVerdict, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[rawURL]; ok {
+		entry := elem.Value.(*cachedVerdict)
+		if time.Now().Before(entry.expiresAt) {
+			c.order.MoveToFront(elem)
+			c.mu.Unlock()
+			return entry.verdict, nil
+		}
+	}
+	c.mu.Unlock()
+
+	verdict, err := c.checker.Check(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cachedVerdict{url: rawURL, verdict: verdict, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[rawURL]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+	} else {
+		c.entries[rawURL] = c.order.PushFront(entry)
+	}
+	for c.order.Len() > MaxCachedVerdicts {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedVerdict).url)
+	}
+	return verdict, nil
+}
+
+// Len returns the number of cached verdicts
+func (c *CachedReputationChecker) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func reputationHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
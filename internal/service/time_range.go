@@ -0,0 +1,65 @@
+package service
+
+import (
+	"time"
+
+	"quocbui.dev/m/internal/repository"
+)
+
+// dateLayout is accepted for from/to besides RFC 3339 times
+const dateLayout = "2006-01-02"
+
+// TimeRange selects clicks with From <= clicked_at < To; a zero bound is open.
+// Location is the time zone dates are read in and buckets are aligned to.
+type TimeRange struct {
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+}
+
+// ParseTimeRange reads from and to as RFC 3339 times or YYYY-MM-DD dates in
+// the IANA time zone tz (UTC if empty). A date for to includes that whole day.
+func ParseTimeRange(from, to, tz string) (TimeRange, error) {
+	if tz == "" {
+		tz = "UTC"
+	}
+	// "Local" depends on the server and is unknown to Postgres
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return TimeRange{}, ErrInvalidTimezone
+	}
+
+	r := TimeRange{Location: loc}
+	if r.From, err = parseRangeBound(from, loc, false); err != nil {
+		return TimeRange{}, err
+	}
+	if r.To, err = parseRangeBound(to, loc, true); err != nil {
+		return TimeRange{}, err
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return TimeRange{}, ErrInvalidTimeRange
+	}
+	return r, nil
+}
+
+func parseRangeBound(value string, loc *time.Location, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, value, loc)
+	if err != nil {
+		return time.Time{}, ErrInvalidTimeRange
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// filter selects the human clicks in the range
+func (r TimeRange) filter() repository.ClickFilter {
+	return repository.ClickFilter{From: r.From, To: r.To}
+}
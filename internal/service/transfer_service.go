@@ -0,0 +1,193 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/repository"
+)
+
+// maxTransferLinks limits how many links one transfer may move
+const maxTransferLinks = 100
+
+// LinkRef identifies a link by short code and the domain it is served on
+type LinkRef struct {
+	Domain    string // empty for the shared domain
+	ShortCode string
+}
+
+// TransferService moves links between accounts. The recipient must accept
+// a transfer before ownership changes.
+type TransferService struct {
+	transferRepo repository.LinkTransferRepository
+	linkRepo     repository.LinkRepository
+	userRepo     repository.UserRepository
+	txManager    repository.TransactionManager
+	linkService  *LinkService
+}
+
+// NewTransferService creates a new transfer service
+func NewTransferService(
+	transferRepo repository.LinkTransferRepository,
+	linkRepo repository.LinkRepository,
+	userRepo repository.UserRepository,
+	txManager repository.TransactionManager,
+	linkService *LinkService,
+) *TransferService {
+	return &TransferService{
+		transferRepo: transferRepo,
+		linkRepo:     linkRepo,
+		userRepo:     userRepo,
+		txManager:    txManager,
+		linkService:  linkService,
+	}
+}
+
+// CreateTransfer offers links owned by fromUserID to the user with the given email.
+// Links on a branded domain keep their URL, so they can only go to a recipient
+// who owns the verified domain.
+func (s *TransferService) CreateTransfer(fromUserID uint, email string, refs []LinkRef) (*models.LinkTransfer, error) {
+	if len(refs) == 0 || len(refs) > maxTransferLinks {
+		return nil, ErrInvalidTransfer
+	}
+
+	recipient, err := s.userRepo.GetByEmail(strings.TrimSpace(email))
+	if err != nil || recipient == nil || recipient.IsGuest() {
+		return nil, ErrRecipientNotFound
+	}
+	if recipient.ID == fromUserID {
+		return nil, ErrInvalidTransfer
+	}
+	sender, err := s.userRepo.GetByID(fromUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]models.Link, 0, len(refs))
+	seen := make(map[uint]bool, len(refs))
+	for _, ref := range refs {
+		link, err := s.linkService.GetLinkWithAnalytics(ref.Domain, ref.ShortCode, fromUserID)
+		if err != nil {
+			return nil, err
+		}
+		if link.DomainID != nil && (link.Domain == nil || link.Domain.UserID != recipient.ID || !link.Domain.IsVerified()) {
+			return nil, ErrTransferDomain
+		}
+		if !seen[link.ID] {
+			seen[link.ID] = true
+			links = append(links, *link)
+		}
+	}
+
+	transfer := &models.LinkTransfer{
+		FromUserID: fromUserID,
+		ToUserID:   recipient.ID,
+		Status:     models.TransferPending,
+		FromUser:   sender,
+		ToUser:     recipient,
+		Links:      links,
+	}
+	if err := s.transferRepo.Create(transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// ListTransfers returns the pending transfers sent or received by a user
+func (s *TransferService) ListTransfers(userID uint) ([]*models.LinkTransfer, error) {
+	return s.transferRepo.GetPendingByUserID(userID)
+}
+
+// AcceptTransfer moves the links to the recipient. Links the sender no longer
+// owns are skipped; click history stays attached to each link.
+func (s *TransferService) AcceptTransfer(userID, transferID uint) (*models.LinkTransfer, error) {
+	transfer, err := s.getPendingTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.ToUserID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	linkIDs := make([]uint, len(transfer.Links))
+	for i, link := range transfer.Links {
+		linkIDs[i] = link.ID
+	}
+
+	err = s.txManager.ExecuteInTransaction(func(tx *gorm.DB) error {
+		if err := s.setStatus(tx, transfer, models.TransferAccepted); err != nil {
+			return err
+		}
+		_, err := s.linkRepo.TransferOwnershipWithTx(tx, linkIDs, transfer.FromUserID, transfer.ToUserID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// DeclineTransfer lets the recipient refuse a transfer
+func (s *TransferService) DeclineTransfer(userID, transferID uint) (*models.LinkTransfer, error) {
+	transfer, err := s.getPendingTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.ToUserID != userID {
+		return nil, ErrUnauthorized
+	}
+	return s.finish(transfer, models.TransferDeclined)
+}
+
+// CancelTransfer lets the sender withdraw a transfer
+func (s *TransferService) CancelTransfer(userID, transferID uint) (*models.LinkTransfer, error) {
+	transfer, err := s.getPendingTransfer(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.FromUserID != userID {
+		return nil, ErrUnauthorized
+	}
+	return s.finish(transfer, models.TransferCanceled)
+}
+
+// finish closes a transfer without moving any links
+func (s *TransferService) finish(transfer *models.LinkTransfer, status string) (*models.LinkTransfer, error) {
+	err := s.txManager.ExecuteInTransaction(func(tx *gorm.DB) error {
+		return s.setStatus(tx, transfer, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// setStatus records the response to a transfer, failing if another response won the race
+func (s *TransferService) setStatus(tx *gorm.DB, transfer *models.LinkTransfer, status string) error {
+	now := time.Now()
+	transfer.Status = status
+	transfer.RespondedAt = &now
+
+	updated, err := s.transferRepo.UpdateStatusWithTx(tx, transfer)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrTransferNotPending
+	}
+	return nil
+}
+
+func (s *TransferService) getPendingTransfer(transferID uint) (*models.LinkTransfer, error) {
+	transfer, err := s.transferRepo.GetByID(transferID)
+	if err != nil {
+		return nil, ErrTransferNotFound
+	}
+	if !transfer.IsPending() {
+		return nil, ErrTransferNotPending
+	}
+	return transfer, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/pkg/utils"
+)
+
+// maxVCardFieldRunes limits each card field; the note may be as long as other payload text
+const maxVCardFieldRunes = 200
+
+// VCardText encodes a hosted contact card as vCard 3.0
+func VCardText(card *models.VCard) string {
+	return toVCardPayload(card).String()
+}
+
+// VCardFileName returns a download file name for a contact card
+func VCardFileName(card *models.VCard) string {
+	name := strings.Map(func(r rune) rune {
+		if r == ' ' {
+			return '-'
+		}
+		if r < 0x20 || strings.ContainsRune(`"\/:*?<>|;`, r) {
+			return -1
+		}
+		return r
+	}, toVCardPayload(card).FullName())
+	if name == "" {
+		name = "contact"
+	}
+	return name + ".vcf"
+}
+
+func toVCardPayload(card *models.VCard) utils.VCard {
+	return utils.VCard{
+		FirstName:    card.FirstName,
+		LastName:     card.LastName,
+		Organization: card.Organization,
+		Title:        card.Title,
+		Phone:        card.Phone,
+		Email:        card.Email,
+		Website:      card.Website,
+		Address:      card.Address,
+		Note:         card.Note,
+	}
+}
+
+// validateVCard trims and checks a contact card; it needs a name or an organization
+func validateVCard(card *models.VCard) error {
+	fields := []*string{&card.FirstName, &card.LastName, &card.Organization, &card.Title,
+		&card.Phone, &card.Email, &card.Website, &card.Address}
+	for _, field := range fields {
+		*field = strings.TrimSpace(*field)
+		if utf8.RuneCountInString(*field) > maxVCardFieldRunes {
+			return ErrInvalidVCard
+		}
+	}
+	card.Note = strings.TrimSpace(card.Note)
+	if utf8.RuneCountInString(card.Note) > maxPayloadText {
+		return ErrInvalidVCard
+	}
+
+	if card.FirstName == "" && card.LastName == "" && card.Organization == "" {
+		return ErrInvalidVCard
+	}
+	if card.Phone != "" {
+		phone, ok := utils.NormalizePhone(card.Phone)
+		if !ok {
+			return ErrInvalidVCard
+		}
+		card.Phone = phone
+	}
+	if card.Email != "" && !utils.ValidateEmail(card.Email) {
+		return ErrInvalidVCard
+	}
+	if card.Website != "" && !utils.ValidateURL(card.Website) {
+		return ErrInvalidVCard
+	}
+	return nil
+}
+
+// VCardPath is where a vCard link sends visitors to download its card
+func VCardPath(shortCode string) string {
+	return "/" + shortCode + "/vcard"
+}
+
+// CreateVCardLink hosts a contact card behind a new short link owned by the user.
+// domainHost selects one of the user's verified domains, empty for the shared domain.
+func (s *LinkService) CreateVCardLink(domainHost string, card *models.VCard, customAlias *string, userID uint, expiresAt *time.Time, shortCodeLength int) (*models.Link, error) {
+	if err := validateVCard(card); err != nil {
+		return nil, err
+	}
+
+	domain, err := s.resolveDomainForUser(&userID, domainHost)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storeLink(&models.Link{
+		UserID:    &userID,
+		DomainID:  domainIDPtr(domain),
+		Domain:    domain,
+		Type:      models.LinkTypeVCard,
+		ExpiresAt: expiresAt,
+		VCard:     card,
+	}, customAlias, shortCodeLength)
+}
+
+// GetVCard returns the card served by a vCard link, applying the same
+// paused and expiry rules as Redirect
+func (s *LinkService) GetVCard(host, shortCode string) (*models.VCard, error) {
+	link, err := s.GetLink(host, shortCode)
+	if err != nil || !link.IsVCard() {
+		return nil, ErrLinkNotFound
+	}
+	if link.Paused {
+		return nil, ErrLinkPaused
+	}
+	if link.IsExpired() {
+		return nil, ErrLinkExpired
+	}
+	return s.vcardOf(link)
+}
+
+// GetOwnedVCard returns the card of a vCard link owned by the user
+func (s *LinkService) GetOwnedVCard(domain, shortCode string, userID uint) (*models.VCard, error) {
+	link, err := s.getOwnedLink(domain, shortCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !link.IsVCard() {
+		return nil, ErrLinkNotFound
+	}
+	return s.vcardOf(link)
+}
+
+// UpdateVCard replaces the card of a vCard link owned by the user; printed QR codes keep working
+func (s *LinkService) UpdateVCard(domain, shortCode string, userID uint, card *models.VCard) (*models.VCard, error) {
+	link, err := s.getOwnedLink(domain, shortCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !link.IsVCard() {
+		return nil, ErrLinkNotFound
+	}
+	if err := validateVCard(card); err != nil {
+		return nil, err
+	}
+
+	card.LinkID = link.ID
+	if err := s.linkRepo.SaveVCard(card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *LinkService) vcardOf(link *models.Link) (*models.VCard, error) {
+	card, err := s.linkRepo.GetVCard(link.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLinkNotFound
+	}
+	return card, err
+}
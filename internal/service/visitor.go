@@ -0,0 +1,71 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"quocbui.dev/m/internal/repository"
+)
+
+// visitorSaltBytes is the size of a daily salt
+const visitorSaltBytes = 32
+
+// VisitorHasher derives anonymous visitor IDs for unique visitor counts.
+// The ID of an IP address and user agent is keyed with a random salt of the
+// UTC day; salts are shared by all instances through the database and deleted
+// the next day, after which no ID can be linked to its IP and user agent.
+type VisitorHasher struct {
+	salts repository.VisitorSaltRepository
+
+	mu   sync.Mutex
+	day  time.Time
+	salt []byte
+}
+
+// NewVisitorHasher creates a visitor hasher storing its salts in salts
+func NewVisitorHasher(salts repository.VisitorSaltRepository) *VisitorHasher {
+	return &VisitorHasher{salts: salts}
+}
+
+// Hash returns the visitor ID of ipAddress and userAgent on the UTC day of t
+func (h *VisitorHasher) Hash(ipAddress, userAgent string, t time.Time) (string, error) {
+	salt, err := h.saltOf(t)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(ipAddress))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// saltOf returns the salt of the UTC day of t, creating it on the first click of the day
+func (h *VisitorHasher) saltOf(t time.Time) ([]byte, error) {
+	y, m, d := t.UTC().Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.salt != nil && h.day.Equal(day) {
+		return h.salt, nil
+	}
+
+	candidate := make([]byte, visitorSaltBytes)
+	if _, err := rand.Read(candidate); err != nil {
+		return nil, err
+	}
+	salt, err := h.salts.GetOrCreate(day, candidate)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.salts.DeleteBefore(day); err != nil {
+		return nil, err
+	}
+	h.day, h.salt = day, salt
+	return salt, nil
+}
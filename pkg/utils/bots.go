@@ -0,0 +1,62 @@
+package utils
+
+import "strings"
+
+// botUserAgents are tokens of crawlers, link preview fetchers (unfurlers) and
+// HTTP tools that the user agent parser doesn't flag as bots. Matching is
+// case-insensitive; add new unfurlers here.
+var botUserAgents = []string{
+	// Link previews in chat apps and social networks
+	"slackbot",
+	"slack-imgproxy",
+	"facebookexternalhit",
+	"facebookcatalog",
+	"facebot",
+	"meta-externalagent",
+	"telegrambot",
+	"twitterbot",
+	"linkedinbot",
+	"discordbot",
+	"whatsapp/",
+	"skypeuripreview",
+	"microsoftpreview",
+	"teamsbot",
+	"pinterestbot",
+	"redditbot",
+	"applebot",
+	"iframely",
+	"embedly",
+	"vkshare",
+	"mastodon/",
+	"bitlybot",
+	"google-pagerenderer",
+	"googleimageproxy",
+	// Generic crawler markers
+	"bot/",
+	"crawler",
+	"spider",
+	"headlesschrome",
+	// HTTP clients and tools
+	"curl/",
+	"wget/",
+	"python-requests",
+	"python-urllib",
+	"go-http-client",
+	"okhttp",
+	"axios/",
+	"node-fetch",
+	"java/",
+	"libwww-perl",
+}
+
+// IsBotUserAgent reports whether a user agent belongs to a known crawler,
+// link preview fetcher or HTTP tool
+func IsBotUserAgent(uaString string) bool {
+	ua := strings.ToLower(uaString)
+	for _, token := range botUserAgents {
+		if strings.Contains(ua, token) {
+			return true
+		}
+	}
+	return false
+}
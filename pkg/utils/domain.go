@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+var hostnameRegex = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// StripPort removes the port from a host if present
+func StripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// NormalizeHost lowercases a host and strips the port and a leading "www."
+func NormalizeHost(host string) string {
+	host = StripPort(strings.ToLower(strings.TrimSpace(host)))
+	return strings.TrimPrefix(host, "www.")
+}
+
+// HostMatchesAny reports whether host is one of the given domains
+// Ports and a leading "www." are ignored on both sides
+func HostMatchesAny(host string, domains []string) bool {
+	host = NormalizeHost(host)
+	if host == "" {
+		return false
+	}
+	for _, domain := range domains {
+		if host == NormalizeHost(domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateHostname checks if a string is a fully qualified lowercase hostname
+// Rules:
+// - At least two labels, e.g. go.example.com
+// - Labels of letters, digits and inner hyphens, max 63 characters
+// - Max length 253 characters, no port
+func ValidateHostname(host string) bool {
+	return len(host) <= 253 && hostnameRegex.MatchString(host)
+}
+
+// nonPublicRanges are the IPv4 ranges not covered by the net.IP predicates:
+// "this network", which Linux dials as loopback, and carrier-grade NAT
+var nonPublicRanges = []*net.IPNet{
+	{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+}
+
+// IsPublicIP reports whether ip is a globally routable unicast address
+// Loopback, private, link-local, shared and unspecified addresses are not public
+func IsPublicIP(ip net.IP) bool {
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, r := range nonPublicRanges {
+		if r.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"math"
+	"math/bits"
+)
+
+// HLLPrecision is the number of hash bits selecting a HyperLogLog register.
+// 2^12 registers give a standard error of about 1.6%.
+const HLLPrecision = 12
+
+// HLLMaxRank is the highest rank a register can hold with 64-bit hashes
+const HLLMaxRank = 64 - HLLPrecision + 1
+
+// HyperLogLog estimates the number of distinct 64-bit hashes
+type HyperLogLog struct {
+	registers [1 << HLLPrecision]uint8
+}
+
+// NewHyperLogLog creates an empty estimator
+func NewHyperLogLog() *HyperLogLog {
+	return &HyperLogLog{}
+}
+
+// Add records a hash: its top bits select a register, the rest give the rank
+func (h *HyperLogLog) Add(hash uint64) {
+	rank := bits.LeadingZeros64(hash<<HLLPrecision|1<<(HLLPrecision-1)) + 1
+	h.Merge(int(hash>>(64-HLLPrecision)), rank)
+}
+
+// Merge raises a register to rank, e.g. to load registers aggregated elsewhere
+func (h *HyperLogLog) Merge(register, rank int) {
+	if register < 0 || register >= len(h.registers) || rank > HLLMaxRank {
+		return
+	}
+	if uint8(rank) > h.registers[register] {
+		h.registers[register] = uint8(rank)
+	}
+}
+
+// Estimate returns the approximate number of distinct hashes added
+func (h *HyperLogLog) Estimate() int64 {
+	m := float64(len(h.registers))
+	sum, zeros := 0.0, 0
+	for _, rank := range h.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// Linear counting is more accurate while many registers are empty
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(estimate))
+}
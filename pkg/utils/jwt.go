@@ -9,20 +9,31 @@ import (
 
 // Claims represents JWT claims
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID  uint   `json:"user_id"`
+	Email   string `json:"email"`
+	GuestID string `json:"guest_id,omitempty"` // set instead of UserID for anonymous guests
 	jwt.RegisteredClaims
 }
 
+// IsGuest reports whether the token identifies an anonymous guest
+func (c *Claims) IsGuest() bool {
+	return c.GuestID != ""
+}
+
 // GenerateToken generates a new JWT token for a user
 func GenerateToken(userID uint, email, secret string, expiryHours int) (string, error) {
-	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiryHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	return signClaims(&Claims{UserID: userID, Email: email}, secret, expiryHours)
+}
+
+// GenerateGuestToken generates a new JWT token for an anonymous guest
+func GenerateGuestToken(guestID, secret string, expiryHours int) (string, error) {
+	return signClaims(&Claims{GuestID: guestID}, secret, expiryHours)
+}
+
+func signClaims(claims *Claims, secret string, expiryHours int) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiryHours) * time.Hour)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
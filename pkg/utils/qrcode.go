@@ -1,63 +1,88 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // register JPEG for DecodeImage
+	"image/png"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/yeqown/go-qrcode/v2"
-	"github.com/yeqown/go-qrcode/writer/standard"
+	xdraw "golang.org/x/image/draw"
 )
 
-// GenerateQRCode generates a QR code PNG with optional logo
-func GenerateQRCode(content string, logoPath string) ([]byte, error) {
-	qrc, err := qrcode.NewWith(content,
-		qrcode.WithErrorCorrectionLevel(qrcode.ErrorCorrectionHighest),
-	)
-	if err != nil {
-		return nil, err
-	}
+// QR error correction levels, from the least to the most redundancy
+const (
+	QRCorrectionLow     = "L" // ~7% of the code can be restored
+	QRCorrectionMedium  = "M" // ~15%
+	QRCorrectionQuart   = "Q" // ~25%
+	QRCorrectionHighest = "H" // ~30%
+)
 
-	// Create temp file
-	tmpFile, err := os.CreateTemp("", "qr-*.png")
-	if err != nil {
-		return nil, err
-	}
-	tmpPath := tmpFile.Name()
-	tmpFile.Close()
-	defer os.Remove(tmpPath)
+var qrCorrectionLevels = map[string]qrcode.EncodeOption{
+	QRCorrectionLow:     qrcode.WithErrorCorrectionLevel(qrcode.ErrorCorrectionLow),
+	QRCorrectionMedium:  qrcode.WithErrorCorrectionLevel(qrcode.ErrorCorrectionMedium),
+	QRCorrectionQuart:   qrcode.WithErrorCorrectionLevel(qrcode.ErrorCorrectionQuart),
+	QRCorrectionHighest: qrcode.WithErrorCorrectionLevel(qrcode.ErrorCorrectionHighest),
+}
 
-	// Find logo file
-	logoFound := findLogoFile(logoPath)
-	fmt.Printf("Logo path: %s, Found: %s\n", logoPath, logoFound)
+// ValidQRCorrectionLevel reports whether level is one of L, M, Q or H
+func ValidQRCorrectionLevel(level string) bool {
+	_, ok := qrCorrectionLevels[level]
+	return ok
+}
 
-	options := []standard.ImageOption{
-		standard.WithBgColorRGBHex("#ffffff"),
-		standard.WithFgColorRGBHex("#000000"),
-		standard.WithQRWidth(30),
-		standard.WithBuiltinImageEncoder(standard.PNG_FORMAT),
-	}
+// qrLogoRatio is the share of the code width covered by a logo. At 20% the
+// logo hides about 4% of the modules, well within levels Q and H.
+const qrLogoRatio = 0.2
 
-	if logoFound != "" {
-		options = append(options, standard.WithLogoImageFilePNG(logoFound))
-		options = append(options, standard.WithLogoSizeMultiplier(3))
-	}
+// qrFinderSize is the width in modules of the three position markers
+const qrFinderSize = 7
+
+// QRStyle controls how QR modules are drawn
+type QRStyle struct {
+	Foreground   color.RGBA
+	Background   color.RGBA
+	RoundModules bool        // draw data modules as dots; position markers stay square
+	Logo         image.Image // optional, drawn in the center
+}
 
-	w, err := standard.New(tmpPath, options...)
+// DefaultQRStyle draws black square modules on white
+var DefaultQRStyle = QRStyle{
+	Foreground: color.RGBA{A: 0xff},
+	Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+}
+
+// GenerateQRCode generates a QR code PNG with optional logo
+func GenerateQRCode(content string, logoPath string) ([]byte, error) {
+	modules, err := QRBitmap(content, QRCorrectionHighest)
 	if err != nil {
-		fmt.Printf("Writer error: %v\n", err)
 		return nil, err
 	}
 
-	if err := qrc.Save(w); err != nil {
-		fmt.Printf("Save error: %v\n", err)
-		return nil, err
+	style := DefaultQRStyle
+	if logoFound := findLogoFile(logoPath); logoFound != "" {
+		if data, err := os.ReadFile(logoFound); err == nil {
+			style.Logo, _ = DecodeImage(data, 0)
+		}
 	}
 
-	return os.ReadFile(tmpPath)
+	return RenderQRCodePNG(modules, (len(modules)+8)*10, 4, style)
 }
 
 func findLogoFile(logoPath string) string {
+	if logoPath == "" {
+		return ""
+	}
+
 	// Try original path
 	if _, err := os.Stat(logoPath); err == nil {
 		return logoPath
@@ -72,3 +97,224 @@ func findLogoFile(logoPath string) string {
 
 	return ""
 }
+
+// bitmapWriter captures the module matrix of a QR code
+type bitmapWriter struct {
+	modules [][]bool
+}
+
+func (w *bitmapWriter) Write(mat qrcode.Matrix) error {
+	w.modules = mat.Bitmap()
+	return nil
+}
+
+func (w *bitmapWriter) Close() error {
+	return nil
+}
+
+// QRBitmap encodes content as a QR code with the given error correction
+// level and returns its modules, true for dark
+func QRBitmap(content, level string) ([][]bool, error) {
+	option, ok := qrCorrectionLevels[level]
+	if !ok {
+		return nil, fmt.Errorf("unknown QR error correction level %q", level)
+	}
+
+	qrc, err := qrcode.NewWith(content, option)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &bitmapWriter{}
+	if err := qrc.Save(w); err != nil {
+		return nil, err
+	}
+	return w.modules, nil
+}
+
+// RenderQRCodePNG draws QR modules as a size x size PNG with a quiet zone of
+// margin modules. Modules are whole pixels; leftover pixels widen the border.
+func RenderQRCodePNG(modules [][]bool, size, margin int, style QRStyle) ([]byte, error) {
+	n := len(modules)
+	total := n + 2*margin
+	scale := max(size/total, 1)
+	size = max(size, scale*total)
+	offset := (size-scale*total)/2 + margin*scale
+
+	var img draw.Image
+	if style.Logo == nil {
+		img = image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{style.Background, style.Foreground})
+	} else {
+		img = image.NewRGBA(image.Rect(0, 0, size, size))
+	}
+	draw.Draw(img, img.Bounds(), image.NewUniform(style.Background), image.Point{}, draw.Src)
+
+	logoBox := qrLogoBox(n, style.Logo)
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark || logoBox.Overlaps(image.Rect(x, y, x+1, y+1)) {
+				continue
+			}
+			round := style.RoundModules && !isQRFinder(x, y, n)
+			for py := 0; py < scale; py++ {
+				for px := 0; px < scale; px++ {
+					if round && !insideDot(px, py, scale) {
+						continue
+					}
+					img.Set(offset+x*scale+px, offset+y*scale+py, style.Foreground)
+				}
+			}
+		}
+	}
+
+	if style.Logo != nil {
+		dst := image.Rect(logoBox.Min.X*scale, logoBox.Min.Y*scale, logoBox.Max.X*scale, logoBox.Max.Y*scale).Add(image.Pt(offset, offset))
+		xdraw.CatmullRom.Scale(img, fitRect(dst, style.Logo.Bounds()), style.Logo, style.Logo.Bounds(), xdraw.Over, nil)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderQRCodeSVG draws QR modules as a size x size SVG with a quiet zone of margin modules
+func RenderQRCodeSVG(modules [][]bool, size, margin int, style QRStyle) ([]byte, error) {
+	n := len(modules)
+	total := n + 2*margin
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, total, total)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/><path fill="%s" d="`,
+		total, total, HexColor(style.Background), HexColor(style.Foreground))
+
+	logoBox := qrLogoBox(n, style.Logo)
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark || logoBox.Overlaps(image.Rect(x, y, x+1, y+1)) {
+				continue
+			}
+			if style.RoundModules && !isQRFinder(x, y, n) {
+				fmt.Fprintf(&buf, "M%d %d.5a.5 .5 0 1 0 1 0a.5 .5 0 1 0-1 0z", x+margin, y+margin)
+			} else {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x+margin, y+margin)
+			}
+		}
+	}
+	buf.WriteString(`"/>`)
+
+	if style.Logo != nil {
+		var logo bytes.Buffer
+		if err := png.Encode(&logo, style.Logo); err != nil {
+			return nil, err
+		}
+		box := logoBox.Add(image.Pt(margin, margin))
+		fmt.Fprintf(&buf, `<image x="%d" y="%d" width="%d" height="%d" href="data:image/png;base64,%s"/>`,
+			box.Min.X, box.Min.Y, box.Dx(), box.Dy(), base64.StdEncoding.EncodeToString(logo.Bytes()))
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
+
+// qrLogoBox returns the centered area in modules kept clear for a logo,
+// or an empty rectangle without a logo
+func qrLogoBox(n int, logo image.Image) image.Rectangle {
+	if logo == nil {
+		return image.Rectangle{}
+	}
+	side := int(float64(n) * qrLogoRatio)
+	if side%2 != n%2 {
+		side++
+	}
+	start := (n - side) / 2
+	return image.Rect(start, start, start+side, start+side)
+}
+
+// fitRect centers a rectangle with the aspect ratio of src inside dst
+func fitRect(dst, src image.Rectangle) image.Rectangle {
+	w, h := dst.Dx(), dst.Dy()
+	if src.Dx()*h > src.Dy()*w {
+		h = max(src.Dy()*w/src.Dx(), 1)
+	} else {
+		w = max(src.Dx()*h/src.Dy(), 1)
+	}
+	x := dst.Min.X + (dst.Dx()-w)/2
+	y := dst.Min.Y + (dst.Dy()-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// isQRFinder reports whether a module belongs to one of the three position markers
+func isQRFinder(x, y, n int) bool {
+	left, top := x < qrFinderSize, y < qrFinderSize
+	right, bottom := x >= n-qrFinderSize, y >= n-qrFinderSize
+	return (left && top) || (right && top) || (left && bottom)
+}
+
+// insideDot reports whether pixel (px, py) of a scale x scale module lies in its inscribed circle
+func insideDot(px, py, scale int) bool {
+	r := float64(scale) / 2
+	dx, dy := float64(px)+0.5-r, float64(py)+0.5-r
+	return dx*dx+dy*dy <= r*r
+}
+
+// ParseHexColor parses a #rrggbb color
+func ParseHexColor(s string) (color.RGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// HexColor formats a color as #rrggbb
+func HexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// RelativeLuminance returns the WCAG relative luminance of a color, 0 for black to 1 for white
+func RelativeLuminance(c color.RGBA) float64 {
+	channel := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+// ContrastRatio returns the WCAG contrast ratio of two colors, from 1 to 21
+func ContrastRatio(a, b color.RGBA) float64 {
+	la, lb := RelativeLuminance(a), RelativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// ErrImageTooLarge is returned by DecodeImage for images wider or taller than allowed
+var ErrImageTooLarge = errors.New("image is too large")
+
+// DecodeImage decodes a PNG or JPEG image. The dimensions are checked before
+// decoding so oversized images are rejected cheaply; maxSide 0 disables the check.
+func DecodeImage(data []byte, maxSide int) (image.Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if format != "png" && format != "jpeg" {
+		return nil, fmt.Errorf("unsupported image format %q", format)
+	}
+	if maxSide > 0 && (config.Width > maxSide || config.Height > maxSide) {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Wi-Fi security types understood by phone cameras
+const (
+	WiFiSecurityWPA  = "WPA"
+	WiFiSecurityWEP  = "WEP"
+	WiFiSecurityOpen = "nopass"
+)
+
+// WiFiPayload is a network that phones join when the QR code is scanned
+type WiFiPayload struct {
+	SSID     string
+	Password string
+	Security string // WPA, WEP or nopass
+	Hidden   bool
+}
+
+// String encodes the network in the WIFI: format
+func (p WiFiPayload) String() string {
+	var b strings.Builder
+	b.WriteString("WIFI:T:" + p.Security + ";S:" + escapeMeCard(p.SSID) + ";")
+	if p.Security != WiFiSecurityOpen {
+		b.WriteString("P:" + escapeMeCard(p.Password) + ";")
+	}
+	if p.Hidden {
+		b.WriteString("H:true;")
+	}
+	b.WriteString(";")
+	return b.String()
+}
+
+// EmailPayload is a pre-filled email
+type EmailPayload struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// String encodes the email as a mailto: URL
+func (p EmailPayload) String() string {
+	query := make([]string, 0, 2)
+	if p.Subject != "" {
+		query = append(query, "subject="+mailtoEscape(p.Subject))
+	}
+	if p.Body != "" {
+		query = append(query, "body="+mailtoEscape(p.Body))
+	}
+
+	result := "mailto:" + p.To
+	if len(query) > 0 {
+		result += "?" + strings.Join(query, "&")
+	}
+	return result
+}
+
+// mailtoEscape escapes a mailto: header value; spaces must be %20, not +
+func mailtoEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// SMSPayload is a pre-filled text message
+type SMSPayload struct {
+	Phone   string
+	Message string
+}
+
+// String encodes the message in the SMSTO: format
+func (p SMSPayload) String() string {
+	return "SMSTO:" + p.Phone + ":" + p.Message
+}
+
+// VCard is a contact card
+type VCard struct {
+	FirstName    string
+	LastName     string
+	Organization string
+	Title        string
+	Phone        string
+	Email        string
+	Website      string
+	Address      string
+	Note         string
+}
+
+// FullName returns the display name of the card, the organization if it has no name
+func (v VCard) FullName() string {
+	if name := strings.TrimSpace(v.FirstName + " " + v.LastName); name != "" {
+		return name
+	}
+	return v.Organization
+}
+
+// String encodes the card as vCard 3.0
+func (v VCard) String() string {
+	var b strings.Builder
+	line := func(name, value string) {
+		if value != "" {
+			b.WriteString(name + ":" + value + "\r\n")
+		}
+	}
+
+	b.WriteString("BEGIN:VCARD\r\nVERSION:3.0\r\n")
+	b.WriteString("N:" + escapeVCard(v.LastName) + ";" + escapeVCard(v.FirstName) + ";;;\r\n")
+	b.WriteString("FN:" + escapeVCard(v.FullName()) + "\r\n")
+	line("ORG", escapeVCard(v.Organization))
+	line("TITLE", escapeVCard(v.Title))
+	line("TEL;TYPE=CELL", escapeVCard(v.Phone))
+	line("EMAIL", escapeVCard(v.Email))
+	line("URL", escapeVCard(v.Website))
+	if v.Address != "" {
+		line("ADR", ";;"+escapeVCard(v.Address)+";;;;")
+	}
+	line("NOTE", escapeVCard(v.Note))
+	b.WriteString("END:VCARD\r\n")
+	return b.String()
+}
+
+var meCardEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+
+// escapeMeCard escapes the separators of the WIFI: format
+func escapeMeCard(s string) string {
+	return meCardEscaper.Replace(s)
+}
+
+var vCardEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeVCard escapes vCard text values
+func escapeVCard(s string) string {
+	return vCardEscaper.Replace(s)
+}
@@ -0,0 +1,93 @@
+package utils
+
+import "strings"
+
+// ReservedWords is a registry of short codes that can never be claimed.
+// Reserved words match a whole code. Blocked words (e.g. offensive terms)
+// match anywhere inside a generated code, but only a whole word of a custom
+// alias, so people can still pick aliases like "class" or "scunthorpe".
+// Matching is case-insensitive.
+// The registry is filled during startup and only read afterwards.
+type ReservedWords struct {
+	reserved map[string]struct{}
+	blocked  []string
+}
+
+// NewReservedWords creates a registry with the given reserved and blocked words
+func NewReservedWords(reserved, blocked []string) *ReservedWords {
+	r := &ReservedWords{reserved: make(map[string]struct{})}
+	r.Reserve(reserved...)
+	r.Block(blocked...)
+	return r
+}
+
+// Reserve adds words that may not be used as a whole code
+func (r *ReservedWords) Reserve(words ...string) {
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			r.reserved[word] = struct{}{}
+		}
+	}
+}
+
+// Block adds words that may not appear in a code
+func (r *ReservedWords) Block(words ...string) {
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			r.blocked = append(r.blocked, word)
+		}
+	}
+}
+
+// ReserveRoutes reserves the first static segment of each route path,
+// e.g. "/api/v1/shorten" reserves "api". Parameter segments are skipped.
+func (r *ReservedWords) ReserveRoutes(paths ...string) {
+	for _, path := range paths {
+		segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+		if segment == "" || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			continue
+		}
+		r.Reserve(segment)
+	}
+}
+
+// IsReserved reports whether a generated code collides with a reserved word
+// or contains a blocked word
+func (r *ReservedWords) IsReserved(code string) bool {
+	if r == nil {
+		return false
+	}
+
+	code = strings.ToLower(code)
+	if _, ok := r.reserved[code]; ok {
+		return true
+	}
+	for _, word := range r.blocked {
+		if strings.Contains(code, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsReservedAlias reports whether a custom alias collides with a reserved word
+// or has a blocked word between its "-" and "_" separators
+func (r *ReservedWords) IsReservedAlias(alias string) bool {
+	if r == nil {
+		return false
+	}
+
+	alias = strings.ToLower(alias)
+	if _, ok := r.reserved[alias]; ok {
+		return true
+	}
+	words := strings.FieldsFunc(alias, func(c rune) bool { return c == '-' || c == '_' })
+	for _, word := range words {
+		for _, blocked := range r.blocked {
+			if word == blocked {
+				return true
+			}
+		}
+	}
+	return false
+}
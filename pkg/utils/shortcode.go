@@ -2,23 +2,128 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
 	"math/big"
+	"strings"
 )
 
-const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+// DefaultAlphabet is the default short code alphabet
+const DefaultAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 
-// GenerateShortCode generates a random short code of specified length
+// GenerateShortCode generates a random code of specified length from DefaultAlphabet.
+// Link codes use the configured alphabet through the service's CodeGenerator.
 func GenerateShortCode(length int) (string, error) {
+	return GenerateShortCodeFromAlphabet(DefaultAlphabet, length)
+}
+
+// GenerateShortCodeFromAlphabet generates a random short code using the given alphabet
+func GenerateShortCodeFromAlphabet(alphabet string, length int) (string, error) {
 	result := make([]byte, length)
-	charsetLen := big.NewInt(int64(len(charset)))
+	alphabetLen := big.NewInt(int64(len(alphabet)))
 
 	for i := range result {
-		num, err := rand.Int(rand.Reader, charsetLen)
+		num, err := rand.Int(rand.Reader, alphabetLen)
 		if err != nil {
 			return "", err
 		}
-		result[i] = charset[num.Int64()]
+		result[i] = alphabet[num.Int64()]
 	}
 
 	return string(result), nil
 }
+
+// ValidateAlphabet checks if an alphabet can be used for short codes
+// Rules:
+// - At least 2 characters
+// - No duplicate characters
+// - Only characters allowed in aliases
+func ValidateAlphabet(alphabet string) bool {
+	if len(alphabet) < 2 || !aliasRegex.MatchString(alphabet) {
+		return false
+	}
+	for i := range alphabet {
+		if strings.IndexByte(alphabet[i+1:], alphabet[i]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeBaseN encodes n in base len(alphabet), left-padded to minLength
+func EncodeBaseN(n *big.Int, alphabet string, minLength int) string {
+	base := big.NewInt(int64(len(alphabet)))
+	value := new(big.Int).Set(n)
+	mod := new(big.Int)
+
+	var digits []byte
+	for value.Sign() > 0 {
+		value.DivMod(value, base, mod)
+		digits = append(digits, alphabet[mod.Int64()])
+	}
+	for len(digits) < minLength || len(digits) == 0 {
+		digits = append(digits, alphabet[0])
+	}
+
+	// Digits were produced least significant first
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
+
+// ShuffleAlphabet deterministically permutes an alphabet using a salt
+func ShuffleAlphabet(alphabet, salt string) string {
+	if salt == "" {
+		return alphabet
+	}
+
+	chars := []byte(alphabet)
+	seed := sha256.Sum256([]byte(salt))
+	for i := len(chars) - 1; i > 0; i-- {
+		seed = sha256.Sum256(seed[:])
+		j := int(binary.BigEndian.Uint64(seed[:8]) % uint64(i+1))
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return string(chars)
+}
+
+// ObfuscateID turns a sequential ID into a non-sequential code (hashids-style).
+// IDs are permuted within the keyspace of their code length by multiplying
+// with a salt-derived factor coprime to the keyspace, so distinct IDs always
+// give distinct codes. The code is at least minLength characters long.
+func ObfuscateID(id uint64, alphabet, salt string, minLength int) string {
+	alphabet = ShuffleAlphabet(alphabet, salt)
+	base := big.NewInt(int64(len(alphabet)))
+	n := new(big.Int).SetUint64(id)
+
+	// Smallest keyspace base^length that holds id
+	length := max(minLength, 1)
+	keyspace := new(big.Int).Exp(base, big.NewInt(int64(length)), nil)
+	for n.Cmp(keyspace) >= 0 {
+		length++
+		keyspace.Mul(keyspace, base)
+	}
+
+	n.Mul(n, obfuscationFactor(salt, keyspace))
+	n.Mod(n, keyspace)
+	return EncodeBaseN(n, alphabet, length)
+}
+
+// obfuscationFactor derives a multiplier from the salt that is coprime to keyspace
+func obfuscationFactor(salt string, keyspace *big.Int) *big.Int {
+	seed := sha256.Sum256([]byte("obfuscate:" + salt))
+	factor := new(big.Int).SetBytes(seed[:])
+	factor.Mod(factor, keyspace)
+
+	one := big.NewInt(1)
+	gcd := new(big.Int)
+	for factor.Cmp(one) <= 0 || gcd.GCD(nil, nil, factor, keyspace).Cmp(one) != 0 {
+		factor.Add(factor, one)
+		if factor.Cmp(keyspace) >= 0 {
+			factor.SetInt64(1)
+			break
+		}
+	}
+	return factor
+}
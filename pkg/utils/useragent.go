@@ -10,17 +10,20 @@ type UserAgentInfo struct {
 	BrowserVer string
 	OS         string
 	Device     string
+	IsBot      bool // crawler or link preview, see IsBotUserAgent
 }
 
 // ParseUserAgent parses a user agent string and extracts information
 func ParseUserAgent(uaString string) *UserAgentInfo {
 	ua := useragent.New(uaString)
 
+	isBot := ua.Bot() || IsBotUserAgent(uaString)
+
 	device := "Desktop"
-	if ua.Mobile() {
-		device = "Mobile"
-	} else if ua.Bot() {
+	if isBot {
 		device = "Bot"
+	} else if ua.Mobile() {
+		device = "Mobile"
 	}
 
 	browserName, browserVer := ua.Browser()
@@ -30,5 +33,6 @@ func ParseUserAgent(uaString string) *UserAgentInfo {
 		BrowserVer: browserVer,
 		OS:         ua.OS(),
 		Device:     device,
+		IsBot:      isBot,
 	}
 }
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"net/mail"
 	"net/url"
 	"regexp"
 	"strings"
@@ -8,6 +9,10 @@ import (
 
 var aliasRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 
+var phoneRegex = regexp.MustCompile(`^\+?[0-9]{3,15}$`)
+
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
 // ValidateURL checks if a string is a valid URL
 // Rules:
 // - Must have http or https scheme
@@ -53,3 +58,24 @@ func ValidateAlias(alias string) bool {
 	}
 	return aliasRegex.MatchString(alias)
 }
+
+// ValidateEmail checks if a string is a bare email address
+// Rules:
+// - Max length 254 characters
+// - No display name or angle brackets
+func ValidateEmail(email string) bool {
+	if len(email) > 254 {
+		return false
+	}
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// NormalizePhone strips separators from a phone number and reports whether it is valid
+// Rules:
+// - Optional leading +
+// - 3-15 digits (E.164 allows at most 15)
+func NormalizePhone(phone string) (string, bool) {
+	phone = phoneSeparators.Replace(strings.TrimSpace(phone))
+	return phone, phoneRegex.MatchString(phone)
+}
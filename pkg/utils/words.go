@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// Word lists for readable codes. Words are kept short so that
+// "adjective-noun-NNNNN" always fits in the 20 character short code column.
+var readableAdjectives = []string{
+	"able", "bold", "brave", "bright", "calm", "clever", "cool", "cozy",
+	"crisp", "eager", "early", "fair", "fancy", "fast", "fresh", "gentle",
+	"giant", "glad", "golden", "grand", "green", "happy", "hardy", "honest",
+	"jolly", "keen", "kind", "lively", "lucky", "mellow", "merry", "mighty",
+	"modest", "neat", "noble", "polite", "proud", "quick", "quiet", "rapid",
+	"ready", "royal", "rustic", "sharp", "shiny", "silent", "silver", "simple",
+	"smart", "snappy", "solid", "spicy", "steady", "sunny", "super", "swift",
+	"tidy", "tiny", "vivid", "warm", "wise", "witty", "young", "zesty",
+}
+
+var readableNouns = []string{
+	"apple", "badger", "bamboo", "beacon", "bear", "breeze", "brook", "cactus",
+	"canyon", "cedar", "cloud", "comet", "coral", "daisy", "dolphin", "dragon",
+	"eagle", "ember", "falcon", "fern", "forest", "fox", "garden", "glacier",
+	"harbor", "hawk", "island", "jaguar", "koala", "lagoon", "lemon", "lion",
+	"lotus", "maple", "meadow", "meteor", "moon", "otter", "owl", "panda",
+	"parrot", "pebble", "pepper", "pine", "planet", "puma", "raven", "river",
+	"robin", "rocket", "salmon", "shadow", "spruce", "star", "stone", "summit",
+	"tiger", "tulip", "valley", "walrus", "willow", "wolf", "yak", "zebra",
+}
+
+// Readable codes end in 2 to 5 digits, 409,600 to 409,600,000 codes in all
+const (
+	MinReadableDigits = 2
+	MaxReadableDigits = 5
+)
+
+// GenerateReadableCode generates a random human-friendly code like "brave-otter-42"
+func GenerateReadableCode() (string, error) {
+	return GenerateReadableCodeWithDigits(MinReadableDigits)
+}
+
+// GenerateReadableCodeWithDigits generates a readable code ending in the given
+// number of digits, clamped to MinReadableDigits..MaxReadableDigits
+func GenerateReadableCodeWithDigits(digits int) (string, error) {
+	digits = min(max(digits, MinReadableDigits), MaxReadableDigits)
+	adjective, err := randomWord(readableAdjectives)
+	if err != nil {
+		return "", err
+	}
+	noun, err := randomWord(readableNouns)
+	if err != nil {
+		return "", err
+	}
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	num, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%s-%0*d", adjective, noun, digits, num.Int64()), nil
+}
+
+func randomWord(words []string) (string, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+	if err != nil {
+		return "", err
+	}
+	return words[i.Int64()], nil
+}
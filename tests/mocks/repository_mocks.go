@@ -1,8 +1,16 @@
 package mocks
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
 	"quocbui.dev/m/internal/dto"
 	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/repository"
+	"quocbui.dev/m/pkg/utils"
 
 	"gorm.io/gorm"
 )
@@ -13,6 +21,8 @@ type MockUserRepository struct {
 	CreateErr     error
 	GetByIDErr    error
 	GetByEmailErr error
+	LinkRepo      *MockLinkRepository // optional, links are moved by MergeWithTx and ArchiveWithTx
+	ActiveUsers   map[uint]bool       // users treated as having recent clicks by GetInactiveGuests
 }
 
 func NewMockUserRepository() *MockUserRepository {
@@ -57,18 +67,92 @@ func (m *MockUserRepository) Update(user *models.User) error {
 	return nil
 }
 
+func (m *MockUserRepository) MergeWithTx(tx *gorm.DB, fromUserID, toUserID uint) (int64, error) {
+	var moved int64
+	if m.LinkRepo != nil {
+		for _, link := range m.LinkRepo.Links {
+			if link.UserID != nil && *link.UserID == fromUserID {
+				owner := toUserID
+				link.UserID = &owner
+				moved++
+			}
+		}
+	}
+	for email, user := range m.Users {
+		if user.ID == fromUserID {
+			delete(m.Users, email)
+		}
+	}
+	return moved, nil
+}
+
+func (m *MockUserRepository) GetInactiveGuests(createdBefore, activeSince time.Time, limit int) ([]*models.User, error) {
+	var guests []*models.User
+	for _, user := range m.Users {
+		if !user.IsGuest() || !user.CreatedAt.Before(createdBefore) || m.ActiveUsers[user.ID] {
+			continue
+		}
+		guests = append(guests, user)
+		if len(guests) == limit {
+			break
+		}
+	}
+	return guests, nil
+}
+
+func (m *MockUserRepository) CountExpiredLinks(userID uint) (int64, error) {
+	var count int64
+	if m.LinkRepo != nil {
+		for _, link := range m.LinkRepo.Links {
+			if link.UserID != nil && *link.UserID == userID && link.IsExpired() {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (m *MockUserRepository) ArchiveWithTx(tx *gorm.DB, userID uint) (int64, error) {
+	var archived int64
+	if m.LinkRepo != nil {
+		for key, link := range m.LinkRepo.Links {
+			if link.UserID == nil || *link.UserID != userID {
+				continue
+			}
+			if link.IsExpired() {
+				delete(m.LinkRepo.Links, key)
+				archived++
+			}
+			link.UserID = nil
+			link.GuestID = nil
+		}
+	}
+	for email, user := range m.Users {
+		if user.ID == userID {
+			delete(m.Users, email)
+		}
+	}
+	return archived, nil
+}
+
 // MockLinkRepository is a mock implementation of LinkRepository
 type MockLinkRepository struct {
-	Links     map[string]*models.Link
-	CreateErr error
-	GetErr    error
-	DeleteErr error
-	NextID    uint
+	Links       map[string]*models.Link
+	VCards      map[uint]*models.VCard
+	CreateErr   error
+	GetErr      error
+	DeleteErr   error
+	NextID      uint
+	Sequence    uint64
+	SequenceErr error
+
+	ActiveGuests map[string]bool // guests treated as having recent clicks by GetAbandonedGuests
 }
 
 func NewMockLinkRepository() *MockLinkRepository {
 	return &MockLinkRepository{
 		Links:  make(map[string]*models.Link),
+		VCards: make(map[uint]*models.VCard),
 		NextID: 1,
 	}
 }
@@ -77,9 +161,37 @@ func (m *MockLinkRepository) Create(link *models.Link) error {
 	if m.CreateErr != nil {
 		return m.CreateErr
 	}
+	key := linkKey(link.DomainID, link.ShortCode)
+	if _, exists := m.Links[key]; exists {
+		return repository.ErrDuplicateKey
+	}
 	link.ID = m.NextID
 	m.NextID++
-	m.Links[link.ShortCode] = link
+	m.Links[key] = link
+	if link.VCard != nil {
+		link.VCard.LinkID = link.ID
+		m.VCards[link.ID] = link.VCard
+	}
+	return nil
+}
+
+// linkKey keys shared domain links by short code and branded ones by "domainID/code"
+func linkKey(domainID *uint, shortCode string) string {
+	if domainID == nil || *domainID == 0 {
+		return shortCode
+	}
+	return fmt.Sprintf("%d/%s", *domainID, shortCode)
+}
+
+func (m *MockLinkRepository) GetVCard(linkID uint) (*models.VCard, error) {
+	if card, ok := m.VCards[linkID]; ok {
+		return card, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockLinkRepository) SaveVCard(card *models.VCard) error {
+	m.VCards[card.LinkID] = card
 	return nil
 }
 
@@ -99,18 +211,31 @@ func (m *MockLinkRepository) GetByID(id uint) (*models.Link, error) {
 	return nil, gorm.ErrRecordNotFound
 }
 
-func (m *MockLinkRepository) GetByShortCode(shortCode string) (*models.Link, error) {
+func (m *MockLinkRepository) GetByShortCode(domainID uint, shortCode string) (*models.Link, error) {
 	if m.GetErr != nil {
 		return nil, m.GetErr
 	}
-	if link, ok := m.Links[shortCode]; ok {
+	if link, ok := m.Links[linkKey(&domainID, shortCode)]; ok {
 		return link, nil
 	}
 	return nil, gorm.ErrRecordNotFound
 }
 
-func (m *MockLinkRepository) GetByShortCodeForUpdate(tx *gorm.DB, shortCode string) (*models.Link, error) {
-	return m.GetByShortCode(shortCode)
+func (m *MockLinkRepository) GetByShortCodes(domainID uint, shortCodes []string) ([]*models.Link, error) {
+	if m.GetErr != nil {
+		return nil, m.GetErr
+	}
+	var links []*models.Link
+	for _, code := range shortCodes {
+		if link, ok := m.Links[linkKey(&domainID, code)]; ok {
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+func (m *MockLinkRepository) GetByShortCodeForUpdate(tx *gorm.DB, domainID uint, shortCode string) (*models.Link, error) {
+	return m.GetByShortCode(domainID, shortCode)
 }
 
 func (m *MockLinkRepository) GetByUserID(userID uint, page, pageSize int) ([]*models.Link, int64, error) {
@@ -137,6 +262,11 @@ func (m *MockLinkRepository) IncrementClickCountWithTx(tx *gorm.DB, id uint) err
 	return m.IncrementClickCount(id)
 }
 
+func (m *MockLinkRepository) Update(link *models.Link) error {
+	m.Links[linkKey(link.DomainID, link.ShortCode)] = link
+	return nil
+}
+
 func (m *MockLinkRepository) Delete(id uint) error {
 	if m.DeleteErr != nil {
 		return m.DeleteErr
@@ -150,19 +280,192 @@ func (m *MockLinkRepository) Delete(id uint) error {
 	return nil
 }
 
-// MockClickRepository is a mock implementation of ClickRepository
+func (m *MockLinkRepository) NextCodeSequence() (uint64, error) {
+	if m.SequenceErr != nil {
+		return 0, m.SequenceErr
+	}
+	m.Sequence++
+	return m.Sequence, nil
+}
+
+func (m *MockLinkRepository) AdoptGuestLinks(guestID string, userID uint) (int64, error) {
+	return m.AdoptGuestLinksWithTx(nil, guestID, userID)
+}
+
+func (m *MockLinkRepository) AdoptGuestLinksWithTx(tx *gorm.DB, guestID string, userID uint) (int64, error) {
+	var adopted int64
+	for _, link := range m.Links {
+		if link.GuestID != nil && *link.GuestID == guestID && link.UserID == nil {
+			owner := userID
+			link.UserID = &owner
+			adopted++
+		}
+	}
+	return adopted, nil
+}
+
+func (m *MockLinkRepository) GetAbandonedGuests(createdBefore, activeSince time.Time, limit int) ([]repository.AbandonedGuest, error) {
+	byGuest := make(map[string]*repository.AbandonedGuest)
+	kept := make(map[string]bool)
+	for _, link := range m.Links {
+		if link.UserID != nil || link.GuestID == nil {
+			continue
+		}
+		guestID := *link.GuestID
+		if m.ActiveGuests[guestID] || !link.CreatedAt.Before(createdBefore) {
+			kept[guestID] = true
+			continue
+		}
+		if byGuest[guestID] == nil {
+			byGuest[guestID] = &repository.AbandonedGuest{GuestID: guestID}
+		}
+		if link.IsExpired() {
+			byGuest[guestID].ExpiredLinks++
+		}
+	}
+
+	var guests []repository.AbandonedGuest
+	for guestID, guest := range byGuest {
+		if !kept[guestID] {
+			guests = append(guests, *guest)
+		}
+	}
+	sort.Slice(guests, func(i, j int) bool { return guests[i].GuestID < guests[j].GuestID })
+	if len(guests) > limit {
+		guests = guests[:limit]
+	}
+	return guests, nil
+}
+
+func (m *MockLinkRepository) ArchiveGuestLinksWithTx(tx *gorm.DB, guestID string) (int64, error) {
+	var archived int64
+	for key, link := range m.Links {
+		if link.UserID != nil || link.GuestID == nil || *link.GuestID != guestID {
+			continue
+		}
+		if link.IsExpired() {
+			delete(m.Links, key)
+			archived++
+		}
+		link.GuestID = nil
+	}
+	return archived, nil
+}
+
+func (m *MockLinkRepository) TransferOwnershipWithTx(tx *gorm.DB, linkIDs []uint, fromUserID, toUserID uint) (int64, error) {
+	var moved int64
+	for _, link := range m.Links {
+		for _, id := range linkIDs {
+			if link.ID != id || link.UserID == nil || *link.UserID != fromUserID {
+				continue
+			}
+			if link.DomainID != nil && (link.Domain == nil || link.Domain.UserID != toUserID || !link.Domain.IsVerified()) {
+				continue
+			}
+			owner := toUserID
+			link.UserID = &owner
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+// MockDomainRepository is a mock implementation of DomainRepository
+type MockDomainRepository struct {
+	Domains    map[uint]*models.Domain
+	LinkCounts map[uint]int64
+	NextID     uint
+}
+
+func NewMockDomainRepository() *MockDomainRepository {
+	return &MockDomainRepository{
+		Domains:    make(map[uint]*models.Domain),
+		LinkCounts: make(map[uint]int64),
+		NextID:     1,
+	}
+}
+
+func (m *MockDomainRepository) Create(domain *models.Domain) error {
+	for _, existing := range m.Domains {
+		if existing.Host == domain.Host && existing.UserID == domain.UserID {
+			return repository.ErrDuplicateKey
+		}
+	}
+	domain.ID = m.NextID
+	m.NextID++
+	m.Domains[domain.ID] = domain
+	return nil
+}
+
+func (m *MockDomainRepository) GetByID(id uint) (*models.Domain, error) {
+	if domain, ok := m.Domains[id]; ok {
+		return domain, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockDomainRepository) GetVerifiedByHost(host string) (*models.Domain, error) {
+	for _, domain := range m.Domains {
+		if domain.Host == host && domain.IsVerified() {
+			return domain, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockDomainRepository) GetByUserID(userID uint) ([]*models.Domain, error) {
+	var domains []*models.Domain
+	for _, domain := range m.Domains {
+		if domain.UserID == userID {
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
+
+func (m *MockDomainRepository) MarkVerified(domain *models.Domain) error {
+	for id, existing := range m.Domains {
+		if existing.Host != domain.Host || id == domain.ID {
+			continue
+		}
+		if existing.IsVerified() {
+			return repository.ErrDuplicateKey
+		}
+		delete(m.Domains, id)
+	}
+	m.Domains[domain.ID] = domain
+	return nil
+}
+
+func (m *MockDomainRepository) Delete(id uint) error {
+	delete(m.Domains, id)
+	return nil
+}
+
+func (m *MockDomainRepository) CountLinks(id uint) (int64, error) {
+	return m.LinkCounts[id], nil
+}
+
+// MockClickRepository is a mock implementation of ClickRepository.
+// Clicks are tracked asynchronously, so tests read them through Recorded.
 type MockClickRepository struct {
 	Clicks    []*models.Click
 	CreateErr error
+	// LinkOwners maps link IDs to their owner for StreamByUserID
+	LinkOwners map[uint]uint
+	mu         sync.Mutex
 }
 
 func NewMockClickRepository() *MockClickRepository {
 	return &MockClickRepository{
-		Clicks: make([]*models.Click, 0),
+		Clicks:     make([]*models.Click, 0),
+		LinkOwners: make(map[uint]uint),
 	}
 }
 
 func (m *MockClickRepository) Create(click *models.Click) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.CreateErr != nil {
 		return m.CreateErr
 	}
@@ -171,22 +474,379 @@ func (m *MockClickRepository) Create(click *models.Click) error {
 	return nil
 }
 
+// Recorded returns a copy of the clicks created so far
+func (m *MockClickRepository) Recorded() []*models.Click {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.Click(nil), m.Clicks...)
+}
+
 func (m *MockClickRepository) CreateWithTx(tx *gorm.DB, click *models.Click) error {
 	return m.Create(click)
 }
 
-func (m *MockClickRepository) GetByLinkID(linkID uint, page, pageSize int) ([]*models.Click, int64, error) {
+func (m *MockClickRepository) GetByLinkID(linkID uint, filter repository.ClickFilter, page, pageSize int) ([]*models.Click, int64, error) {
+	var clicks []*models.Click
+	for _, click := range m.Recorded() {
+		if matchClick(click, linkID, filter) {
+			clicks = append(clicks, click)
+		}
+	}
+	sort.Slice(clicks, func(i, j int) bool {
+		if !clicks[i].ClickedAt.Equal(clicks[j].ClickedAt) {
+			return clicks[i].ClickedAt.After(clicks[j].ClickedAt)
+		}
+		return clicks[i].ID > clicks[j].ID
+	})
+	total := len(clicks)
+	start := min((page-1)*pageSize, total)
+	return clicks[start:min(start+pageSize, total)], int64(total), nil
+}
+
+func (m *MockClickRepository) StreamByLinkID(linkID uint, filter repository.ClickFilter, fn func(*models.Click) error) error {
+	return m.stream(func(click *models.Click) bool { return matchClick(click, linkID, filter) }, fn)
+}
+
+func (m *MockClickRepository) StreamByUserID(userID uint, filter repository.ClickFilter, fn func(*models.Click) error) error {
+	return m.stream(m.ownedBy(userID, filter), fn)
+}
+
+// stream calls fn with the matching clicks, oldest first
+func (m *MockClickRepository) stream(match func(*models.Click) bool, fn func(*models.Click) error) error {
 	var clicks []*models.Click
-	for _, click := range m.Clicks {
-		if click.LinkID == linkID {
+	for _, click := range m.Recorded() {
+		if match(click) {
 			clicks = append(clicks, click)
 		}
 	}
-	return clicks, int64(len(clicks)), nil
+	sort.SliceStable(clicks, func(i, j int) bool { return clicks[i].ClickedAt.Before(clicks[j].ClickedAt) })
+	for _, click := range clicks {
+		if err := fn(click); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchClick reports whether a click of linkID is selected by filter
+func matchClick(click *models.Click, linkID uint, filter repository.ClickFilter) bool {
+	switch {
+	case click.LinkID != linkID:
+		return false
+	case !filter.From.IsZero() && click.ClickedAt.Before(filter.From):
+		return false
+	case !filter.To.IsZero() && !click.ClickedAt.Before(filter.To):
+		return false
+	case filter.Bots == repository.ExcludeBots && click.IsBot:
+		return false
+	case filter.Bots == repository.OnlyBots && !click.IsBot:
+		return false
+	case filter.Country != "" && click.Country != filter.Country:
+		return false
+	case filter.Browser != "" && click.Browser != filter.Browser:
+		return false
+	case filter.Device != "" && click.Device != filter.Device:
+		return false
+	case filter.RefererSource != "" && click.RefererSource != filter.RefererSource:
+		return false
+	}
+	return true
+}
+
+func (m *MockClickRepository) GetAnalytics(linkID uint, filter repository.ClickFilter) (*dto.AnalyticsSummary, error) {
+	return m.summarize(func(click *models.Click) bool { return matchClick(click, linkID, filter) }), nil
+}
+
+func (m *MockClickRepository) GetUserAnalytics(userID uint, filter repository.ClickFilter) (*dto.AnalyticsSummary, error) {
+	return m.summarize(m.ownedBy(userID, filter)), nil
+}
+
+// summarize counts the matching clicks per country, device and referer source
+func (m *MockClickRepository) summarize(match func(*models.Click) bool) *dto.AnalyticsSummary {
+	summary := &dto.AnalyticsSummary{}
+	count := func(counts *map[string]int64, value string) {
+		if value == "" {
+			return
+		}
+		if *counts == nil {
+			*counts = make(map[string]int64)
+		}
+		(*counts)[value]++
+	}
+	for _, click := range m.Recorded() {
+		if !match(click) {
+			continue
+		}
+		summary.TotalClicks++
+		count(&summary.Countries, click.Country)
+		count(&summary.Devices, click.Device)
+		count(&summary.RefererSources, click.RefererSource)
+	}
+	return summary
+}
+
+// ownedBy matches the clicks selected by filter on links LinkOwners gives to userID
+func (m *MockClickRepository) ownedBy(userID uint, filter repository.ClickFilter) func(*models.Click) bool {
+	return func(click *models.Click) bool {
+		owner, ok := m.LinkOwners[click.LinkID]
+		return ok && owner == userID && matchClick(click, click.LinkID, filter)
+	}
+}
+
+func (m *MockClickRepository) GetTopLinks(userID uint, filter repository.ClickFilter, limit int) ([]repository.LinkClicks, error) {
+	counts := make(map[uint]int64)
+	for _, click := range m.Recorded() {
+		if m.ownedBy(userID, filter)(click) {
+			counts[click.LinkID]++
+		}
+	}
+	links := make([]repository.LinkClicks, 0, len(counts))
+	for id, n := range counts {
+		links = append(links, repository.LinkClicks{LinkID: id, Clicks: n})
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Clicks != links[j].Clicks {
+			return links[i].Clicks > links[j].Clicks
+		}
+		return links[i].LinkID < links[j].LinkID
+	})
+	return links[:min(limit, len(links))], nil
+}
+
+func (m *MockClickRepository) GetTimeSeries(linkID uint, interval string, filter repository.ClickFilter, tz string) ([]dto.TimeSeriesPoint, error) {
+	return m.timeSeries(func(click *models.Click) bool { return matchClick(click, linkID, filter) }, interval, tz)
+}
+
+func (m *MockClickRepository) GetUserTimeSeries(userID uint, interval string, filter repository.ClickFilter, tz string) ([]dto.TimeSeriesPoint, error) {
+	return m.timeSeries(m.ownedBy(userID, filter), interval, tz)
+}
+
+func (m *MockClickRepository) timeSeries(match func(*models.Click) bool, interval, tz string) ([]dto.TimeSeriesPoint, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[time.Time]int64)
+	visitors := make(map[time.Time]map[string]bool)
+	for _, click := range m.Recorded() {
+		if !match(click) {
+			continue
+		}
+		t := click.ClickedAt.In(loc)
+		y, mo, d := t.Date()
+		switch interval {
+		case "hour":
+			t = time.Date(y, mo, d, t.Hour(), 0, 0, 0, loc)
+		case "week":
+			t = time.Date(y, mo, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, loc)
+		case "month":
+			t = time.Date(y, mo, 1, 0, 0, 0, 0, loc)
+		default:
+			t = time.Date(y, mo, d, 0, 0, 0, 0, loc)
+		}
+		counts[t.UTC()]++
+		if click.VisitorHash != "" {
+			if visitors[t.UTC()] == nil {
+				visitors[t.UTC()] = make(map[string]bool)
+			}
+			visitors[t.UTC()][click.VisitorHash] = true
+		}
+	}
+
+	points := make([]dto.TimeSeriesPoint, 0, len(counts))
+	for t, n := range counts {
+		points = append(points, dto.TimeSeriesPoint{Time: t, Clicks: n, Visitors: int64(len(visitors[t]))})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+	return points, nil
+}
+
+// GetUniqueVisitors counts exactly, or like the Postgres implementation with HyperLogLog if approximate
+func (m *MockClickRepository) GetUniqueVisitors(linkID uint, filter repository.ClickFilter, approximate bool) (*dto.UniqueVisitors, error) {
+	total := utils.NewHyperLogLog()
+	exact := make(map[string]bool)
+	countries := make(map[string]map[string]bool)
+	for _, click := range m.Recorded() {
+		if click.VisitorHash == "" || !matchClick(click, linkID, filter) {
+			continue
+		}
+		hash, err := strconv.ParseUint(click.VisitorHash[:16], 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		total.Add(hash)
+		exact[click.VisitorHash] = true
+		if countries[click.Country] == nil {
+			countries[click.Country] = make(map[string]bool)
+		}
+		countries[click.Country][click.VisitorHash] = true
+	}
+
+	visitors := &dto.UniqueVisitors{Total: int64(len(exact)), Approximate: approximate}
+	if approximate {
+		visitors.Total = total.Estimate()
+	}
+	if len(countries) > 0 {
+		visitors.Countries = make(map[string]int64)
+		for country, hashes := range countries {
+			visitors.Countries[country] = int64(len(hashes))
+		}
+	}
+	return visitors, nil
+}
+
+// MockClickRollupRepository is a mock implementation of ClickRollupRepository.
+// Its rollups are always up to date with the clicks of the click repository.
+type MockClickRollupRepository struct {
+	clicks      *MockClickRepository
+	incremented []*models.Click
+	// Rebuilt holds the days passed to RebuildDay
+	Rebuilt []time.Time
+	mu      sync.Mutex
+}
+
+func NewMockClickRollupRepository(clicks *MockClickRepository) *MockClickRollupRepository {
+	return &MockClickRollupRepository{clicks: clicks}
+}
+
+func (m *MockClickRollupRepository) IncrementWithTx(tx *gorm.DB, click *models.Click) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.incremented = append(m.incremented, click)
+	return nil
+}
+
+// Incremented returns a copy of the clicks passed to IncrementWithTx so far
+func (m *MockClickRollupRepository) Incremented() []*models.Click {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*models.Click(nil), m.incremented...)
+}
+
+func (m *MockClickRollupRepository) GetAnalytics(linkID uint, filter repository.ClickFilter) (*dto.AnalyticsSummary, error) {
+	if err := checkRollupDays(filter); err != nil {
+		return nil, err
+	}
+	return m.clicks.GetAnalytics(linkID, filter)
+}
+
+func (m *MockClickRollupRepository) GetUserAnalytics(userID uint, filter repository.ClickFilter) (*dto.AnalyticsSummary, error) {
+	if err := checkRollupDays(filter); err != nil {
+		return nil, err
+	}
+	return m.clicks.GetUserAnalytics(userID, filter)
+}
+
+// checkRollupDays rejects ranges that daily rollups can't answer
+func checkRollupDays(filter repository.ClickFilter) error {
+	for _, t := range []time.Time{filter.From, filter.To} {
+		if !t.IsZero() && !t.Equal(t.UTC().Truncate(24*time.Hour)) {
+			return fmt.Errorf("rollups hold whole UTC days, got %v", t)
+		}
+	}
+	return nil
+}
+
+func (m *MockClickRollupRepository) RebuildDay(day time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Rebuilt = append(m.Rebuilt, day)
+	return nil
+}
+
+func (m *MockClickRollupRepository) FirstClickDay() (time.Time, error) {
+	var first time.Time
+	for _, click := range m.clicks.Recorded() {
+		if first.IsZero() || click.ClickedAt.Before(first) {
+			first = click.ClickedAt
+		}
+	}
+	if first.IsZero() {
+		return first, nil
+	}
+	return first.UTC().Truncate(24 * time.Hour), nil
+}
+
+// MockVisitorSaltRepository is a mock implementation of VisitorSaltRepository
+type MockVisitorSaltRepository struct {
+	Salts map[time.Time][]byte
+	mu    sync.Mutex
+}
+
+func NewMockVisitorSaltRepository() *MockVisitorSaltRepository {
+	return &MockVisitorSaltRepository{
+		Salts: make(map[time.Time][]byte),
+	}
+}
+
+func (m *MockVisitorSaltRepository) GetOrCreate(day time.Time, salt []byte) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stored, ok := m.Salts[day]; ok {
+		return stored, nil
+	}
+	m.Salts[day] = salt
+	return salt, nil
+}
+
+func (m *MockVisitorSaltRepository) DeleteBefore(day time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for d := range m.Salts {
+		if d.Before(day) {
+			delete(m.Salts, d)
+		}
+	}
+	return nil
 }
 
-func (m *MockClickRepository) GetAnalytics(linkID uint) (*dto.AnalyticsSummary, error) {
-	return &dto.AnalyticsSummary{}, nil
+// MockLinkTransferRepository is a mock implementation of LinkTransferRepository.
+// It stores copies so status changes only take effect through UpdateStatusWithTx.
+type MockLinkTransferRepository struct {
+	Transfers map[uint]models.LinkTransfer
+	NextID    uint
+}
+
+func NewMockLinkTransferRepository() *MockLinkTransferRepository {
+	return &MockLinkTransferRepository{
+		Transfers: make(map[uint]models.LinkTransfer),
+		NextID:    1,
+	}
+}
+
+func (m *MockLinkTransferRepository) Create(transfer *models.LinkTransfer) error {
+	transfer.ID = m.NextID
+	m.NextID++
+	m.Transfers[transfer.ID] = *transfer
+	return nil
+}
+
+func (m *MockLinkTransferRepository) GetByID(id uint) (*models.LinkTransfer, error) {
+	if transfer, ok := m.Transfers[id]; ok {
+		return &transfer, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockLinkTransferRepository) GetPendingByUserID(userID uint) ([]*models.LinkTransfer, error) {
+	var transfers []*models.LinkTransfer
+	for _, transfer := range m.Transfers {
+		if transfer.IsPending() && (transfer.FromUserID == userID || transfer.ToUserID == userID) {
+			transfers = append(transfers, &transfer)
+		}
+	}
+	return transfers, nil
+}
+
+func (m *MockLinkTransferRepository) UpdateStatusWithTx(tx *gorm.DB, transfer *models.LinkTransfer) (bool, error) {
+	stored, ok := m.Transfers[transfer.ID]
+	if !ok || !stored.IsPending() {
+		return false, nil
+	}
+	stored.Status = transfer.Status
+	stored.RespondedAt = transfer.RespondedAt
+	m.Transfers[transfer.ID] = stored
+	return true, nil
 }
 
 // MockTransactionManager is a mock implementation of TransactionManager
@@ -204,3 +864,82 @@ func (m *MockTransactionManager) ExecuteInTransaction(fn func(tx *gorm.DB) error
 	}
 	return fn(nil)
 }
+
+// MockQRStyleRepository is a mock implementation of QRStyleRepository
+type MockQRStyleRepository struct {
+	Styles map[uint]*models.QRStyle
+	Logos  map[uint]*models.QRLogo
+	NextID uint
+}
+
+func NewMockQRStyleRepository() *MockQRStyleRepository {
+	return &MockQRStyleRepository{
+		Styles: make(map[uint]*models.QRStyle),
+		Logos:  make(map[uint]*models.QRLogo),
+		NextID: 1,
+	}
+}
+
+func (m *MockQRStyleRepository) Create(style *models.QRStyle) error {
+	style.ID = m.NextID
+	m.NextID++
+	return m.Update(style)
+}
+
+func (m *MockQRStyleRepository) Update(style *models.QRStyle) error {
+	if style.IsDefault {
+		for _, existing := range m.Styles {
+			if existing.UserID == style.UserID && existing.ID != style.ID {
+				existing.IsDefault = false
+			}
+		}
+	}
+	m.Styles[style.ID] = style
+	return nil
+}
+
+func (m *MockQRStyleRepository) GetByID(id uint) (*models.QRStyle, error) {
+	if style, ok := m.Styles[id]; ok {
+		return style, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *MockQRStyleRepository) GetByUserID(userID uint) ([]*models.QRStyle, error) {
+	var styles []*models.QRStyle
+	for _, style := range m.Styles {
+		if style.UserID == userID {
+			styles = append(styles, style)
+		}
+	}
+	return styles, nil
+}
+
+func (m *MockQRStyleRepository) GetDefault(userID uint) (*models.QRStyle, error) {
+	for _, style := range m.Styles {
+		if style.UserID == userID && style.IsDefault {
+			return style, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockQRStyleRepository) Delete(id uint) error {
+	delete(m.Styles, id)
+	return nil
+}
+
+func (m *MockQRStyleRepository) GetLogo(userID uint) (*models.QRLogo, error) {
+	return m.Logos[userID], nil
+}
+
+func (m *MockQRStyleRepository) SaveLogo(logo *models.QRLogo) error {
+	logo.UpdatedAt = time.Now()
+	m.Logos[logo.UserID] = logo
+	return nil
+}
+
+func (m *MockQRStyleRepository) DeleteLogo(userID uint) error {
+	delete(m.Logos, userID)
+	return nil
+}
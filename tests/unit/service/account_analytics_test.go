@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+)
+
+func TestAnalyticsService_GetAccountAnalytics(t *testing.T) {
+	svc, clickRepo, linkRepo := setupAnalyticsService()
+	owner, other := uint(1), uint(2)
+	linkRepo.Links["one"] = &models.Link{ID: 1, ShortCode: "one", UserID: &owner}
+	linkRepo.Links["two"] = &models.Link{ID: 2, ShortCode: "two", UserID: &owner}
+	linkRepo.Links["else"] = &models.Link{ID: 3, ShortCode: "else", UserID: &other}
+	clickRepo.LinkOwners = map[uint]uint{1: owner, 2: owner, 3: other}
+
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, click := range []*models.Click{
+		{LinkID: 1, Country: "Vietnam", Device: "Mobile", RefererSource: "Facebook", ClickedAt: day},
+		{LinkID: 2, Country: "Vietnam", Device: "Desktop", RefererSource: "Direct", ClickedAt: day},
+		{LinkID: 2, Country: "Japan", Device: "Mobile", RefererSource: "Google", ClickedAt: day.AddDate(0, 0, 2)},
+		{LinkID: 2, Country: "Japan", Device: "Desktop", RefererSource: "Direct", ClickedAt: day.AddDate(0, 0, 2)},
+		{LinkID: 1, Device: "Bot", IsBot: true, ClickedAt: day},
+		{LinkID: 3, Country: "Germany", ClickedAt: day},
+		{LinkID: 1, Country: "Vietnam", ClickedAt: day.AddDate(0, 1, 0)},
+	} {
+		if err := clickRepo.Create(click); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+	}
+
+	r, err := service.ParseTimeRange("2025-03-10", "2025-03-12", "UTC")
+	if err != nil {
+		t.Fatalf("ParseTimeRange returned error: %v", err)
+	}
+	overview, err := svc.GetAccountAnalytics(owner, service.IntervalDay, r, 10)
+	if err != nil {
+		t.Fatalf("GetAccountAnalytics returned error: %v", err)
+	}
+
+	if overview.Summary.TotalClicks != 4 {
+		t.Errorf("TotalClicks = %d, want 4", overview.Summary.TotalClicks)
+	}
+	if got := overview.Summary.Countries; got["Vietnam"] != 2 || got["Japan"] != 2 || got["Germany"] != 0 {
+		t.Errorf("Countries = %v", got)
+	}
+	if got := overview.Summary.Devices; got["Desktop"] != 2 || got["Mobile"] != 2 || got["Bot"] != 0 {
+		t.Errorf("Devices = %v", got)
+	}
+	if got := overview.Summary.RefererSources; got["Direct"] != 2 || got["Facebook"] != 1 || got["Google"] != 1 {
+		t.Errorf("RefererSources = %v", got)
+	}
+
+	var clicks []int64
+	for _, point := range overview.Series.Points {
+		clicks = append(clicks, point.Clicks)
+	}
+	if len(clicks) != 3 || clicks[0] != 2 || clicks[1] != 0 || clicks[2] != 2 {
+		t.Errorf("series clicks = %v, want [2 0 2]", clicks)
+	}
+
+	if len(overview.TopLinks) != 2 {
+		t.Fatalf("got %d top links, want 2", len(overview.TopLinks))
+	}
+	if top := overview.TopLinks[0]; top.Link.ShortCode != "two" || top.Clicks != 3 {
+		t.Errorf("top link = %s with %d clicks, want two with 3", top.Link.ShortCode, top.Clicks)
+	}
+	if top := overview.TopLinks[1]; top.Link.ShortCode != "one" || top.Clicks != 1 {
+		t.Errorf("second link = %s with %d clicks, want one with 1", top.Link.ShortCode, top.Clicks)
+	}
+
+	overview, err = svc.GetAccountAnalytics(owner, service.IntervalDay, r, 1)
+	if err != nil {
+		t.Fatalf("GetAccountAnalytics returned error: %v", err)
+	}
+	if len(overview.TopLinks) != 1 || overview.TopLinks[0].Link.ShortCode != "two" {
+		t.Errorf("top 1 = %v, want link two", overview.TopLinks)
+	}
+}
+
+func TestAnalyticsService_GetAccountAnalytics_Errors(t *testing.T) {
+	svc, _, _ := setupAnalyticsService()
+
+	if _, err := svc.GetAccountAnalytics(1, "minute", service.TimeRange{}, 10); err != service.ErrInvalidInterval {
+		t.Errorf("error = %v, want ErrInvalidInterval", err)
+	}
+	r, err := service.ParseTimeRange("2000-01-01", "2025-01-01", "UTC")
+	if err != nil {
+		t.Fatalf("ParseTimeRange returned error: %v", err)
+	}
+	if _, err := svc.GetAccountAnalytics(1, service.IntervalHour, r, 10); err != service.ErrInvalidTimeRange {
+		t.Errorf("error = %v, want ErrInvalidTimeRange", err)
+	}
+}
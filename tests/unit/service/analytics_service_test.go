@@ -1,7 +1,9 @@
 package service_test
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"quocbui.dev/m/internal/models"
 	"quocbui.dev/m/internal/service"
@@ -11,7 +13,7 @@ import (
 func setupAnalyticsService() (*service.AnalyticsService, *mocks.MockClickRepository, *mocks.MockLinkRepository) {
 	clickRepo := mocks.NewMockClickRepository()
 	linkRepo := mocks.NewMockLinkRepository()
-	svc := service.NewAnalyticsService(clickRepo, linkRepo)
+	svc := service.NewAnalyticsService(clickRepo, mocks.NewMockClickRollupRepository(clickRepo), linkRepo)
 	return svc, clickRepo, linkRepo
 }
 
@@ -38,7 +40,7 @@ func TestAnalyticsService_GetClicksByLinkID_Success(t *testing.T) {
 		LinkID: linkID,
 	})
 
-	clicks, total, err := svc.GetClicksByLinkID(linkID, userID, 1, 10)
+	clicks, total, err := svc.GetClicksByLinkID(linkID, userID, service.ClickLogFilter{}, 1, 10)
 	if err != nil {
 		t.Fatalf("GetClicksByLinkID returned error: %v", err)
 	}
@@ -55,7 +57,7 @@ func TestAnalyticsService_GetClicksByLinkID_Success(t *testing.T) {
 func TestAnalyticsService_GetClicksByLinkID_LinkNotFound(t *testing.T) {
 	svc, _, _ := setupAnalyticsService()
 
-	_, _, err := svc.GetClicksByLinkID(999, 1, 1, 10)
+	_, _, err := svc.GetClicksByLinkID(999, 1, service.ClickLogFilter{}, 1, 10)
 	if err == nil {
 		t.Error("Expected error for non-existent link")
 	}
@@ -78,7 +80,7 @@ func TestAnalyticsService_GetClicksByLinkID_Unauthorized(t *testing.T) {
 		UserID:    &ownerID,
 	}
 
-	_, _, err := svc.GetClicksByLinkID(linkID, otherUserID, 1, 10)
+	_, _, err := svc.GetClicksByLinkID(linkID, otherUserID, service.ClickLogFilter{}, 1, 10)
 	if err == nil {
 		t.Error("Expected error for unauthorized access")
 	}
@@ -88,6 +90,60 @@ func TestAnalyticsService_GetClicksByLinkID_Unauthorized(t *testing.T) {
 	}
 }
 
+func TestAnalyticsService_GetClicksByLinkID_Filters(t *testing.T) {
+	svc, clickRepo, linkRepo := setupAnalyticsService()
+
+	userID := uint(1)
+	linkRepo.Links["test"] = &models.Link{ID: 1, ShortCode: "test", UserID: &userID}
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	clicks := []*models.Click{
+		{ID: 1, LinkID: 1, Country: "Vietnam", Browser: "Chrome", ClickedAt: day},
+		{ID: 2, LinkID: 1, Country: "Vietnam", Browser: "Firefox", ClickedAt: day.Add(time.Hour)},
+		{ID: 3, LinkID: 1, Country: "Japan", Browser: "Chrome", ClickedAt: day.Add(2 * time.Hour)},
+		{ID: 4, LinkID: 1, Country: "Vietnam", Browser: "Chrome", ClickedAt: day.Add(3 * time.Hour)},
+		{ID: 5, LinkID: 1, Country: "Vietnam", Device: "Bot", IsBot: true, ClickedAt: day.Add(4 * time.Hour)},
+		{ID: 6, LinkID: 1, Country: "Vietnam", Browser: "Chrome", ClickedAt: day.AddDate(0, 0, 1)},
+	}
+	clickRepo.Clicks = append(clickRepo.Clicks, clicks...)
+	r, err := service.ParseTimeRange("2025-03-10", "2025-03-10", "UTC")
+	if err != nil {
+		t.Fatalf("ParseTimeRange returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		filter   service.ClickLogFilter
+		page     int
+		pageSize int
+		wantIDs  []uint
+		total    int64
+	}{
+		{"range", service.ClickLogFilter{Range: r}, 1, 10, []uint{4, 3, 2, 1}, 4},
+		{"country and browser", service.ClickLogFilter{Range: r, Country: "Vietnam", Browser: "Chrome"}, 1, 10, []uint{4, 1}, 2},
+		{"include bots", service.ClickLogFilter{Range: r, Device: "Bot", IncludeBots: true}, 1, 10, []uint{5}, 1},
+		{"bots excluded", service.ClickLogFilter{Range: r, Device: "Bot"}, 1, 10, nil, 0},
+		{"second page", service.ClickLogFilter{Country: "Vietnam"}, 2, 2, []uint{2, 1}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := svc.GetClicksByLinkID(1, userID, tt.filter, tt.page, tt.pageSize)
+			if err != nil {
+				t.Fatalf("GetClicksByLinkID returned error: %v", err)
+			}
+			if total != tt.total {
+				t.Errorf("total = %d, want %d", total, tt.total)
+			}
+			var ids []uint
+			for _, click := range got {
+				ids = append(ids, click.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("clicks = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
 func TestAnalyticsService_GetAnalyticsSummary_Success(t *testing.T) {
 	svc, _, linkRepo := setupAnalyticsService()
 
@@ -100,7 +156,7 @@ func TestAnalyticsService_GetAnalyticsSummary_Success(t *testing.T) {
 		UserID:    &userID,
 	}
 
-	summary, err := svc.GetAnalyticsSummary(linkID, userID)
+	summary, err := svc.GetAnalyticsSummary(linkID, userID, service.TimeRange{}, false)
 	if err != nil {
 		t.Fatalf("GetAnalyticsSummary returned error: %v", err)
 	}
@@ -113,7 +169,7 @@ func TestAnalyticsService_GetAnalyticsSummary_Success(t *testing.T) {
 func TestAnalyticsService_GetAnalyticsSummary_LinkNotFound(t *testing.T) {
 	svc, _, _ := setupAnalyticsService()
 
-	_, err := svc.GetAnalyticsSummary(999, 1)
+	_, err := svc.GetAnalyticsSummary(999, 1, service.TimeRange{}, false)
 	if err == nil {
 		t.Error("Expected error for non-existent link")
 	}
@@ -136,7 +192,7 @@ func TestAnalyticsService_GetAnalyticsSummary_Unauthorized(t *testing.T) {
 		UserID:    &ownerID,
 	}
 
-	_, err := svc.GetAnalyticsSummary(linkID, otherUserID)
+	_, err := svc.GetAnalyticsSummary(linkID, otherUserID, service.TimeRange{}, false)
 	if err == nil {
 		t.Error("Expected error for unauthorized access")
 	}
@@ -145,3 +201,184 @@ func TestAnalyticsService_GetAnalyticsSummary_Unauthorized(t *testing.T) {
 		t.Errorf("Expected ErrUnauthorized, got %v", err)
 	}
 }
+
+func TestAnalyticsService_GetAnalyticsSummary_TimeRange(t *testing.T) {
+	svc, clickRepo, linkRepo := setupAnalyticsService()
+
+	userID := uint(1)
+	linkRepo.Links["test"] = &models.Link{ID: 1, ShortCode: "test", UserID: &userID}
+	for i, day := range []int{1, 3, 5, 7} {
+		clickRepo.Clicks = append(clickRepo.Clicks, &models.Click{
+			ID:        uint(i + 1),
+			LinkID:    1,
+			Country:   "Vietnam",
+			ClickedAt: time.Date(2025, 3, day, 12, 0, 0, 0, time.UTC),
+		})
+	}
+
+	r, err := service.ParseTimeRange("2025-03-03", "2025-03-05", "")
+	if err != nil {
+		t.Fatalf("ParseTimeRange returned error: %v", err)
+	}
+	summary, err := svc.GetAnalyticsSummary(1, userID, r, false)
+	if err != nil {
+		t.Fatalf("GetAnalyticsSummary returned error: %v", err)
+	}
+	if summary.TotalClicks != 2 || summary.Countries["Vietnam"] != 2 {
+		t.Errorf("TotalClicks = %d, Countries = %v, want 2 clicks in range", summary.TotalClicks, summary.Countries)
+	}
+	if summary.From == nil || !summary.From.Equal(r.From) || summary.To == nil || !summary.To.Equal(r.To) {
+		t.Errorf("summary range = %v to %v, want %v to %v", summary.From, summary.To, r.From, r.To)
+	}
+
+	summary, err = svc.GetAnalyticsSummary(1, userID, service.TimeRange{}, false)
+	if err != nil {
+		t.Fatalf("GetAnalyticsSummary returned error: %v", err)
+	}
+	if summary.TotalClicks != 4 || summary.From != nil || summary.To != nil {
+		t.Errorf("TotalClicks = %d from %v to %v, want 4 over the lifetime", summary.TotalClicks, summary.From, summary.To)
+	}
+}
+
+func TestParseTimeRange(t *testing.T) {
+	r, err := service.ParseTimeRange("2025-03-01", "2025-03-07", "Asia/Ho_Chi_Minh")
+	if err != nil {
+		t.Fatalf("ParseTimeRange returned error: %v", err)
+	}
+	if want := time.Date(2025, 2, 28, 17, 0, 0, 0, time.UTC); !r.From.Equal(want) {
+		t.Errorf("From = %v, want %v", r.From, want)
+	}
+	// to includes the whole day
+	if want := time.Date(2025, 3, 7, 17, 0, 0, 0, time.UTC); !r.To.Equal(want) {
+		t.Errorf("To = %v, want %v", r.To, want)
+	}
+
+	r, err = service.ParseTimeRange("2025-03-01T10:00:00Z", "", "")
+	if err != nil {
+		t.Fatalf("ParseTimeRange returned error: %v", err)
+	}
+	if r.Location != time.UTC || !r.To.IsZero() {
+		t.Errorf("got %v to %v in %v, want open end in UTC", r.From, r.To, r.Location)
+	}
+
+	tests := []struct {
+		name         string
+		from, to, tz string
+		want         error
+	}{
+		{"bad date", "03/01/2025", "", "", service.ErrInvalidTimeRange},
+		{"reversed", "2025-03-07", "2025-03-01", "", service.ErrInvalidTimeRange},
+		{"empty range", "2025-03-01T00:00:00Z", "2025-03-01T00:00:00Z", "", service.ErrInvalidTimeRange},
+		{"unknown zone", "", "", "Mars/Olympus", service.ErrInvalidTimezone},
+		{"server zone", "", "", "Local", service.ErrInvalidTimezone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.ParseTimeRange(tt.from, tt.to, tt.tz); err != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func setupTimeSeries(t *testing.T, clickTimes ...time.Time) *service.AnalyticsService {
+	t.Helper()
+	svc, clickRepo, linkRepo := setupAnalyticsService()
+
+	userID := uint(1)
+	linkRepo.Links["test"] = &models.Link{ID: 1, ShortCode: "test", UserID: &userID}
+	for i, clickedAt := range clickTimes {
+		clickRepo.Clicks = append(clickRepo.Clicks, &models.Click{ID: uint(i + 1), LinkID: 1, ClickedAt: clickedAt})
+	}
+	return svc
+}
+
+func TestAnalyticsService_GetTimeSeries_ZeroFilled(t *testing.T) {
+	svc := setupTimeSeries(t,
+		time.Date(2025, 3, 1, 1, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 1, 18, 0, 0, 0, time.UTC), // March 2 in Ho Chi Minh City
+		time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC), // outside the range
+	)
+
+	r, err := service.ParseTimeRange("2025-03-01", "2025-03-04", "Asia/Ho_Chi_Minh")
+	if err != nil {
+		t.Fatalf("ParseTimeRange returned error: %v", err)
+	}
+	series, err := svc.GetTimeSeries(1, 1, service.IntervalDay, r)
+	if err != nil {
+		t.Fatalf("GetTimeSeries returned error: %v", err)
+	}
+
+	if series.Total != 3 {
+		t.Errorf("Total = %d, want 3", series.Total)
+	}
+	want := []int64{1, 1, 1, 0}
+	if len(series.Points) != len(want) {
+		t.Fatalf("len(Points) = %d, want %d", len(series.Points), len(want))
+	}
+	for i, point := range series.Points {
+		if day := point.Time.Format("2006-01-02T15:04Z07:00"); day != fmt.Sprintf("2025-03-%02dT00:00+07:00", i+1) {
+			t.Errorf("Points[%d].Time = %s", i, day)
+		}
+		if point.Clicks != want[i] {
+			t.Errorf("Points[%d].Clicks = %d, want %d", i, point.Clicks, want[i])
+		}
+	}
+}
+
+func TestAnalyticsService_GetTimeSeries_Weeks(t *testing.T) {
+	// Wednesday and the following Sunday fall in the same week
+	svc := setupTimeSeries(t,
+		time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC),
+	)
+
+	r, _ := service.ParseTimeRange("2025-03-05", "2025-03-16", "")
+	series, err := svc.GetTimeSeries(1, 1, service.IntervalWeek, r)
+	if err != nil {
+		t.Fatalf("GetTimeSeries returned error: %v", err)
+	}
+	if len(series.Points) != 2 {
+		t.Fatalf("len(Points) = %d, want 2", len(series.Points))
+	}
+	if start := series.Points[0].Time; start.Weekday() != time.Monday || start.Day() != 3 {
+		t.Errorf("first week starts %s, want Monday March 3", start)
+	}
+	if series.Points[0].Clicks != 2 || series.Points[1].Clicks != 1 {
+		t.Errorf("clicks = %d, %d, want 2, 1", series.Points[0].Clicks, series.Points[1].Clicks)
+	}
+}
+
+func TestAnalyticsService_GetTimeSeries_Defaults(t *testing.T) {
+	svc := setupTimeSeries(t, time.Now().Add(-time.Hour))
+
+	series, err := svc.GetTimeSeries(1, 1, service.IntervalHour, service.TimeRange{})
+	if err != nil {
+		t.Fatalf("GetTimeSeries returned error: %v", err)
+	}
+	if n := len(series.Points); n < 48 || n > 49 {
+		t.Errorf("len(Points) = %d, want the last 48 hours", n)
+	}
+	if series.Total != 1 || series.Timezone != "UTC" {
+		t.Errorf("Total = %d in %s, want 1 in UTC", series.Total, series.Timezone)
+	}
+}
+
+func TestAnalyticsService_GetTimeSeries_Errors(t *testing.T) {
+	svc := setupTimeSeries(t)
+
+	if _, err := svc.GetTimeSeries(1, 1, "minute", service.TimeRange{}); err != service.ErrInvalidInterval {
+		t.Errorf("Expected ErrInvalidInterval, got %v", err)
+	}
+
+	r, _ := service.ParseTimeRange("2020-01-01", "2025-01-01", "")
+	if _, err := svc.GetTimeSeries(1, 1, service.IntervalHour, r); err != service.ErrInvalidTimeRange {
+		t.Errorf("Expected ErrInvalidTimeRange for too many buckets, got %v", err)
+	}
+
+	if _, err := svc.GetTimeSeries(1, 2, service.IntervalDay, service.TimeRange{}); err != service.ErrUnauthorized {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+}
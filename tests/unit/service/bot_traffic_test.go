@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"testing"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+)
+
+const slackbotUA = "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"
+
+func TestLinkService_Redirect_BotNotCounted(t *testing.T) {
+	svc, linkRepo, clickRepo := setupLinkService()
+
+	link := &models.Link{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com"}
+	linkRepo.Links["abc123"] = link
+	if _, err := svc.Redirect("abc123", &service.ClickInfo{IPAddress: "127.0.0.1", UserAgent: slackbotUA}); err != nil {
+		t.Fatalf("Redirect returned error: %v", err)
+	}
+
+	// The bot request is still recorded for the bot traffic report
+	clicks := waitForClicks(t, clickRepo, 1)
+	if !clicks[0].IsBot || clicks[0].Device != "Bot" {
+		t.Errorf("click IsBot = %v, Device = %s, want a bot", clicks[0].IsBot, clicks[0].Device)
+	}
+	if link.ClickCount != 0 {
+		t.Errorf("ClickCount = %d, want 0", link.ClickCount)
+	}
+}
+
+func TestAnalyticsService_BotTraffic(t *testing.T) {
+	svc, clickRepo, linkRepo := setupAnalyticsService()
+
+	userID := uint(1)
+	linkRepo.Links["test"] = &models.Link{ID: 1, ShortCode: "test", UserID: &userID}
+	clickRepo.Clicks = append(clickRepo.Clicks,
+		&models.Click{ID: 1, LinkID: 1, Country: "Vietnam"},
+		&models.Click{ID: 2, LinkID: 1, Country: "United States", IsBot: true},
+		&models.Click{ID: 3, LinkID: 1, Country: "United States", IsBot: true},
+	)
+
+	summary, err := svc.GetAnalyticsSummary(1, userID, service.TimeRange{}, false)
+	if err != nil {
+		t.Fatalf("GetAnalyticsSummary returned error: %v", err)
+	}
+	if summary.TotalClicks != 1 || summary.Countries["United States"] != 0 {
+		t.Errorf("summary counts %d clicks from %v, want bots left out", summary.TotalClicks, summary.Countries)
+	}
+
+	summary, err = svc.GetAnalyticsSummary(1, userID, service.TimeRange{}, true)
+	if err != nil {
+		t.Fatalf("GetAnalyticsSummary returned error: %v", err)
+	}
+	if summary.TotalClicks != 3 {
+		t.Errorf("TotalClicks with bots = %d, want 3", summary.TotalClicks)
+	}
+
+	report, err := svc.GetBotTraffic(1, userID, service.TimeRange{})
+	if err != nil {
+		t.Fatalf("GetBotTraffic returned error: %v", err)
+	}
+	if report.TotalClicks != 2 || report.Countries["United States"] != 2 {
+		t.Errorf("bot report counts %d clicks from %v, want 2 bots", report.TotalClicks, report.Countries)
+	}
+
+	if _, err := svc.GetBotTraffic(1, 2, service.TimeRange{}); err != service.ErrUnauthorized {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/tests/mocks"
+)
+
+func setupClickExport(t *testing.T) (*service.AnalyticsService, *mocks.MockClickRepository) {
+	t.Helper()
+	svc, clickRepo, linkRepo := setupAnalyticsService()
+	owner, other := uint(1), uint(2)
+	linkRepo.Links["one"] = &models.Link{ID: 1, ShortCode: "one", UserID: &owner}
+	linkRepo.Links["two"] = &models.Link{ID: 2, ShortCode: "two", UserID: &owner}
+	linkRepo.Links["else"] = &models.Link{ID: 3, ShortCode: "else", UserID: &other}
+	clickRepo.LinkOwners = map[uint]uint{1: owner, 2: owner, 3: other}
+
+	day := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	clickRepo.Clicks = append(clickRepo.Clicks,
+		&models.Click{ID: 1, LinkID: 2, IPAddress: "203.0.113.7", UserAgent: "Mozilla/5.0", Browser: "Chrome", Country: "Vietnam", ClickedAt: day.Add(time.Hour)},
+		&models.Click{ID: 2, LinkID: 1, IPAddress: "198.51.100.1", UserAgent: "=HYPERLINK(\"x\")", Browser: "Firefox", Referer: "https://example.com/?a=1,b", ClickedAt: day},
+		&models.Click{ID: 3, LinkID: 1, UserAgent: "Googlebot", Device: "Bot", IsBot: true, ClickedAt: day.Add(2 * time.Hour)},
+		&models.Click{ID: 4, LinkID: 3, Browser: "Safari", ClickedAt: day},
+		&models.Click{ID: 5, LinkID: 1, Browser: "Chrome", ClickedAt: day.AddDate(0, 0, 2)},
+	)
+	return svc, clickRepo
+}
+
+func exportClicks(t *testing.T, svc *service.AnalyticsService, linkID uint, opts service.ClickExportOptions) string {
+	t.Helper()
+	export, err := svc.PrepareClickExport(linkID, 1, opts)
+	if err != nil {
+		t.Fatalf("PrepareClickExport returned error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := export.Write(&buf); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestClickExport_CSV(t *testing.T) {
+	svc, _ := setupClickExport(t)
+	r, err := service.ParseTimeRange("2025-03-10", "2025-03-10", "UTC")
+	if err != nil {
+		t.Fatalf("ParseTimeRange returned error: %v", err)
+	}
+
+	out := exportClicks(t, svc, 1, service.ClickExportOptions{Format: service.ClickExportCSV, Range: r})
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v\n%s", err, out)
+	}
+	// The bot click and the click after the range are left out
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want header and 1 click:\n%s", len(rows), out)
+	}
+	header, row := rows[0], rows[1]
+	got := make(map[string]string)
+	for i, column := range header {
+		got[column] = row[i]
+	}
+	want := map[string]string{
+		"clicked_at": "2025-03-10T08:00:00Z",
+		"link_id":    "1",
+		"short_code": "one",
+		"ip_address": "198.51.100.1",
+		"user_agent": `'=HYPERLINK("x")`,
+		"browser":    "Firefox",
+		"referer":    "https://example.com/?a=1,b",
+		"is_bot":     "false",
+	}
+	for column, value := range want {
+		if got[column] != value {
+			t.Errorf("%s = %q, want %q", column, got[column], value)
+		}
+	}
+}
+
+func TestClickExport_OmitPII(t *testing.T) {
+	svc, _ := setupClickExport(t)
+
+	out := exportClicks(t, svc, 0, service.ClickExportOptions{Format: service.ClickExportCSV, OmitPII: true})
+	if strings.Contains(out, "ip_address") || strings.Contains(out, "user_agent") {
+		t.Errorf("CSV header still has PII columns:\n%s", out)
+	}
+	if strings.Contains(out, "198.51.100.1") || strings.Contains(out, "Mozilla") {
+		t.Errorf("CSV still has PII:\n%s", out)
+	}
+
+	out = exportClicks(t, svc, 0, service.ClickExportOptions{Format: service.ClickExportNDJSON, OmitPII: true})
+	if strings.Contains(out, "ip_address") || strings.Contains(out, "203.0.113.7") {
+		t.Errorf("NDJSON still has PII:\n%s", out)
+	}
+}
+
+func TestClickExport_NDJSONAllLinks(t *testing.T) {
+	svc, _ := setupClickExport(t)
+
+	out := exportClicks(t, svc, 0, service.ClickExportOptions{Format: service.ClickExportNDJSON, IncludeBots: true})
+	var codes []string
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		var record struct {
+			ShortCode string `json:"short_code"`
+			IPAddress string `json:"ip_address"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		codes = append(codes, record.ShortCode)
+	}
+	// Oldest first, without the other user's link
+	want := []string{"one", "two", "one", "one"}
+	if strings.Join(codes, ",") != strings.Join(want, ",") {
+		t.Errorf("exported links = %v, want %v", codes, want)
+	}
+}
+
+func TestClickExport_Errors(t *testing.T) {
+	svc, _ := setupClickExport(t)
+
+	tests := []struct {
+		name   string
+		linkID uint
+		format string
+		want   error
+	}{
+		{"unknown format", 1, "xlsx", service.ErrInvalidExportFormat},
+		{"other user's link", 3, service.ClickExportCSV, service.ErrUnauthorized},
+		{"missing link", 99, service.ClickExportNDJSON, service.ErrLinkNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.PrepareClickExport(tt.linkID, 1, service.ClickExportOptions{Format: tt.format})
+			if err != tt.want {
+				t.Errorf("PrepareClickExport error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/tests/mocks"
+)
+
+func TestNewCodeGenerator_Strategies(t *testing.T) {
+	linkRepo := mocks.NewMockLinkRepository()
+
+	for _, strategy := range []string{"", "random", "sequential", "hashids", "words"} {
+		if _, err := service.NewCodeGenerator(strategy, "abcdef0123", "salt", linkRepo); err != nil {
+			t.Errorf("NewCodeGenerator(%q) returned error: %v", strategy, err)
+		}
+	}
+
+	if _, err := service.NewCodeGenerator("uuid", "abcdef0123", "", linkRepo); err == nil {
+		t.Error("Expected error for unknown strategy")
+	}
+	if _, err := service.NewCodeGenerator("random", "a/b", "", linkRepo); err == nil {
+		t.Error("Expected error for invalid alphabet")
+	}
+}
+
+func TestRandomCodeGenerator_UsesAlphabet(t *testing.T) {
+	gen := service.NewRandomCodeGenerator("XYZ")
+	valid := regexp.MustCompile(`^[XYZ]{7}$`)
+
+	for i := 0; i < 50; i++ {
+		code, err := gen.Generate(7)
+		if err != nil {
+			t.Fatalf("Generate returned error: %v", err)
+		}
+		if !valid.MatchString(code) {
+			t.Errorf("Generate returned %s, want 7 characters from XYZ", code)
+		}
+	}
+}
+
+func TestSequentialCodeGenerator_Generate(t *testing.T) {
+	linkRepo := mocks.NewMockLinkRepository()
+	gen := service.NewSequentialCodeGenerator("0123456789", linkRepo)
+
+	for _, want := range []string{"1", "2", "3"} {
+		code, err := gen.Generate(6)
+		if err != nil {
+			t.Fatalf("Generate returned error: %v", err)
+		}
+		if code != want {
+			t.Errorf("Generate = %s, want %s", code, want)
+		}
+	}
+
+	linkRepo.SequenceErr = errors.New("db down")
+	if _, err := gen.Generate(6); err == nil {
+		t.Error("Expected error when the sequence is unavailable")
+	}
+}
+
+func TestHashidsCodeGenerator_Generate(t *testing.T) {
+	gen := service.NewHashidsCodeGenerator("0123456789abcdef", "salt", mocks.NewMockLinkRepository())
+
+	codes := make(map[string]bool)
+	for i := 0; i < 500; i++ {
+		code, err := gen.Generate(4)
+		if err != nil {
+			t.Fatalf("Generate returned error: %v", err)
+		}
+		if len(code) < 4 {
+			t.Errorf("Generate = %s, want at least 4 characters", code)
+		}
+		if codes[code] {
+			t.Fatalf("Generate returned duplicate code %s", code)
+		}
+		codes[code] = true
+	}
+}
+
+func TestLinkService_CreateLink_UsesCodeGenerator(t *testing.T) {
+	linkRepo := mocks.NewMockLinkRepository()
+	authService := service.NewAuthService(mocks.NewMockUserRepository(), "test-secret", 24)
+	svc := service.NewLinkService(linkRepo, mocks.NewMockClickRepository(), mocks.NewMockTransactionManager(),
+		service.NewGeoIPService(), authService, nil, service.LinkServiceConfig{
+			CodeGenerator: service.NewSequentialCodeGenerator("0123456789", linkRepo),
+		})
+
+	link, err := svc.CreateLink("https://example.com", nil, nil, nil, 6)
+	if err != nil {
+		t.Fatalf("CreateLink returned error: %v", err)
+	}
+	if link.ShortCode != "1" {
+		t.Errorf("link.ShortCode = %s, want 1", link.ShortCode)
+	}
+}
+
+func TestWordCodeGenerator_GrowsWithLength(t *testing.T) {
+	gen := service.NewWordCodeGenerator()
+
+	for length, digits := range map[int]string{4: "2", 6: "2", 7: "3", 9: "5", 20: "5"} {
+		valid := regexp.MustCompile(`^[a-z]+-[a-z]+-[0-9]{` + digits + `}$`)
+		code, err := gen.Generate(length)
+		if err != nil {
+			t.Fatalf("Generate returned error: %v", err)
+		}
+		if !valid.MatchString(code) || len(code) > 20 {
+			t.Errorf("Generate(%d) = %s, want a %s digit suffix within 20 characters", length, code, digits)
+		}
+	}
+}
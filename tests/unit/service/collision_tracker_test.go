@@ -0,0 +1,93 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/tests/mocks"
+)
+
+// fixedCodeGenerator always returns the same code and counts calls
+type fixedCodeGenerator struct {
+	code  string
+	calls int
+}
+
+func (g *fixedCodeGenerator) Generate(length int) (string, error) {
+	g.calls++
+	return g.code, nil
+}
+
+func setupLinkServiceWithGenerator(gen service.CodeGenerator) (*service.LinkService, *mocks.MockLinkRepository) {
+	linkRepo := mocks.NewMockLinkRepository()
+	authService := service.NewAuthService(mocks.NewMockUserRepository(), "test-secret", 24)
+	svc := service.NewLinkService(linkRepo, mocks.NewMockClickRepository(), mocks.NewMockTransactionManager(),
+		service.NewGeoIPService(), authService, nil, service.LinkServiceConfig{CodeGenerator: gen})
+	return svc, linkRepo
+}
+
+func TestCollisionTracker_GrowsLengthOnHighCollisionRate(t *testing.T) {
+	tracker := service.NewCollisionTracker()
+
+	for i := 0; i < 100; i++ {
+		tracker.Record(false)
+	}
+	if got := tracker.Length(6); got != 6 {
+		t.Fatalf("Length(6) = %d without collisions, want 6", got)
+	}
+
+	for i := 0; i < 30; i++ {
+		tracker.Record(true)
+	}
+	if got := tracker.Length(6); got <= 6 {
+		t.Errorf("Length(6) = %d after many collisions, want > 6", got)
+	}
+
+	stats := tracker.Stats()
+	if stats.Attempts != 130 || stats.Collisions != 30 {
+		t.Errorf("Stats = %+v, want 130 attempts and 30 collisions", stats)
+	}
+}
+
+func TestCollisionTracker_LengthIsCapped(t *testing.T) {
+	tracker := service.NewCollisionTracker()
+	for i := 0; i < 10000; i++ {
+		tracker.Record(true)
+	}
+	if got := tracker.Length(6); got != 20 {
+		t.Errorf("Length(6) = %d, want cap of 20", got)
+	}
+}
+
+func TestLinkService_CreateLink_SurfacesNonCollisionErrors(t *testing.T) {
+	gen := &fixedCodeGenerator{code: "abc123"}
+	svc, linkRepo := setupLinkServiceWithGenerator(gen)
+
+	dbErr := errors.New("connection refused")
+	linkRepo.CreateErr = dbErr
+
+	_, err := svc.CreateLink("https://example.com", nil, nil, nil, 6)
+	if err != dbErr {
+		t.Errorf("CreateLink error = %v, want %v", err, dbErr)
+	}
+	if gen.calls != 1 {
+		t.Errorf("generator called %d times, want 1 (no retry on non-collision errors)", gen.calls)
+	}
+}
+
+func TestLinkService_CreateLink_CollisionsExhausted(t *testing.T) {
+	gen := &fixedCodeGenerator{code: "taken1"}
+	svc, linkRepo := setupLinkServiceWithGenerator(gen)
+	linkRepo.Links["taken1"] = &models.Link{ID: 99, ShortCode: "taken1"}
+
+	_, err := svc.CreateLink("https://example.com", nil, nil, nil, 6)
+	if err != service.ErrShortCodeExhausted {
+		t.Errorf("CreateLink error = %v, want ErrShortCodeExhausted", err)
+	}
+
+	if stats := svc.CollisionStats(); stats.Collisions != 5 {
+		t.Errorf("CollisionStats().Collisions = %d, want 5", stats.Collisions)
+	}
+}
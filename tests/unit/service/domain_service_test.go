@@ -0,0 +1,247 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/tests/mocks"
+)
+
+// fakeResolver serves TXT records from a map
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if records, ok := r[name]; ok {
+		return records, nil
+	}
+	return nil, fmt.Errorf("no such host %s", name)
+}
+
+func setupDomainService(resolver fakeResolver) (*service.DomainService, *mocks.MockDomainRepository) {
+	domainRepo := mocks.NewMockDomainRepository()
+	svc := service.NewDomainService(domainRepo, map[string]service.DomainVerifier{
+		service.DomainVerificationDNS:  service.NewDNSDomainVerifier(resolver),
+		service.DomainVerificationHTTP: service.NewHTTPDomainVerifier("http"),
+	}, []string{"short.test"})
+	return svc, domainRepo
+}
+
+func TestDomainService_AddDomain(t *testing.T) {
+	svc, _ := setupDomainService(nil)
+
+	domain, err := svc.AddDomain(1, "Go.Example.com", "")
+	if err != nil {
+		t.Fatalf("AddDomain returned error: %v", err)
+	}
+	if domain.Host != "go.example.com" || domain.VerificationMethod != service.DomainVerificationDNS {
+		t.Errorf("AddDomain = %+v, want host go.example.com with dns verification", domain)
+	}
+	if len(domain.VerificationToken) != 32 || domain.IsVerified() {
+		t.Error("new domain should have a token and be unverified")
+	}
+
+	if _, err := svc.AddDomain(1, "go.example.com", "http"); err != service.ErrDomainAlreadyExists {
+		t.Errorf("AddDomain(duplicate) error = %v, want ErrDomainAlreadyExists", err)
+	}
+	if _, err := svc.AddDomain(2, "go.example.com", "http"); err != nil {
+		t.Errorf("AddDomain(pending host by other user) returned error: %v", err)
+	}
+
+	for _, host := range []string{"localhost", "example.com:8080", "short.test", "bad_host.com", "https://x.com"} {
+		if _, err := svc.AddDomain(1, host, ""); err != service.ErrInvalidDomain {
+			t.Errorf("AddDomain(%q) error = %v, want ErrInvalidDomain", host, err)
+		}
+	}
+}
+
+func TestDomainService_VerifyDomain_DNS(t *testing.T) {
+	resolver := fakeResolver{}
+	svc, _ := setupDomainService(resolver)
+
+	domain, _ := svc.AddDomain(1, "go.example.com", service.DomainVerificationDNS)
+
+	if _, err := svc.VerifyDomain(1, domain.ID); err == nil {
+		t.Fatal("VerifyDomain should fail before the TXT record exists")
+	}
+
+	resolver[service.DomainTXTRecordName("go.example.com")] = []string{"other", domain.VerificationToken}
+	verified, err := svc.VerifyDomain(1, domain.ID)
+	if err != nil {
+		t.Fatalf("VerifyDomain returned error: %v", err)
+	}
+	if !verified.IsVerified() {
+		t.Error("domain should be verified")
+	}
+
+	if _, err := svc.VerifyDomain(2, domain.ID); err != service.ErrUnauthorized {
+		t.Errorf("VerifyDomain by other user error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestDomainService_VerifyDomain_PendingClaims(t *testing.T) {
+	resolver := fakeResolver{}
+	svc, domainRepo := setupDomainService(resolver)
+
+	squatter, _ := svc.AddDomain(1, "go.example.com", service.DomainVerificationDNS)
+	owner, err := svc.AddDomain(2, "go.example.com", service.DomainVerificationDNS)
+	if err != nil {
+		t.Fatalf("AddDomain(pending host) returned error: %v", err)
+	}
+
+	resolver[service.DomainTXTRecordName("go.example.com")] = []string{owner.VerificationToken}
+	if _, err := svc.VerifyDomain(2, owner.ID); err != nil {
+		t.Fatalf("VerifyDomain returned error: %v", err)
+	}
+	if _, ok := domainRepo.Domains[squatter.ID]; ok {
+		t.Error("the losing claim should be removed once the host is verified")
+	}
+	if domain, err := svc.ResolveHost("go.example.com"); err != nil || domain.UserID != 2 {
+		t.Errorf("ResolveHost = %+v, %v; want the verified claim", domain, err)
+	}
+
+	if _, err := svc.AddDomain(3, "go.example.com", service.DomainVerificationDNS); err != service.ErrDomainAlreadyExists {
+		t.Errorf("AddDomain(verified host) error = %v, want ErrDomainAlreadyExists", err)
+	}
+
+	// A claim that escaped the cleanup can no longer be verified
+	late := &models.Domain{ID: 10, UserID: 3, Host: "go.example.com", VerificationMethod: service.DomainVerificationDNS}
+	domainRepo.Domains[late.ID] = late
+	if _, err := svc.VerifyDomain(3, late.ID); err != service.ErrDomainAlreadyExists {
+		t.Errorf("VerifyDomain(verified host) error = %v, want ErrDomainAlreadyExists", err)
+	}
+}
+
+func TestHTTPDomainVerifier_Verify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/.well-known/shorten-verify.txt" {
+			fmt.Fprintln(w, "secret-token")
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	verifier := service.NewHTTPDomainVerifierWithClient("http", server.Client())
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	if err := verifier.Verify(&models.Domain{Host: host, VerificationToken: "secret-token"}); err != nil {
+		t.Errorf("Verify returned error: %v", err)
+	}
+	if err := verifier.Verify(&models.Domain{Host: host, VerificationToken: "wrong"}); err == nil {
+		t.Error("Verify should fail for a wrong token")
+	}
+}
+
+func TestHTTPDomainVerifier_RejectsInternalTargets(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/.well-known/shorten-verify.txt" {
+			http.Redirect(w, r, "/token", http.StatusFound)
+			return
+		}
+		fmt.Fprintln(w, "secret-token")
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	domain := &models.Domain{Host: host, VerificationToken: "secret-token"}
+
+	if err := service.NewHTTPDomainVerifier("http").Verify(domain); !errors.Is(err, service.ErrDomainVerificationFailed) {
+		t.Errorf("Verify of a loopback host error = %v, want ErrDomainVerificationFailed", err)
+	}
+	if requests != 0 {
+		t.Errorf("loopback server got %d requests, want 0", requests)
+	}
+
+	verifier := service.NewHTTPDomainVerifierWithClient("http", server.Client())
+	if err := verifier.Verify(domain); err == nil {
+		t.Error("Verify should not follow redirects")
+	}
+	if requests != 1 {
+		t.Errorf("server got %d requests, want 1", requests)
+	}
+}
+
+func TestDomainService_DeleteDomain_InUse(t *testing.T) {
+	svc, domainRepo := setupDomainService(nil)
+
+	domain, _ := svc.AddDomain(1, "go.example.com", "")
+	domainRepo.LinkCounts[domain.ID] = 3
+
+	if err := svc.DeleteDomain(1, domain.ID); err != service.ErrDomainInUse {
+		t.Errorf("DeleteDomain error = %v, want ErrDomainInUse", err)
+	}
+
+	domainRepo.LinkCounts[domain.ID] = 0
+	if err := svc.DeleteDomain(1, domain.ID); err != nil {
+		t.Errorf("DeleteDomain returned error: %v", err)
+	}
+}
+
+func TestLinkService_CustomDomains(t *testing.T) {
+	domainSvc, domainRepo := setupDomainService(nil)
+	now := time.Now()
+	domainRepo.Domains[1] = &models.Domain{ID: 1, UserID: 1, Host: "go.example.com", VerifiedAt: &now}
+	domainRepo.Domains[2] = &models.Domain{ID: 2, UserID: 1, Host: "pending.example.com"}
+	domainRepo.NextID = 3
+
+	linkRepo := mocks.NewMockLinkRepository()
+	authService := service.NewAuthService(mocks.NewMockUserRepository(), "test-secret", 24)
+	svc := service.NewLinkService(linkRepo, mocks.NewMockClickRepository(), mocks.NewMockTransactionManager(),
+		service.NewGeoIPService(), authService, nil, service.LinkServiceConfig{
+			OwnDomains:    []string{"short.test"},
+			CustomDomains: domainSvc,
+		})
+
+	owner, other := uint(1), uint(2)
+	alias := "promo"
+
+	shared, err := svc.CreateLink("https://example.com/shared", &alias, &other, nil, 6)
+	if err != nil {
+		t.Fatalf("CreateLink on shared domain returned error: %v", err)
+	}
+	branded, err := svc.CreateLinkOnDomain("go.example.com", "https://example.com/branded", &alias, &owner, nil, 6)
+	if err != nil {
+		t.Fatalf("CreateLinkOnDomain returned error: %v", err)
+	}
+	if branded.Domain == nil || branded.Domain.Host != "go.example.com" || shared.Domain != nil {
+		t.Error("links should carry their domain")
+	}
+
+	if _, err := svc.CreateLinkOnDomain("pending.example.com", "https://example.com", nil, &owner, nil, 6); err != service.ErrDomainNotFound {
+		t.Errorf("CreateLinkOnDomain(unverified) error = %v, want ErrDomainNotFound", err)
+	}
+	if _, err := svc.CreateLinkOnDomain("go.example.com", "https://example.com", nil, &other, nil, 6); err != service.ErrDomainNotFound {
+		t.Errorf("CreateLinkOnDomain(not owner) error = %v, want ErrDomainNotFound", err)
+	}
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"go.example.com", "https://example.com/branded"},
+		{"short.test", "https://example.com/shared"},
+		{"unknown.example.com", "https://example.com/shared"},
+	}
+	for _, tt := range tests {
+		url, err := svc.Redirect("promo", &service.ClickInfo{IPAddress: "127.0.0.1", Host: tt.host})
+		if err != nil {
+			t.Fatalf("Redirect(host %s) returned error: %v", tt.host, err)
+		}
+		if url != tt.want {
+			t.Errorf("Redirect(host %s) = %s, want %s", tt.host, url, tt.want)
+		}
+	}
+
+	if _, err := svc.GetLinkWithAnalytics("go.example.com", "promo", owner); err != nil {
+		t.Errorf("GetLinkWithAnalytics on branded domain returned error: %v", err)
+	}
+}
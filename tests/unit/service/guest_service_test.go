@@ -0,0 +1,305 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/pkg/utils"
+	"quocbui.dev/m/tests/mocks"
+)
+
+func setupGuestService() (*service.GuestService, *service.AuthService, *mocks.MockUserRepository, *mocks.MockLinkRepository) {
+	linkRepo := mocks.NewMockLinkRepository()
+	userRepo := mocks.NewMockUserRepository()
+	userRepo.LinkRepo = linkRepo
+	authService := service.NewAuthService(userRepo, "test-secret", 24)
+	guestService := service.NewGuestService(userRepo, linkRepo, mocks.NewMockTransactionManager(), authService)
+	return guestService, authService, userRepo, linkRepo
+}
+
+func TestGuestService_MaterializeGuest(t *testing.T) {
+	guestService, authService, userRepo, linkRepo := setupGuestService()
+
+	guestID, _, err := authService.CreateGuestToken()
+	if err != nil {
+		t.Fatalf("CreateGuestToken returned error: %v", err)
+	}
+	if len(userRepo.Users) != 0 {
+		t.Fatal("Creating a guest token should not create a user")
+	}
+
+	linkRepo.Links["guest1"] = &models.Link{ID: 1, ShortCode: "guest1", GuestID: &guestID}
+
+	userID, err := guestService.MaterializeGuest(guestID)
+	if err != nil {
+		t.Fatalf("MaterializeGuest returned error: %v", err)
+	}
+	user, err := userRepo.GetByID(userID)
+	if err != nil || !user.IsGuest() || user.PasswordHash != "" {
+		t.Fatalf("Materialized user = %+v, want a guest without password", user)
+	}
+	if linkRepo.Links["guest1"].UserID == nil || *linkRepo.Links["guest1"].UserID != userID {
+		t.Error("Guest links should be adopted by the materialized user")
+	}
+
+	again, err := guestService.MaterializeGuest(guestID)
+	if err != nil || again != userID {
+		t.Errorf("MaterializeGuest again = %d, %v; want %d", again, err, userID)
+	}
+	if len(userRepo.Users) != 1 {
+		t.Error("Second call should reuse the user")
+	}
+}
+
+func TestGuestService_MaterializeGuest_AdoptsOnce(t *testing.T) {
+	guestService, authService, _, linkRepo := setupGuestService()
+	linkService := service.NewLinkService(linkRepo, mocks.NewMockClickRepository(), mocks.NewMockTransactionManager(),
+		service.NewGeoIPService(), authService, nil, service.LinkServiceConfig{})
+
+	guestID, guestToken, _ := authService.CreateGuestToken()
+	userID, err := guestService.MaterializeGuest(guestID)
+	if err != nil {
+		t.Fatalf("MaterializeGuest returned error: %v", err)
+	}
+
+	// Links created afterwards are not adopted by later requests
+	linkRepo.Links["late"] = &models.Link{ID: 9, ShortCode: "late", GuestID: &guestID}
+	guestService.MaterializeGuest(guestID)
+	if linkRepo.Links["late"].UserID != nil {
+		t.Error("MaterializeGuest should only adopt links when it creates the user")
+	}
+
+	// but links shortened with the guest token belong to the user at once
+	link, _, err := linkService.CreateLinkWithAuth("https://example.com", nil, "", nil, "Bearer "+guestToken, 6)
+	if err != nil {
+		t.Fatalf("CreateLinkWithAuth returned error: %v", err)
+	}
+	if link.UserID == nil || *link.UserID != userID || link.GuestID == nil || *link.GuestID != guestID {
+		t.Errorf("link owner = %v, guest = %v; want user %d and guest %s", link.UserID, link.GuestID, userID, guestID)
+	}
+}
+
+func TestGuestService_ClaimLinks(t *testing.T) {
+	guestService, authService, userRepo, linkRepo := setupGuestService()
+
+	guestID, guestToken, _ := authService.CreateGuestToken()
+	user, _ := authService.Register("user@example.com", "password123", "User")
+
+	// One link was adopted by the materialized guest, one is still unowned
+	linkRepo.Links["guest1"] = &models.Link{ID: 1, ShortCode: "guest1", GuestID: &guestID}
+	guestService.MaterializeGuest(guestID)
+	linkRepo.Links["guest2"] = &models.Link{ID: 2, ShortCode: "guest2", GuestID: &guestID}
+	linkRepo.Links["other"] = &models.Link{ID: 3, ShortCode: "other", UserID: &user.ID}
+
+	guest, err := guestService.ResolveGuest("Bearer " + guestToken)
+	if err != nil {
+		t.Fatalf("ResolveGuest returned error: %v", err)
+	}
+	if guest.ID != guestID || guest.User == nil {
+		t.Fatalf("ResolveGuest = %+v, want guest %s with its user row", guest, guestID)
+	}
+	guestUserID := guest.User.ID
+
+	claimed, err := guestService.ClaimLinks(guest, user.ID)
+	if err != nil {
+		t.Fatalf("ClaimLinks returned error: %v", err)
+	}
+	if claimed != 2 {
+		t.Errorf("claimed = %d, want 2", claimed)
+	}
+	for _, code := range []string{"guest1", "guest2"} {
+		if *linkRepo.Links[code].UserID != user.ID {
+			t.Errorf("Link %s should belong to the user", code)
+		}
+	}
+	if _, err := userRepo.GetByID(guestUserID); err == nil {
+		t.Error("Guest user should be deleted")
+	}
+}
+
+func TestGuestService_ResolveGuest_LegacyGuestUser(t *testing.T) {
+	guestService, _, userRepo, _ := setupGuestService()
+
+	legacy := &models.User{Email: "guest_1@temp.local", Name: "Guest"}
+	userRepo.Create(legacy)
+	token, _ := utils.GenerateToken(legacy.ID, legacy.Email, "test-secret", 24)
+
+	guest, err := guestService.ResolveGuest(token)
+	if err != nil {
+		t.Fatalf("ResolveGuest returned error: %v", err)
+	}
+	if guest.ID != "" || guest.User == nil || guest.User.ID != legacy.ID {
+		t.Errorf("ResolveGuest = %+v, want the legacy guest user", guest)
+	}
+}
+
+func TestGuestService_ResolveGuest_Invalid(t *testing.T) {
+	guestService, authService, _, _ := setupGuestService()
+
+	authService.Register("user@example.com", "password123", "User")
+	_, userToken, _ := authService.LoginWithToken("user@example.com", "password123")
+
+	for name, token := range map[string]string{
+		"malformed":       "not-a-token",
+		"registered user": userToken,
+	} {
+		if _, err := guestService.ResolveGuest(token); err != service.ErrInvalidGuestToken {
+			t.Errorf("ResolveGuest(%s) error = %v, want ErrInvalidGuestToken", name, err)
+		}
+	}
+}
+
+func TestGuestService_CollectGarbage(t *testing.T) {
+	guestService, _, userRepo, linkRepo := setupGuestService()
+	userRepo.ActiveUsers = map[uint]bool{}
+
+	old := time.Now().Add(-48 * time.Hour)
+	abandoned := &models.User{Email: "guest_1@temp.local", CreatedAt: old}
+	active := &models.User{Email: "guest_2@temp.local", CreatedAt: old}
+	fresh := &models.User{Email: "guest_3@temp.local", CreatedAt: time.Now()}
+	registered := &models.User{Email: "user@example.com", CreatedAt: old}
+	for _, user := range []*models.User{abandoned, active, fresh, registered} {
+		userRepo.Create(user)
+	}
+	userRepo.ActiveUsers[active.ID] = true
+
+	expired := time.Now().Add(-time.Hour)
+	linkRepo.Links["old1"] = &models.Link{ID: 1, ShortCode: "old1", UserID: &abandoned.ID, ExpiresAt: &expired}
+	linkRepo.Links["old2"] = &models.Link{ID: 2, ShortCode: "old2", UserID: &abandoned.ID, ExpiresAt: &expired}
+	linkRepo.Links["live"] = &models.Link{ID: 4, ShortCode: "live", UserID: &abandoned.ID}
+	linkRepo.Links["busy"] = &models.Link{ID: 3, ShortCode: "busy", UserID: &active.ID}
+
+	policy := service.GuestGCPolicy{
+		TokenLifetime: 24 * time.Hour,
+		InactiveFor:   30 * 24 * time.Hour,
+		BatchSize:     100,
+		DryRun:        true,
+	}
+
+	report, err := guestService.CollectGarbage(policy)
+	if err != nil {
+		t.Fatalf("CollectGarbage(dry run) returned error: %v", err)
+	}
+	if report.Guests != 1 || report.Links != 2 || !report.DryRun {
+		t.Errorf("dry run report = %+v, want 1 guest and 2 links", report)
+	}
+	if len(userRepo.Users) != 4 || len(linkRepo.Links) != 4 {
+		t.Error("Dry run should not remove anything")
+	}
+
+	policy.DryRun = false
+	report, err = guestService.CollectGarbage(policy)
+	if err != nil {
+		t.Fatalf("CollectGarbage returned error: %v", err)
+	}
+	if report.Guests != 1 || report.Links != 2 {
+		t.Errorf("report = %+v, want 1 guest and 2 links", report)
+	}
+	if _, err := userRepo.GetByID(abandoned.ID); err == nil {
+		t.Error("Abandoned guest should be removed")
+	}
+	if _, ok := linkRepo.Links["busy"]; !ok || len(userRepo.Users) != 3 {
+		t.Error("Active guests, fresh guests and registered users should be kept")
+	}
+	if live, ok := linkRepo.Links["live"]; !ok || live.UserID != nil {
+		t.Error("Unexpired links should be kept and detached from the removed guest")
+	}
+}
+
+func TestGuestService_CollectGarbage_GuestLinks(t *testing.T) {
+	guestService, _, _, linkRepo := setupGuestService()
+
+	old := time.Now().Add(-48 * time.Hour)
+	expired := time.Now().Add(-time.Hour)
+	abandoned, active, recent, owner := "abandoned", "active", "recent", uint(1)
+	linkRepo.ActiveGuests = map[string]bool{active: true}
+	linkRepo.Links["gone"] = &models.Link{ID: 1, ShortCode: "gone", GuestID: &abandoned, CreatedAt: old, ExpiresAt: &expired}
+	linkRepo.Links["kept"] = &models.Link{ID: 2, ShortCode: "kept", GuestID: &abandoned, CreatedAt: old}
+	linkRepo.Links["busy"] = &models.Link{ID: 3, ShortCode: "busy", GuestID: &active, CreatedAt: old, ExpiresAt: &expired}
+	linkRepo.Links["new"] = &models.Link{ID: 4, ShortCode: "new", GuestID: &recent, CreatedAt: time.Now(), ExpiresAt: &expired}
+	linkRepo.Links["claimed"] = &models.Link{ID: 5, ShortCode: "claimed", GuestID: &abandoned, UserID: &owner, CreatedAt: old, ExpiresAt: &expired}
+
+	policy := service.GuestGCPolicy{
+		TokenLifetime: 24 * time.Hour,
+		InactiveFor:   30 * 24 * time.Hour,
+		BatchSize:     100,
+		DryRun:        true,
+	}
+	report, err := guestService.CollectGarbage(policy)
+	if err != nil {
+		t.Fatalf("CollectGarbage(dry run) returned error: %v", err)
+	}
+	if report.Anonymous != 1 || report.Links != 1 || len(linkRepo.Links) != 5 {
+		t.Errorf("dry run report = %+v with %d links left, want 1 anonymous guest, 1 link and nothing removed", report, len(linkRepo.Links))
+	}
+
+	policy.DryRun = false
+	report, err = guestService.CollectGarbage(policy)
+	if err != nil {
+		t.Fatalf("CollectGarbage returned error: %v", err)
+	}
+	if report.Anonymous != 1 || report.Links != 1 {
+		t.Errorf("report = %+v, want 1 anonymous guest and 1 link", report)
+	}
+	if _, ok := linkRepo.Links["gone"]; ok {
+		t.Error("Expired link of the abandoned guest should be archived")
+	}
+	if kept, ok := linkRepo.Links["kept"]; !ok || kept.GuestID != nil {
+		t.Error("Unexpired link of the abandoned guest should be kept and detached")
+	}
+	for _, code := range []string{"busy", "new", "claimed"} {
+		if link, ok := linkRepo.Links[code]; !ok || link.GuestID == nil {
+			t.Errorf("Link %s should be left alone", code)
+		}
+	}
+
+	report, err = guestService.CollectGarbage(policy)
+	if err != nil || report.Anonymous != 0 {
+		t.Errorf("second run report = %+v, %v; want nothing left to collect", report, err)
+	}
+}
+
+func TestGuestService_CollectGarbage_MaterializedGuestLinks(t *testing.T) {
+	guestService, authService, userRepo, linkRepo := setupGuestService()
+	userRepo.ActiveUsers = map[uint]bool{}
+
+	guestID, _, _ := authService.CreateGuestToken()
+	expired := time.Now().Add(-time.Hour)
+	old := time.Now().Add(-48 * time.Hour)
+	linkRepo.Links["old"] = &models.Link{ID: 1, ShortCode: "old", GuestID: &guestID, CreatedAt: old, ExpiresAt: &expired}
+	linkRepo.Links["live"] = &models.Link{ID: 2, ShortCode: "live", GuestID: &guestID, CreatedAt: old}
+	userID, err := guestService.MaterializeGuest(guestID)
+	if err != nil {
+		t.Fatalf("MaterializeGuest returned error: %v", err)
+	}
+	user, _ := userRepo.GetByID(userID)
+	user.CreatedAt = old
+
+	policy := service.GuestGCPolicy{
+		TokenLifetime: 24 * time.Hour,
+		InactiveFor:   30 * 24 * time.Hour,
+		BatchSize:     100,
+		DryRun:        true,
+	}
+	dryRun, err := guestService.CollectGarbage(policy)
+	if err != nil {
+		t.Fatalf("CollectGarbage(dry run) returned error: %v", err)
+	}
+
+	policy.DryRun = false
+	report, err := guestService.CollectGarbage(policy)
+	if err != nil {
+		t.Fatalf("CollectGarbage returned error: %v", err)
+	}
+	if report.Guests != 1 || report.Anonymous != 0 || report.Links != 1 {
+		t.Errorf("report = %+v, want 1 guest, no anonymous guest and 1 link", report)
+	}
+	if dryRun.Guests != report.Guests || dryRun.Anonymous != report.Anonymous || dryRun.Links != report.Links {
+		t.Errorf("dry run report = %+v, want the same totals as %+v", dryRun, report)
+	}
+	if live, ok := linkRepo.Links["live"]; !ok || live.UserID != nil || live.GuestID != nil {
+		t.Error("Unexpired link should be kept and detached from both the user and the guest")
+	}
+}
@@ -1,11 +1,13 @@
 package service_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"quocbui.dev/m/internal/models"
 	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/pkg/utils"
 	"quocbui.dev/m/tests/mocks"
 )
 
@@ -17,7 +19,7 @@ func setupLinkService() (*service.LinkService, *mocks.MockLinkRepository, *mocks
 	userRepo := mocks.NewMockUserRepository()
 	authService := service.NewAuthService(userRepo, "test-secret", 24)
 
-	svc := service.NewLinkService(linkRepo, clickRepo, txManager, geoIP, authService)
+	svc := service.NewLinkService(linkRepo, clickRepo, txManager, geoIP, authService, nil, service.LinkServiceConfig{})
 	return svc, linkRepo, clickRepo
 }
 
@@ -196,6 +198,83 @@ func TestLinkService_Redirect_Expired(t *testing.T) {
 	}
 }
 
+func TestLinkService_Redirect_ExpiredWithFallback(t *testing.T) {
+	svc, linkRepo, _ := setupLinkService()
+
+	expiredTime := time.Now().Add(-1 * time.Hour)
+	fallbackURL := "https://example.com/expired"
+	linkRepo.Links["expired"] = &models.Link{
+		ID:          1,
+		ShortCode:   "expired",
+		OriginalURL: "https://example.com",
+		FallbackURL: &fallbackURL,
+		ExpiresAt:   &expiredTime,
+	}
+
+	url, err := svc.Redirect("expired", &service.ClickInfo{IPAddress: "127.0.0.1"})
+	if err != service.ErrLinkExpired {
+		t.Errorf("Expected ErrLinkExpired, got %v", err)
+	}
+	if url != fallbackURL {
+		t.Errorf("Expected fallback URL %s, got %s", fallbackURL, url)
+	}
+}
+
+func TestLinkService_Redirect_Paused(t *testing.T) {
+	svc, linkRepo, _ := setupLinkService()
+
+	linkRepo.Links["paused"] = &models.Link{
+		ID:          1,
+		ShortCode:   "paused",
+		OriginalURL: "https://example.com",
+		Paused:      true,
+	}
+
+	_, err := svc.Redirect("paused", &service.ClickInfo{IPAddress: "127.0.0.1"})
+	if err != service.ErrLinkPaused {
+		t.Errorf("Expected ErrLinkPaused, got %v", err)
+	}
+}
+
+func TestLinkService_UpdateLink(t *testing.T) {
+	svc, linkRepo, _ := setupLinkService()
+
+	userID := uint(1)
+	linkRepo.Links["mylink"] = &models.Link{
+		ID:          1,
+		ShortCode:   "mylink",
+		OriginalURL: "https://example.com",
+		UserID:      &userID,
+	}
+
+	paused := true
+	fallbackURL := "https://example.com/gone"
+	link, err := svc.UpdateLink("", "mylink", userID, service.LinkUpdate{Paused: &paused, FallbackURL: &fallbackURL})
+	if err != nil {
+		t.Fatalf("UpdateLink returned error: %v", err)
+	}
+	if !link.Paused || link.FallbackURL == nil || *link.FallbackURL != fallbackURL {
+		t.Errorf("UpdateLink did not apply changes: %+v", link)
+	}
+
+	empty := ""
+	link, err = svc.UpdateLink("", "mylink", userID, service.LinkUpdate{FallbackURL: &empty})
+	if err != nil {
+		t.Fatalf("UpdateLink returned error: %v", err)
+	}
+	if link.FallbackURL != nil || !link.Paused {
+		t.Error("Empty fallback URL should remove the fallback and keep other settings")
+	}
+
+	invalid := "not-a-url"
+	if _, err := svc.UpdateLink("", "mylink", userID, service.LinkUpdate{FallbackURL: &invalid}); err != service.ErrInvalidURL {
+		t.Errorf("Expected ErrInvalidURL, got %v", err)
+	}
+	if _, err := svc.UpdateLink("", "mylink", 2, service.LinkUpdate{Paused: &paused}); err != service.ErrUnauthorized {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+}
+
 func TestLinkService_GetUserLinks_Success(t *testing.T) {
 	svc, linkRepo, _ := setupLinkService()
 
@@ -245,7 +324,7 @@ func TestLinkService_GetLinkWithAnalytics_Success(t *testing.T) {
 		UserID:      &userID,
 	}
 
-	link, err := svc.GetLinkWithAnalytics("mylink", userID)
+	link, err := svc.GetLinkWithAnalytics("", "mylink", userID)
 	if err != nil {
 		t.Fatalf("GetLinkWithAnalytics returned error: %v", err)
 	}
@@ -258,7 +337,7 @@ func TestLinkService_GetLinkWithAnalytics_Success(t *testing.T) {
 func TestLinkService_GetLinkWithAnalytics_NotFound(t *testing.T) {
 	svc, _, _ := setupLinkService()
 
-	_, err := svc.GetLinkWithAnalytics("nonexistent", 1)
+	_, err := svc.GetLinkWithAnalytics("", "nonexistent", 1)
 	if err == nil {
 		t.Error("Expected error for non-existent link")
 	}
@@ -275,7 +354,7 @@ func TestLinkService_GetLinkWithAnalytics_Unauthorized(t *testing.T) {
 		UserID:    &ownerID,
 	}
 
-	_, err := svc.GetLinkWithAnalytics("mylink", otherUserID)
+	_, err := svc.GetLinkWithAnalytics("", "mylink", otherUserID)
 	if err == nil {
 		t.Error("Expected error for unauthorized access")
 	}
@@ -291,7 +370,7 @@ func TestLinkService_DeleteLink_Success(t *testing.T) {
 		UserID:    &userID,
 	}
 
-	err := svc.DeleteLink("todelete", userID)
+	err := svc.DeleteLink("", "todelete", userID)
 	if err != nil {
 		t.Fatalf("DeleteLink returned error: %v", err)
 	}
@@ -304,7 +383,7 @@ func TestLinkService_DeleteLink_Success(t *testing.T) {
 func TestLinkService_DeleteLink_NotFound(t *testing.T) {
 	svc, _, _ := setupLinkService()
 
-	err := svc.DeleteLink("nonexistent", 1)
+	err := svc.DeleteLink("", "nonexistent", 1)
 	if err == nil {
 		t.Error("Expected error for non-existent link")
 	}
@@ -321,8 +400,230 @@ func TestLinkService_DeleteLink_Unauthorized(t *testing.T) {
 		UserID:    &ownerID,
 	}
 
-	err := svc.DeleteLink("mylink", otherUserID)
+	err := svc.DeleteLink("", "mylink", otherUserID)
 	if err == nil {
 		t.Error("Expected error for unauthorized delete")
 	}
 }
+
+func setupLinkServiceWithDomains() (*service.LinkService, *mocks.MockLinkRepository) {
+	linkRepo := mocks.NewMockLinkRepository()
+	authService := service.NewAuthService(mocks.NewMockUserRepository(), "test-secret", 24)
+	svc := service.NewLinkService(linkRepo, mocks.NewMockClickRepository(), mocks.NewMockTransactionManager(),
+		service.NewGeoIPService(), authService, nil, service.LinkServiceConfig{
+			OwnDomains:       []string{"short.test", "go.short.test:8080"},
+			ShortenerDomains: []string{"bit.ly"},
+			Reserved:         utils.NewReservedWords([]string{"swagger"}, nil),
+		})
+	return svc, linkRepo
+}
+
+func TestLinkService_CreateLink_FlattensOwnShortLink(t *testing.T) {
+	svc, linkRepo := setupLinkServiceWithDomains()
+
+	linkRepo.Links["target"] = &models.Link{
+		ID:          1,
+		ShortCode:   "target",
+		OriginalURL: "https://example.com/final",
+	}
+
+	for _, url := range []string{"https://short.test/target", "https://www.short.test/target/", "http://go.short.test:8080/target"} {
+		link, err := svc.CreateLink(url, nil, nil, nil, 6)
+		if err != nil {
+			t.Fatalf("CreateLink(%q) returned error: %v", url, err)
+		}
+		if link.OriginalURL != "https://example.com/final" {
+			t.Errorf("CreateLink(%q) OriginalURL = %s, want https://example.com/final", url, link.OriginalURL)
+		}
+	}
+}
+
+func TestLinkService_CreateLink_RejectsRedirectLoop(t *testing.T) {
+	svc, linkRepo := setupLinkServiceWithDomains()
+
+	// Paused links are kept in chains, so they must not lead back to the new link
+	linkRepo.Links["paused"] = &models.Link{ID: 1, ShortCode: "paused", OriginalURL: "https://short.test/loop", Paused: true}
+	fallback := "https://short.test/paused"
+	expired := time.Now().Add(-time.Hour)
+	linkRepo.Links["old"] = &models.Link{ID: 2, ShortCode: "old", OriginalURL: "https://example.com", ExpiresAt: &expired, FallbackURL: &fallback}
+
+	self, loop := "self", "loop"
+	tests := []struct {
+		url   string
+		alias *string
+		err   error
+	}{
+		{"https://short.test/self", &self, service.ErrRedirectLoop},   // alias pointing to itself
+		{"https://short.test/paused", &loop, service.ErrRedirectLoop}, // back through a paused link
+		{"https://short.test/old", &loop, service.ErrRedirectLoop},    // back through a fallback
+		{"https://short.test/missing", nil, service.ErrTargetLinkNotFound},
+	}
+
+	for _, tt := range tests {
+		_, err := svc.CreateLink(tt.url, tt.alias, nil, nil, 6)
+		if err != tt.err {
+			t.Errorf("CreateLink(%q) error = %v, want %v", tt.url, err, tt.err)
+		}
+	}
+}
+
+func TestLinkService_CreateLink_KeepsOwnPages(t *testing.T) {
+	svc, linkRepo := setupLinkServiceWithDomains()
+
+	linkRepo.Links["paused"] = &models.Link{ID: 1, ShortCode: "paused", OriginalURL: "https://example.com", Paused: true}
+
+	for _, url := range []string{
+		"https://short.test/",
+		"https://short.test/swagger",
+		"https://short.test/api/v1/shorten",
+		"https://short.test/paused",
+	} {
+		link, err := svc.CreateLink(url, nil, nil, nil, 6)
+		if err != nil {
+			t.Errorf("CreateLink(%q) returned error: %v", url, err)
+			continue
+		}
+		if link.OriginalURL != url {
+			t.Errorf("OriginalURL = %s, want %s kept as is", link.OriginalURL, url)
+		}
+	}
+}
+
+func TestLinkService_UpdateLink_FallbackChain(t *testing.T) {
+	svc, linkRepo := setupLinkServiceWithDomains()
+
+	userID := uint(1)
+	linkRepo.Links["mylink"] = &models.Link{ID: 1, ShortCode: "mylink", OriginalURL: "https://example.com", UserID: &userID, Paused: true}
+	linkRepo.Links["other"] = &models.Link{ID: 2, ShortCode: "other", OriginalURL: "https://example.com/other"}
+
+	tests := []struct {
+		url string
+		err error
+	}{
+		{"https://short.test/mylink", service.ErrRedirectLoop}, // itself, while paused
+		{"https://short.test/missing", service.ErrTargetLinkNotFound},
+		{"https://bit.ly/abc", service.ErrShortenerChain},
+	}
+	for _, tt := range tests {
+		if _, err := svc.UpdateLink("", "mylink", userID, service.LinkUpdate{FallbackURL: &tt.url}); err != tt.err {
+			t.Errorf("UpdateLink(fallback %q) error = %v, want %v", tt.url, err, tt.err)
+		}
+	}
+
+	fallbackURL := "https://short.test/other"
+	link, err := svc.UpdateLink("", "mylink", userID, service.LinkUpdate{FallbackURL: &fallbackURL})
+	if err != nil {
+		t.Fatalf("UpdateLink returned error: %v", err)
+	}
+	if link.FallbackURL == nil || *link.FallbackURL != "https://example.com/other" {
+		t.Errorf("FallbackURL = %v, want the flattened https://example.com/other", link.FallbackURL)
+	}
+}
+
+func TestLinkService_CreateLink_OwnLinkLookupError(t *testing.T) {
+	svc, linkRepo := setupLinkServiceWithDomains()
+	linkRepo.GetErr = errors.New("connection refused")
+
+	_, err := svc.CreateLink("https://short.test/target", nil, nil, nil, 6)
+	if err != linkRepo.GetErr {
+		t.Errorf("CreateLink error = %v, want the lookup error", err)
+	}
+}
+
+func TestLinkService_CreateLink_RejectsOtherShorteners(t *testing.T) {
+	svc, _ := setupLinkServiceWithDomains()
+
+	_, err := svc.CreateLink("https://bit.ly/abc", nil, nil, nil, 6)
+	if err != service.ErrShortenerChain {
+		t.Errorf("CreateLink error = %v, want ErrShortenerChain", err)
+	}
+}
+
+func TestLinkService_CreateLink_ReservedAlias(t *testing.T) {
+	linkRepo := mocks.NewMockLinkRepository()
+	authService := service.NewAuthService(mocks.NewMockUserRepository(), "test-secret", 24)
+	reserved := utils.NewReservedWords([]string{"admin"}, []string{"badword"})
+	reserved.ReserveRoutes("/health", "/api/v1/shorten")
+	svc := service.NewLinkService(linkRepo, mocks.NewMockClickRepository(), mocks.NewMockTransactionManager(),
+		service.NewGeoIPService(), authService, nil, service.LinkServiceConfig{Reserved: reserved})
+
+	for _, alias := range []string{"health", "API", "admin", "my-badword"} {
+		_, err := svc.CreateLink("https://example.com", &alias, nil, nil, 6)
+		if err != service.ErrAliasReserved {
+			t.Errorf("CreateLink with alias %q error = %v, want ErrAliasReserved", alias, err)
+		}
+	}
+}
+
+func TestLinkService_CreateLinkWithAuth_Guest(t *testing.T) {
+	linkRepo := mocks.NewMockLinkRepository()
+	userRepo := mocks.NewMockUserRepository()
+	authService := service.NewAuthService(userRepo, "test-secret", 24)
+	svc := service.NewLinkService(linkRepo, mocks.NewMockClickRepository(), mocks.NewMockTransactionManager(),
+		service.NewGeoIPService(), authService, nil, service.LinkServiceConfig{})
+
+	link, token, err := svc.CreateLinkWithAuth("https://example.com/1", nil, "", nil, "", 6)
+	if err != nil {
+		t.Fatalf("CreateLinkWithAuth returned error: %v", err)
+	}
+	if token == "" || link.GuestID == nil || link.UserID != nil {
+		t.Fatalf("Anonymous link should get a guest token and guest owner, got token %q and link %+v", token, link)
+	}
+	if len(userRepo.Users) != 0 {
+		t.Error("Anonymous shorten should not create a user")
+	}
+
+	next, nextToken, err := svc.CreateLinkWithAuth("https://example.com/2", nil, "", nil, "Bearer "+token, 6)
+	if err != nil {
+		t.Fatalf("CreateLinkWithAuth with guest token returned error: %v", err)
+	}
+	if nextToken != "" || next.GuestID == nil || *next.GuestID != *link.GuestID {
+		t.Error("Guest token should keep creating links for the same guest")
+	}
+}
+
+// waitForClicks waits for the asynchronous click tracking of a redirect
+func waitForClicks(t *testing.T, clickRepo *mocks.MockClickRepository, n int) []*models.Click {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		clicks := clickRepo.Recorded()
+		if len(clicks) >= n {
+			return clicks
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("recorded %d clicks, want %d", len(clicks), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLinkService_Redirect_Channel(t *testing.T) {
+	svc, linkRepo, clickRepo := setupLinkService()
+
+	linkRepo.Links["abc123"] = &models.Link{
+		ID:          1,
+		ShortCode:   "abc123",
+		OriginalURL: "https://example.com/original",
+	}
+
+	originalURL, err := svc.Redirect("abc123", &service.ClickInfo{IPAddress: "127.0.0.1", Source: "qr"})
+	if err != nil {
+		t.Fatalf("Redirect returned error: %v", err)
+	}
+	if originalURL != "https://example.com/original" {
+		t.Errorf("originalURL = %s, the source marker must not be passed on", originalURL)
+	}
+	clicks := waitForClicks(t, clickRepo, 1)
+	if clicks[0].Channel != models.ClickChannelQR {
+		t.Errorf("Channel = %q, want qr", clicks[0].Channel)
+	}
+
+	if _, err := svc.Redirect("abc123", &service.ClickInfo{IPAddress: "127.0.0.1", Source: "newsletter"}); err != nil {
+		t.Fatalf("Redirect returned error: %v", err)
+	}
+	clicks = waitForClicks(t, clickRepo, 2)
+	if clicks[1].Channel != models.ClickChannelDirect {
+		t.Errorf("Channel = %q, want direct for unknown sources", clicks[1].Channel)
+	}
+}
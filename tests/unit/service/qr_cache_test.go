@@ -0,0 +1,153 @@
+package service_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/tests/mocks"
+)
+
+func TestQRCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache, err := service.NewQRCache(10, "", 0)
+	if err != nil {
+		t.Fatalf("NewQRCache returned error: %v", err)
+	}
+
+	cache.Add("a", []byte("1234"))
+	cache.Add("b", []byte("1234"))
+	cache.Get("a") // b is now the least recently used
+	cache.Add("c", []byte("1234"))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Error("a should still be cached")
+	}
+	if _, ok := cache.Get("c"); !ok {
+		t.Error("c should be cached")
+	}
+
+	cache.Add("huge", make([]byte, 11))
+	if _, ok := cache.Get("huge"); ok {
+		t.Error("images larger than the cache should not be kept")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len = %d, want 2", cache.Len())
+	}
+}
+
+func TestQRCache_Disk(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := service.NewQRCache(1<<20, dir, 1<<20)
+	if err != nil {
+		t.Fatalf("NewQRCache returned error: %v", err)
+	}
+	first.Add("key", []byte("image"))
+
+	// A new cache on the same directory, e.g. after a restart
+	second, _ := service.NewQRCache(1<<20, dir, 1<<20)
+	data, ok := second.Get("key")
+	if !ok || string(data) != "image" {
+		t.Fatalf("Get from disk = %q, %v; want image, true", data, ok)
+	}
+	if second.Len() != 1 {
+		t.Errorf("disk hit should be loaded into memory, Len = %d", second.Len())
+	}
+}
+
+func TestQRCache_DiskEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+
+	cache, err := service.NewQRCache(0, dir, 10)
+	if err != nil {
+		t.Fatalf("NewQRCache returned error: %v", err)
+	}
+	cache.Add("a", []byte("1234"))
+	cache.Add("b", []byte("1234"))
+	cache.Get("a") // b is now the least recently used
+	cache.Add("c", []byte("1234"))
+	cache.Add("huge", make([]byte, 11))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("b should have been evicted from disk")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("%s should still be on disk", key)
+		}
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 2 || cache.DiskLen() != 2 {
+		t.Errorf("got %d files and DiskLen %d, want 2", len(files), cache.DiskLen())
+	}
+
+	// A smaller limit after a restart removes the files that no longer fit
+	smaller, err := service.NewQRCache(0, dir, 4)
+	if err != nil {
+		t.Fatalf("NewQRCache returned error: %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 || smaller.DiskLen() != 1 {
+		t.Errorf("got %d files and DiskLen %d after restart, want 1", len(files), smaller.DiskLen())
+	}
+}
+
+func TestQRCache_Nil(t *testing.T) {
+	var cache *service.QRCache
+	cache.Add("key", []byte("image"))
+	if _, ok := cache.Get("key"); ok {
+		t.Error("nil cache should never hit")
+	}
+}
+
+func TestQRService_Render_Cached(t *testing.T) {
+	cache, _ := service.NewQRCache(1<<20, "", 0)
+	repo := mocks.NewMockQRStyleRepository()
+	svc := service.NewQRService("assets/logo.png", repo, cache)
+
+	opts := service.QROptions{Format: "png", Size: 256, Margin: 4}
+	first, err := svc.Render("https://example.com/abc123", opts)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	second, _ := svc.Render("https://example.com/abc123", opts)
+	if !bytes.Equal(first.Data, second.Data) || cache.Len() != 1 {
+		t.Errorf("second render should be served from cache, Len = %d", cache.Len())
+	}
+
+	opts.Format = "svg"
+	if img, _ := svc.Render("https://example.com/abc123", opts); img.ContentType != "image/svg+xml" || cache.Len() != 2 {
+		t.Errorf("other formats should be cached separately, Len = %d", cache.Len())
+	}
+
+	if _, err := svc.GenerateQRCodeBase64("https://example.com/abc123"); err != nil {
+		t.Fatalf("GenerateQRCodeBase64 returned error: %v", err)
+	}
+	if cache.Len() != 3 {
+		t.Errorf("inline QR code should be cached, Len = %d", cache.Len())
+	}
+}
+
+func TestQRService_Render_CacheFollowsLogo(t *testing.T) {
+	cache, _ := service.NewQRCache(1<<20, "", 0)
+	repo := mocks.NewMockQRStyleRepository()
+	svc := service.NewQRService("assets/logo.png", repo, cache)
+
+	if err := svc.SetLogo(1, testLogoPNG(t, 32, 32)); err != nil {
+		t.Fatalf("SetLogo returned error: %v", err)
+	}
+	style, _ := svc.CreateStyle(1, service.QRStyleInput{Name: "Logo", WithLogo: true})
+	opts := service.QROptions{Format: "svg", Size: 256, Margin: 4, Style: style}
+
+	before, _ := svc.Render("https://example.com/abc123", opts)
+	if err := svc.SetLogo(1, testLogoPNG(t, 48, 16)); err != nil {
+		t.Fatalf("SetLogo returned error: %v", err)
+	}
+	after, _ := svc.Render("https://example.com/abc123", opts)
+
+	if bytes.Equal(before.Data, after.Data) {
+		t.Error("replacing the logo should not serve the cached image")
+	}
+}
@@ -0,0 +1,143 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image/png"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+)
+
+// renderExport renders items with PrepareQRExport and Write
+func renderExport(svc *service.QRService, output string, items []service.QRExportItem, opts service.QROptions) (*bytes.Buffer, error) {
+	export, err := svc.PrepareQRExport(output, items, opts)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	return &buf, export.Write(&buf)
+}
+
+func exportItems(n int) []service.QRExportItem {
+	items := make([]service.QRExportItem, n)
+	for i := range items {
+		code := fmt.Sprintf("code%02d", i)
+		items[i] = service.QRExportItem{ShortCode: code, ShortURL: "https://sho.rt/" + code}
+	}
+	return items
+}
+
+func TestQRService_ExportZIP(t *testing.T) {
+	svc := service.NewQRService("assets/logo.png", nil, nil)
+
+	buf, err := renderExport(svc, service.QRExportZIP, exportItems(3), service.QROptions{Size: 128, Margin: 2})
+	if err != nil {
+		t.Fatalf("renderExport returned error: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ExportZIP output is not a ZIP: %v", err)
+	}
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	if want := []string{"code00.png", "code01.png", "code02.png"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("files = %v, want %v", names, want)
+	}
+
+	file, err := archive.File[0].Open()
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer file.Close()
+	if _, err := png.Decode(file); err != nil {
+		t.Errorf("file is not a PNG: %v", err)
+	}
+}
+
+func TestQRService_ExportZIP_SVG(t *testing.T) {
+	svc := service.NewQRService("assets/logo.png", nil, nil)
+
+	buf, err := renderExport(svc, service.QRExportZIP, exportItems(1), service.QROptions{Format: "svg", Size: 128})
+	if err != nil {
+		t.Fatalf("renderExport returned error: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ExportZIP output is not a ZIP: %v", err)
+	}
+	if archive.File[0].Name != "code00.svg" {
+		t.Errorf("file = %s, want code00.svg", archive.File[0].Name)
+	}
+}
+
+func TestQRService_ExportZIP_InvalidOptions(t *testing.T) {
+	svc := service.NewQRService("assets/logo.png", nil, nil)
+
+	_, err := svc.PrepareQRExport(service.QRExportZIP, exportItems(1), service.QROptions{Format: "gif", Size: 128})
+	if err != service.ErrInvalidQROptions {
+		t.Errorf("Expected ErrInvalidQROptions, got %v", err)
+	}
+}
+
+func TestQRService_ExportPDF(t *testing.T) {
+	svc := service.NewQRService("assets/logo.png", nil, nil)
+
+	// 20 codes fit on a page
+	buf, err := renderExport(svc, service.QRExportPDF, exportItems(21), service.QROptions{Format: "svg", Size: 128, Margin: 2})
+	if err != nil {
+		t.Fatalf("renderExport returned error: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("ExportPDF output is not a PDF: %.20q", buf.Bytes())
+	}
+	pages := regexp.MustCompile(`/Type /Page\b[^s]`).FindAll(buf.Bytes(), -1)
+	if len(pages) != 2 {
+		t.Errorf("pages = %d, want 2", len(pages))
+	}
+}
+
+func TestQRService_Export_BypassesCache(t *testing.T) {
+	cache, _ := service.NewQRCache(1<<20, t.TempDir(), 1<<20)
+	svc := service.NewQRService("assets/logo.png", nil, cache)
+
+	for _, output := range []string{service.QRExportZIP, service.QRExportPDF} {
+		if _, err := renderExport(svc, output, exportItems(3), service.QROptions{Size: 128}); err != nil {
+			t.Fatalf("renderExport(%s) returned error: %v", output, err)
+		}
+	}
+	if cache.Len() != 0 || cache.DiskLen() != 0 {
+		t.Errorf("exports should not be cached, Len = %d, DiskLen = %d", cache.Len(), cache.DiskLen())
+	}
+}
+
+func TestLinkService_GetOwnedLinks(t *testing.T) {
+	svc, linkRepo, _ := setupLinkService()
+
+	owner, other := uint(1), uint(2)
+	linkRepo.Links["aaa"] = &models.Link{ID: 1, ShortCode: "aaa", UserID: &owner}
+	linkRepo.Links["bbb"] = &models.Link{ID: 2, ShortCode: "bbb", UserID: &owner}
+	linkRepo.Links["ccc"] = &models.Link{ID: 3, ShortCode: "ccc", UserID: &other}
+
+	links, missing, err := svc.GetOwnedLinks("", []string{"bbb", "aaa", "ccc", "bbb", "zzz"}, owner)
+	if err != nil {
+		t.Fatalf("GetOwnedLinks returned error: %v", err)
+	}
+	var codes []string
+	for _, link := range links {
+		codes = append(codes, link.ShortCode)
+	}
+	if want := []string{"bbb", "aaa"}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("links = %v, want %v", codes, want)
+	}
+	if want := []string{"ccc", "zzz"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+}
@@ -1,14 +1,21 @@
 package service_test
 
 import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
 	"strings"
 	"testing"
 
+	"quocbui.dev/m/internal/models"
 	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/pkg/utils"
+	"quocbui.dev/m/tests/mocks"
 )
 
 func TestQRService_GenerateQRCodeBase64_Success(t *testing.T) {
-	svc := service.NewQRService("assets/logo.png")
+	svc := service.NewQRService("assets/logo.png", nil, nil)
 
 	url := "https://example.com/abc123"
 	qrCode, err := svc.GenerateQRCodeBase64(url)
@@ -33,7 +40,7 @@ func TestQRService_GenerateQRCodeBase64_Success(t *testing.T) {
 }
 
 func TestQRService_GenerateQRCodeBase64_EmptyURL(t *testing.T) {
-	svc := service.NewQRService("assets/logo.png")
+	svc := service.NewQRService("assets/logo.png", nil, nil)
 
 	qrCode, err := svc.GenerateQRCodeBase64("")
 	if err != nil {
@@ -47,7 +54,7 @@ func TestQRService_GenerateQRCodeBase64_EmptyURL(t *testing.T) {
 }
 
 func TestQRService_GenerateQRCodeBase64_LongURL(t *testing.T) {
-	svc := service.NewQRService("assets/logo.png")
+	svc := service.NewQRService("assets/logo.png", nil, nil)
 
 	// Generate a very long URL
 	longURL := "https://example.com/" + strings.Repeat("a", 1000)
@@ -62,7 +69,7 @@ func TestQRService_GenerateQRCodeBase64_LongURL(t *testing.T) {
 }
 
 func TestQRService_GenerateQRCodeBase64_SpecialCharacters(t *testing.T) {
-	svc := service.NewQRService("assets/logo.png")
+	svc := service.NewQRService("assets/logo.png", nil, nil)
 
 	url := "https://example.com/test?param=value&foo=bar#section"
 	qrCode, err := svc.GenerateQRCodeBase64(url)
@@ -77,7 +84,7 @@ func TestQRService_GenerateQRCodeBase64_SpecialCharacters(t *testing.T) {
 
 func TestQRService_GenerateQRCodeBase64_WithoutLogo(t *testing.T) {
 	// Test with non-existent logo path
-	svc := service.NewQRService("non-existent-logo.png")
+	svc := service.NewQRService("non-existent-logo.png", nil, nil)
 
 	url := "https://example.com/abc123"
 	qrCode, err := svc.GenerateQRCodeBase64(url)
@@ -91,3 +98,276 @@ func TestQRService_GenerateQRCodeBase64_WithoutLogo(t *testing.T) {
 		t.Error("GenerateQRCodeBase64 should work without logo")
 	}
 }
+
+func TestQRService_Render_PNG(t *testing.T) {
+	svc := service.NewQRService("assets/logo.png", nil, nil)
+
+	img, err := svc.Render("https://example.com/abc123", service.QROptions{Format: "png", Size: 300, Margin: 4})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if img.ContentType != "image/png" {
+		t.Errorf("ContentType = %s, want image/png", img.ContentType)
+	}
+
+	decoded, err := png.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		t.Fatalf("Render output is not a PNG: %v", err)
+	}
+	if bounds := decoded.Bounds(); bounds.Dx() != 300 || bounds.Dy() != 300 {
+		t.Errorf("PNG size = %dx%d, want 300x300", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestQRService_Render_SVG(t *testing.T) {
+	svc := service.NewQRService("assets/logo.png", nil, nil)
+
+	img, err := svc.Render("https://example.com/abc123", service.QROptions{Format: "svg", Size: 128, Margin: 0})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if img.ContentType != "image/svg+xml" {
+		t.Errorf("ContentType = %s, want image/svg+xml", img.ContentType)
+	}
+	svg := string(img.Data)
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, `width="128"`) {
+		t.Errorf("unexpected SVG output: %.100s", svg)
+	}
+}
+
+func TestQRService_Render_InvalidOptions(t *testing.T) {
+	svc := service.NewQRService("assets/logo.png", nil, nil)
+
+	tests := []service.QROptions{
+		{Format: "gif", Size: 256, Margin: 4},
+		{Format: "png", Size: 10, Margin: 4},
+		{Format: "png", Size: 5000, Margin: 4},
+		{Format: "svg", Size: 256, Margin: -1},
+		{Format: "svg", Size: 256, Margin: 100},
+	}
+	for _, opts := range tests {
+		if _, err := svc.Render("https://example.com", opts); err != service.ErrInvalidQROptions {
+			t.Errorf("Render(%+v) error = %v, want ErrInvalidQROptions", opts, err)
+		}
+	}
+}
+
+func setupQRStyleService() (*service.QRService, *mocks.MockQRStyleRepository) {
+	repo := mocks.NewMockQRStyleRepository()
+	return service.NewQRService("assets/logo.png", repo, nil), repo
+}
+
+func testLogoPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 0xe5, G: 0x39, B: 0x35, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode test logo: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestQRService_CreateStyle_Defaults(t *testing.T) {
+	svc, _ := setupQRStyleService()
+
+	style, err := svc.CreateStyle(1, service.QRStyleInput{Name: " Plain "})
+	if err != nil {
+		t.Fatalf("CreateStyle returned error: %v", err)
+	}
+	if style.Name != "Plain" || style.Foreground != "#000000" || style.Background != "#ffffff" ||
+		style.Shape != models.QRShapeSquare || style.ErrorCorrection != "M" {
+		t.Errorf("unexpected defaults: %+v", style)
+	}
+
+	withLogo, err := svc.CreateStyle(1, service.QRStyleInput{Name: "Logo", WithLogo: true})
+	if err != nil {
+		t.Fatalf("CreateStyle returned error: %v", err)
+	}
+	if withLogo.ErrorCorrection != "H" {
+		t.Errorf("ErrorCorrection = %s, want H for a style with logo", withLogo.ErrorCorrection)
+	}
+}
+
+func TestQRService_CreateStyle_Validation(t *testing.T) {
+	svc, _ := setupQRStyleService()
+
+	tests := []struct {
+		name  string
+		input service.QRStyleInput
+		want  error
+	}{
+		{"missing name", service.QRStyleInput{}, service.ErrInvalidQRStyle},
+		{"bad color", service.QRStyleInput{Name: "x", Foreground: "red"}, service.ErrInvalidQRStyle},
+		{"bad shape", service.QRStyleInput{Name: "x", Shape: "star"}, service.ErrInvalidQRStyle},
+		{"bad level", service.QRStyleInput{Name: "x", ErrorCorrection: "X"}, service.ErrInvalidQRStyle},
+		{"logo needs Q or H", service.QRStyleInput{Name: "x", WithLogo: true, ErrorCorrection: "M"}, service.ErrInvalidQRStyle},
+		{"low contrast", service.QRStyleInput{Name: "x", Foreground: "#777777", Background: "#999999"}, service.ErrLowQRContrast},
+		{"inverted", service.QRStyleInput{Name: "x", Foreground: "#ffffff", Background: "#000000"}, service.ErrLowQRContrast},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.CreateStyle(1, tt.input); err != tt.want {
+				t.Errorf("CreateStyle error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	style, err := svc.CreateStyle(1, service.QRStyleInput{Name: "Navy", Foreground: "#1A237E", Background: "#FFF8E1", Shape: "circle", ErrorCorrection: "q"})
+	if err != nil {
+		t.Fatalf("CreateStyle returned error: %v", err)
+	}
+	if style.Foreground != "#1a237e" || style.ErrorCorrection != "Q" {
+		t.Errorf("style not normalized: %+v", style)
+	}
+}
+
+func TestQRService_DefaultStyle(t *testing.T) {
+	svc, _ := setupQRStyleService()
+
+	if style, err := svc.ResolveStyle(1, 0); err != nil || style != nil {
+		t.Fatalf("ResolveStyle without styles = %v, %v; want nil, nil", style, err)
+	}
+
+	first, _ := svc.CreateStyle(1, service.QRStyleInput{Name: "First", IsDefault: true})
+	second, _ := svc.CreateStyle(1, service.QRStyleInput{Name: "Second", IsDefault: true})
+	if first.IsDefault {
+		t.Error("first style should no longer be the default")
+	}
+
+	style, err := svc.ResolveStyle(1, 0)
+	if err != nil || style == nil || style.ID != second.ID {
+		t.Errorf("ResolveStyle = %v, %v; want style %d", style, err, second.ID)
+	}
+}
+
+func TestQRService_StyleOwnership(t *testing.T) {
+	svc, _ := setupQRStyleService()
+
+	style, _ := svc.CreateStyle(1, service.QRStyleInput{Name: "Mine"})
+
+	if _, err := svc.ResolveStyle(2, style.ID); err != service.ErrUnauthorized {
+		t.Errorf("ResolveStyle by other user error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := svc.UpdateStyle(2, style.ID, service.QRStyleInput{Name: "Theirs"}); err != service.ErrUnauthorized {
+		t.Errorf("UpdateStyle by other user error = %v, want ErrUnauthorized", err)
+	}
+	if err := svc.DeleteStyle(1, 999); err != service.ErrQRStyleNotFound {
+		t.Errorf("DeleteStyle missing error = %v, want ErrQRStyleNotFound", err)
+	}
+	if err := svc.DeleteStyle(1, style.ID); err != nil {
+		t.Errorf("DeleteStyle returned error: %v", err)
+	}
+}
+
+func TestQRService_SetLogo(t *testing.T) {
+	svc, repo := setupQRStyleService()
+
+	if err := svc.SetLogo(1, []byte("not an image")); err != service.ErrInvalidLogo {
+		t.Errorf("SetLogo garbage error = %v, want ErrInvalidLogo", err)
+	}
+	if err := svc.SetLogo(1, testLogoPNG(t, service.MaxQRLogoPixels+1, 8)); err != service.ErrInvalidLogo {
+		t.Errorf("SetLogo oversized error = %v, want ErrInvalidLogo", err)
+	}
+	if _, err := svc.GetLogo(1); err != service.ErrLogoNotFound {
+		t.Errorf("GetLogo without logo error = %v, want ErrLogoNotFound", err)
+	}
+
+	if err := svc.SetLogo(1, testLogoPNG(t, 64, 32)); err != nil {
+		t.Fatalf("SetLogo returned error: %v", err)
+	}
+	if repo.Logos[1] == nil {
+		t.Fatal("logo was not stored")
+	}
+	if _, err := svc.GetLogo(1); err != nil {
+		t.Errorf("GetLogo returned error: %v", err)
+	}
+}
+
+func TestQRService_Render_WithStyle(t *testing.T) {
+	svc, _ := setupQRStyleService()
+
+	if err := svc.SetLogo(1, testLogoPNG(t, 64, 64)); err != nil {
+		t.Fatalf("SetLogo returned error: %v", err)
+	}
+	style, err := svc.CreateStyle(1, service.QRStyleInput{
+		Name: "Brand", Foreground: "#1a237e", Background: "#fff8e1", Shape: "circle", WithLogo: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateStyle returned error: %v", err)
+	}
+
+	img, err := svc.Render("https://example.com/abc123", service.QROptions{Format: "png", Size: 300, Margin: 4, Style: style})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		t.Fatalf("Render output is not a PNG: %v", err)
+	}
+	if bounds := decoded.Bounds(); bounds.Dx() != 300 || bounds.Dy() != 300 {
+		t.Errorf("PNG size = %dx%d, want 300x300", bounds.Dx(), bounds.Dy())
+	}
+	if r, g, b, _ := decoded.At(0, 0).RGBA(); r>>8 != 0xff || g>>8 != 0xf8 || b>>8 != 0xe1 {
+		t.Errorf("corner pixel = %02x%02x%02x, want background fff8e1", r>>8, g>>8, b>>8)
+	}
+
+	svg, err := svc.Render("https://example.com/abc123", service.QROptions{Format: "svg", Size: 300, Margin: 4, Style: style})
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	out := string(svg.Data)
+	if !strings.Contains(out, `fill="#1a237e"`) || !strings.Contains(out, "<image ") {
+		t.Errorf("styled SVG misses colors or logo: %.200s", out)
+	}
+}
+
+func TestQRScanURL(t *testing.T) {
+	if got := service.QRScanURL("https://sho.rt/abc123"); got != "https://sho.rt/abc123?src=qr" {
+		t.Errorf("QRScanURL = %s, want https://sho.rt/abc123?src=qr", got)
+	}
+}
+
+func TestQRService_RenderPayload(t *testing.T) {
+	svc := service.NewQRService("assets/logo.png", nil, nil)
+	opts := service.QROptions{Format: "svg", Size: 256, Margin: 4}
+
+	tests := []struct {
+		name    string
+		payload service.QRPayload
+		wantErr bool
+	}{
+		{"wifi", service.QRPayload{Type: "wifi", WiFi: &utils.WiFiPayload{SSID: "Office", Password: "secret123"}}, false},
+		{"open wifi", service.QRPayload{Type: "wifi", WiFi: &utils.WiFiPayload{SSID: "Guest", Security: "nopass"}}, false},
+		{"short wpa password", service.QRPayload{Type: "wifi", WiFi: &utils.WiFiPayload{SSID: "Office", Password: "short"}}, true},
+		{"unknown security", service.QRPayload{Type: "wifi", WiFi: &utils.WiFiPayload{SSID: "Office", Password: "secret123", Security: "WPA3"}}, true},
+		{"long ssid", service.QRPayload{Type: "wifi", WiFi: &utils.WiFiPayload{SSID: strings.Repeat("s", 33), Security: "nopass"}}, true},
+		{"email", service.QRPayload{Type: "email", Email: &utils.EmailPayload{To: "a@example.com", Subject: "Hi"}}, false},
+		{"bad email", service.QRPayload{Type: "email", Email: &utils.EmailPayload{To: "nobody"}}, true},
+		{"sms", service.QRPayload{Type: "sms", SMS: &utils.SMSPayload{Phone: "+84 90 123 4567", Message: "JOIN"}}, false},
+		{"bad phone", service.QRPayload{Type: "sms", SMS: &utils.SMSPayload{Phone: "abc"}}, true},
+		{"missing object", service.QRPayload{Type: "sms", Email: &utils.EmailPayload{To: "a@example.com"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := svc.RenderPayload(tt.payload, opts)
+			if tt.wantErr {
+				if err != service.ErrInvalidQRPayload {
+					t.Errorf("Expected ErrInvalidQRPayload, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderPayload returned error: %v", err)
+			}
+			if img.ContentType != "image/svg+xml" || len(img.Data) == 0 {
+				t.Errorf("unexpected image %s (%d bytes)", img.ContentType, len(img.Data))
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package service_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/tests/mocks"
+)
+
+func writeReputationFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "reputation.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write reputation file: %v", err)
+	}
+	return path
+}
+
+// countingChecker counts lookups and returns a fixed verdict
+type countingChecker struct {
+	verdict *service.ReputationVerdict
+	calls   int
+}
+
+func (c *countingChecker) Check(rawURL string) (*service.ReputationVerdict, error) {
+	c.calls++
+	return c.verdict, nil
+}
+
+func TestFileReputationChecker_Check(t *testing.T) {
+	path := writeReputationFile(t, `# test list
+malicious evil.example MALWARE
+suspicious sketchy.example
+safe good.sketchy.example
+`)
+	checker, err := service.NewFileReputationChecker(path)
+	if err != nil {
+		t.Fatalf("NewFileReputationChecker returned error: %v", err)
+	}
+
+	tests := []struct {
+		url     string
+		verdict string
+	}{
+		{"https://evil.example/login", service.VerdictMalicious},
+		{"https://cdn.evil.example/x.exe", service.VerdictMalicious},
+		{"https://sketchy.example", service.VerdictSuspicious},
+		{"https://good.sketchy.example", service.VerdictSafe},
+		{"https://github.com", service.VerdictSafe},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			verdict, err := checker.Check(tt.url)
+			if err != nil {
+				t.Fatalf("Check returned error: %v", err)
+			}
+			if verdict.Verdict != tt.verdict {
+				t.Errorf("Check(%q) = %s, want %s", tt.url, verdict.Verdict, tt.verdict)
+			}
+		})
+	}
+}
+
+func TestFileReputationChecker_InvalidVerdict(t *testing.T) {
+	path := writeReputationFile(t, "dangerous evil.example\n")
+	if _, err := service.NewFileReputationChecker(path); err == nil {
+		t.Error("Expected error for unknown verdict")
+	}
+}
+
+func TestHTTPReputationChecker_Check(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			URL string `json:"url"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.URL == "https://phish.example" {
+			json.NewEncoder(w).Encode(service.ReputationVerdict{Verdict: service.VerdictMalicious, ThreatType: "SOCIAL_ENGINEERING"})
+			return
+		}
+		json.NewEncoder(w).Encode(service.ReputationVerdict{Verdict: service.VerdictSafe})
+	}))
+	defer server.Close()
+
+	checker := service.NewHTTPReputationChecker(server.URL, "test-key")
+
+	verdict, err := checker.Check("https://phish.example")
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if !verdict.IsMalicious() || verdict.ThreatType != "SOCIAL_ENGINEERING" {
+		t.Errorf("Check = %+v, want malicious SOCIAL_ENGINEERING", verdict)
+	}
+
+	unauthorized := service.NewHTTPReputationChecker(server.URL, "")
+	if _, err := unauthorized.Check("https://phish.example"); err == nil {
+		t.Error("Expected error for non-200 response")
+	}
+}
+
+func TestCachedReputationChecker_CachesVerdicts(t *testing.T) {
+	inner := &countingChecker{verdict: &service.ReputationVerdict{Verdict: service.VerdictSafe}}
+	checker := service.NewCachedReputationChecker(inner, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if _, err := checker.Check("https://example.com"); err != nil {
+			t.Fatalf("Check returned error: %v", err)
+		}
+	}
+
+	if inner.calls != 1 {
+		t.Errorf("inner checker called %d times, want 1", inner.calls)
+	}
+}
+
+func TestCachedReputationChecker_EvictsLeastRecentlyUsed(t *testing.T) {
+	inner := &countingChecker{verdict: &service.ReputationVerdict{Verdict: service.VerdictSafe}}
+	checker := service.NewCachedReputationChecker(inner, time.Hour)
+
+	check := func(rawURL string) {
+		t.Helper()
+		if _, err := checker.Check(rawURL); err != nil {
+			t.Fatalf("Check returned error: %v", err)
+		}
+	}
+	for i := 0; i < service.MaxCachedVerdicts; i++ {
+		check(fmt.Sprintf("https://example.com/%d", i))
+	}
+	check("https://example.com/0") // keeps the first URL recently used
+	check("https://example.com/new")
+
+	if got := checker.Len(); got != service.MaxCachedVerdicts {
+		t.Errorf("Len = %d, want %d", got, service.MaxCachedVerdicts)
+	}
+	calls := inner.calls
+	check("https://example.com/0")
+	if inner.calls != calls {
+		t.Error("recently used verdict was evicted")
+	}
+	check("https://example.com/1")
+	if inner.calls != calls+1 {
+		t.Error("least recently used verdict was not evicted")
+	}
+}
+
+func TestLinkService_CreateLink_ReputationVerdicts(t *testing.T) {
+	path := writeReputationFile(t, "malicious evil.example\nsuspicious sketchy.example\n")
+	checker, err := service.NewFileReputationChecker(path)
+	if err != nil {
+		t.Fatalf("NewFileReputationChecker returned error: %v", err)
+	}
+
+	linkRepo := mocks.NewMockLinkRepository()
+	authService := service.NewAuthService(mocks.NewMockUserRepository(), "test-secret", 24)
+	svc := service.NewLinkService(linkRepo, mocks.NewMockClickRepository(), mocks.NewMockTransactionManager(),
+		service.NewGeoIPService(), authService, checker, service.LinkServiceConfig{})
+
+	if _, err := svc.CreateLink("https://evil.example/login", nil, nil, nil, 6); err != service.ErrURLBlocked {
+		t.Errorf("CreateLink(malicious) error = %v, want ErrURLBlocked", err)
+	}
+
+	link, err := svc.CreateLink("https://sketchy.example", nil, nil, nil, 6)
+	if err != nil {
+		t.Fatalf("CreateLink(suspicious) returned error: %v", err)
+	}
+	if !link.Suspicious {
+		t.Error("link.Suspicious should be set for suspicious destinations")
+	}
+}
+
+func TestLinkService_Redirect_SuspiciousRequiresConfirmation(t *testing.T) {
+	svc, linkRepo, _ := setupLinkService()
+
+	linkRepo.Links["warn"] = &models.Link{
+		ID:          1,
+		ShortCode:   "warn",
+		OriginalURL: "https://sketchy.example",
+		Suspicious:  true,
+	}
+
+	originalURL, err := svc.Redirect("warn", &service.ClickInfo{IPAddress: "127.0.0.1"})
+	if err != service.ErrLinkSuspicious {
+		t.Fatalf("Redirect error = %v, want ErrLinkSuspicious", err)
+	}
+	if originalURL != "https://sketchy.example" {
+		t.Errorf("originalURL = %s, want https://sketchy.example", originalURL)
+	}
+
+	_, err = svc.Redirect("warn", &service.ClickInfo{IPAddress: "127.0.0.1", WarningAccepted: true})
+	if err != nil {
+		t.Errorf("Redirect with accepted warning returned error: %v", err)
+	}
+}
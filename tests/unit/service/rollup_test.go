@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/tests/mocks"
+)
+
+func TestAnalyticsService_GetAnalyticsSummary_PartialDays(t *testing.T) {
+	svc, clickRepo, linkRepo := setupAnalyticsService()
+
+	userID := uint(1)
+	linkRepo.Links["test"] = &models.Link{ID: 1, ShortCode: "test", UserID: &userID}
+	// Dates in Ho Chi Minh City start at 17:00 UTC the day before, so both ends
+	// of the range are partial UTC days
+	for i, at := range []string{
+		"2025-03-09T16:59:59Z", // before the range
+		"2025-03-09T17:00:00Z",
+		"2025-03-10T05:00:00Z",
+		"2025-03-11T23:59:59Z",
+		"2025-03-12T16:59:59Z",
+		"2025-03-12T17:00:00Z", // after the range
+	} {
+		clickedAt, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clickRepo.Clicks = append(clickRepo.Clicks, &models.Click{ID: uint(i + 1), LinkID: 1, Country: "Vietnam", ClickedAt: clickedAt})
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     int64
+	}{
+		{"partial days at both ends", "2025-03-10", "2025-03-12", 4},
+		{"within one day", "2025-03-12T00:00:00+07:00", "2025-03-12T23:00:00+07:00", 1},
+		{"open start", "", "2025-03-10", 3},
+		{"open end", "2025-03-12", "", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := service.ParseTimeRange(tt.from, tt.to, "Asia/Ho_Chi_Minh")
+			if err != nil {
+				t.Fatalf("ParseTimeRange returned error: %v", err)
+			}
+			summary, err := svc.GetAnalyticsSummary(1, userID, r, false)
+			if err != nil {
+				t.Fatalf("GetAnalyticsSummary returned error: %v", err)
+			}
+			if summary.TotalClicks != tt.want || summary.Countries["Vietnam"] != tt.want {
+				t.Errorf("TotalClicks = %d, Countries = %v, want %d", summary.TotalClicks, summary.Countries, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkService_Redirect_UpdatesRollups(t *testing.T) {
+	linkRepo := mocks.NewMockLinkRepository()
+	clickRepo := mocks.NewMockClickRepository()
+	rollups := mocks.NewMockClickRollupRepository(clickRepo)
+	authService := service.NewAuthService(mocks.NewMockUserRepository(), "test-secret", 24)
+	svc := service.NewLinkService(linkRepo, clickRepo, mocks.NewMockTransactionManager(), service.NewGeoIPService(), authService, nil,
+		service.LinkServiceConfig{Rollups: rollups})
+
+	linkRepo.Links["abc123"] = &models.Link{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com"}
+	for _, ua := range []string{"Mozilla/5.0", slackbotUA} {
+		if _, err := svc.Redirect("abc123", &service.ClickInfo{IPAddress: "203.0.113.7", UserAgent: ua}); err != nil {
+			t.Fatalf("Redirect returned error: %v", err)
+		}
+	}
+
+	// Bot clicks are rolled up too, for the bot traffic report
+	deadline := time.Now().Add(2 * time.Second)
+	for len(rollups.Incremented()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("rolled up %d clicks, want 2", len(rollups.Incremented()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAnalyticsService_RebuildRollups(t *testing.T) {
+	clickRepo := mocks.NewMockClickRepository()
+	rollups := mocks.NewMockClickRollupRepository(clickRepo)
+	svc := service.NewAnalyticsService(clickRepo, rollups, mocks.NewMockLinkRepository())
+
+	days, err := svc.RebuildRollups(time.Time{})
+	if err != nil || days != 0 {
+		t.Fatalf("RebuildRollups without clicks = %d, %v, want 0 days", days, err)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	clickRepo.Clicks = append(clickRepo.Clicks,
+		&models.Click{ID: 1, LinkID: 1, ClickedAt: today.AddDate(0, 0, -2).Add(20 * time.Hour)},
+		&models.Click{ID: 2, LinkID: 1, ClickedAt: today.Add(time.Minute)},
+	)
+	days, err = svc.RebuildRollups(time.Time{})
+	if err != nil {
+		t.Fatalf("RebuildRollups returned error: %v", err)
+	}
+	if days != 3 || len(rollups.Rebuilt) != 3 || !rollups.Rebuilt[0].Equal(today.AddDate(0, 0, -2)) || !rollups.Rebuilt[2].Equal(today) {
+		t.Errorf("rebuilt %d days %v, want the 3 days from the first click through today", days, rollups.Rebuilt)
+	}
+
+	rollups.Rebuilt = nil
+	days, err = svc.RebuildRollups(today.AddDate(0, 0, -1))
+	if err != nil || days != 2 || len(rollups.Rebuilt) != 2 {
+		t.Errorf("RebuildRollups since yesterday = %d days %v, %v, want 2", days, rollups.Rebuilt, err)
+	}
+}
@@ -0,0 +1,168 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/tests/mocks"
+)
+
+type transferFixture struct {
+	svc          *service.TransferService
+	linkRepo     *mocks.MockLinkRepository
+	transferRepo *mocks.MockLinkTransferRepository
+	domainRepo   *mocks.MockDomainRepository
+	alice, bob   *models.User
+}
+
+func setupTransferService() *transferFixture {
+	linkRepo := mocks.NewMockLinkRepository()
+	userRepo := mocks.NewMockUserRepository()
+	txManager := mocks.NewMockTransactionManager()
+	authService := service.NewAuthService(userRepo, "test-secret", 24)
+	domainSvc, domainRepo := setupDomainService(nil)
+	linkService := service.NewLinkService(linkRepo, mocks.NewMockClickRepository(), txManager,
+		service.NewGeoIPService(), authService, nil, service.LinkServiceConfig{CustomDomains: domainSvc})
+	transferRepo := mocks.NewMockLinkTransferRepository()
+
+	alice := &models.User{Email: "alice@example.com"}
+	bob := &models.User{Email: "bob@example.com"}
+	userRepo.Create(alice)
+	userRepo.Create(bob)
+	userRepo.Create(&models.User{Email: "guest_1@temp.local"})
+
+	for i, code := range []string{"link1", "link2"} {
+		linkRepo.Links[code] = &models.Link{ID: uint(i + 1), ShortCode: code, UserID: &alice.ID}
+	}
+
+	return &transferFixture{
+		svc:          service.NewTransferService(transferRepo, linkRepo, userRepo, txManager, linkService),
+		linkRepo:     linkRepo,
+		transferRepo: transferRepo,
+		domainRepo:   domainRepo,
+		alice:        alice,
+		bob:          bob,
+	}
+}
+
+func TestTransferService_CreateTransfer_Validation(t *testing.T) {
+	f := setupTransferService()
+	refs := []service.LinkRef{{ShortCode: "link1"}}
+
+	tests := []struct {
+		name  string
+		email string
+		refs  []service.LinkRef
+		want  error
+	}{
+		{"unknown recipient", "nobody@example.com", refs, service.ErrRecipientNotFound},
+		{"guest recipient", "guest_1@temp.local", refs, service.ErrRecipientNotFound},
+		{"self", "alice@example.com", refs, service.ErrInvalidTransfer},
+		{"no links", "bob@example.com", nil, service.ErrInvalidTransfer},
+		{"missing link", "bob@example.com", []service.LinkRef{{ShortCode: "nope"}}, service.ErrLinkNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := f.svc.CreateTransfer(f.alice.ID, tt.email, tt.refs); err != tt.want {
+				t.Errorf("CreateTransfer error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := f.svc.CreateTransfer(f.bob.ID, "alice@example.com", refs); err != service.ErrUnauthorized {
+		t.Errorf("CreateTransfer of someone else's link error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestTransferService_AcceptTransfer(t *testing.T) {
+	f := setupTransferService()
+
+	transfer, err := f.svc.CreateTransfer(f.alice.ID, " bob@example.com", []service.LinkRef{
+		{ShortCode: "link1"}, {ShortCode: "link2"}, {ShortCode: "link1"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTransfer returned error: %v", err)
+	}
+	if len(transfer.Links) != 2 {
+		t.Errorf("Expected duplicate links to be merged, got %d links", len(transfer.Links))
+	}
+
+	if *f.linkRepo.Links["link1"].UserID != f.alice.ID {
+		t.Error("Links should not move before the transfer is accepted")
+	}
+
+	if _, err := f.svc.AcceptTransfer(f.alice.ID, transfer.ID); err != service.ErrUnauthorized {
+		t.Errorf("AcceptTransfer by sender error = %v, want ErrUnauthorized", err)
+	}
+
+	accepted, err := f.svc.AcceptTransfer(f.bob.ID, transfer.ID)
+	if err != nil {
+		t.Fatalf("AcceptTransfer returned error: %v", err)
+	}
+	if accepted.Status != models.TransferAccepted || accepted.RespondedAt == nil {
+		t.Errorf("Transfer status = %s, want accepted", accepted.Status)
+	}
+	for _, code := range []string{"link1", "link2"} {
+		if *f.linkRepo.Links[code].UserID != f.bob.ID {
+			t.Errorf("Link %s should belong to the recipient", code)
+		}
+	}
+
+	if _, err := f.svc.AcceptTransfer(f.bob.ID, transfer.ID); err != service.ErrTransferNotPending {
+		t.Errorf("Second AcceptTransfer error = %v, want ErrTransferNotPending", err)
+	}
+}
+
+func TestTransferService_DeclineAndCancel(t *testing.T) {
+	f := setupTransferService()
+	refs := []service.LinkRef{{ShortCode: "link1"}}
+
+	declined, _ := f.svc.CreateTransfer(f.alice.ID, "bob@example.com", refs)
+	if _, err := f.svc.DeclineTransfer(f.alice.ID, declined.ID); err != service.ErrUnauthorized {
+		t.Errorf("DeclineTransfer by sender error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := f.svc.DeclineTransfer(f.bob.ID, declined.ID); err != nil {
+		t.Fatalf("DeclineTransfer returned error: %v", err)
+	}
+
+	canceled, _ := f.svc.CreateTransfer(f.alice.ID, "bob@example.com", refs)
+	if _, err := f.svc.CancelTransfer(f.bob.ID, canceled.ID); err != service.ErrUnauthorized {
+		t.Errorf("CancelTransfer by recipient error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := f.svc.CancelTransfer(f.alice.ID, canceled.ID); err != nil {
+		t.Fatalf("CancelTransfer returned error: %v", err)
+	}
+	if _, err := f.svc.AcceptTransfer(f.bob.ID, canceled.ID); err != service.ErrTransferNotPending {
+		t.Errorf("AcceptTransfer after cancel error = %v, want ErrTransferNotPending", err)
+	}
+
+	if *f.linkRepo.Links["link1"].UserID != f.alice.ID {
+		t.Error("Declined and canceled transfers should not move links")
+	}
+
+	pending, _ := f.svc.ListTransfers(f.bob.ID)
+	if len(pending) != 0 {
+		t.Errorf("Expected no pending transfers, got %d", len(pending))
+	}
+}
+
+func TestTransferService_BrandedDomainLinks(t *testing.T) {
+	f := setupTransferService()
+	now := time.Now()
+	domain := &models.Domain{ID: 1, UserID: f.alice.ID, Host: "go.example.com", VerifiedAt: &now}
+	f.domainRepo.Domains[domain.ID] = domain
+	f.linkRepo.Links["1/promo"] = &models.Link{ID: 3, ShortCode: "promo", UserID: &f.alice.ID, DomainID: &domain.ID, Domain: domain}
+
+	// bob doesn't own go.example.com, so he couldn't manage the link
+	_, err := f.svc.CreateTransfer(f.alice.ID, "bob@example.com", []service.LinkRef{
+		{ShortCode: "link1"}, {Domain: "go.example.com", ShortCode: "promo"},
+	})
+	if err != service.ErrTransferDomain {
+		t.Errorf("CreateTransfer(branded link) error = %v, want ErrTransferDomain", err)
+	}
+	if len(f.transferRepo.Transfers) != 0 {
+		t.Error("No transfer should be created")
+	}
+}
@@ -0,0 +1,140 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+)
+
+func testVCard() *models.VCard {
+	return &models.VCard{
+		FirstName: " Quoc ",
+		LastName:  "Bui",
+		Phone:     "+84 90 123 4567",
+		Email:     "quoc@example.com",
+		Website:   "https://quocbui.dev",
+	}
+}
+
+func TestLinkService_CreateVCardLink(t *testing.T) {
+	svc, linkRepo, _ := setupLinkService()
+
+	link, err := svc.CreateVCardLink("", testVCard(), nil, 1, nil, 6)
+	if err != nil {
+		t.Fatalf("CreateVCardLink returned error: %v", err)
+	}
+	if link.Type != models.LinkTypeVCard || link.OriginalURL != "" {
+		t.Errorf("link = %s %q, want a vcard link without URL", link.Type, link.OriginalURL)
+	}
+
+	card := linkRepo.VCards[link.ID]
+	if card == nil {
+		t.Fatal("card was not stored")
+	}
+	if card.FirstName != "Quoc" || card.Phone != "+84901234567" {
+		t.Errorf("card was not normalized: %q %q", card.FirstName, card.Phone)
+	}
+}
+
+func TestLinkService_CreateVCardLink_Invalid(t *testing.T) {
+	svc, _, _ := setupLinkService()
+
+	tests := []struct {
+		name string
+		card *models.VCard
+	}{
+		{"no name", &models.VCard{Title: "Engineer", Phone: "123456"}},
+		{"bad phone", &models.VCard{FirstName: "Quoc", Phone: "call me"}},
+		{"bad email", &models.VCard{FirstName: "Quoc", Email: "quoc"}},
+		{"bad website", &models.VCard{Organization: "Acme", Website: "javascript:alert(1)"}},
+		{"long field", &models.VCard{FirstName: strings.Repeat("q", 201)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.CreateVCardLink("", tt.card, nil, 1, nil, 6); err != service.ErrInvalidVCard {
+				t.Errorf("Expected ErrInvalidVCard, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLinkService_Redirect_VCard(t *testing.T) {
+	svc, _, clickRepo := setupLinkService()
+
+	link, err := svc.CreateVCardLink("", testVCard(), nil, 1, nil, 6)
+	if err != nil {
+		t.Fatalf("CreateVCardLink returned error: %v", err)
+	}
+
+	target, err := svc.Redirect(link.ShortCode, &service.ClickInfo{IPAddress: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("Redirect returned error: %v", err)
+	}
+	if want := "/" + link.ShortCode + "/vcard"; target != want {
+		t.Errorf("Redirect = %s, want %s", target, want)
+	}
+	waitForClicks(t, clickRepo, 1)
+
+	card, err := svc.GetVCard("", link.ShortCode)
+	if err != nil {
+		t.Fatalf("GetVCard returned error: %v", err)
+	}
+	text := service.VCardText(card)
+	if !strings.Contains(text, "FN:Quoc Bui\r\n") {
+		t.Errorf("vCard text misses the name:\n%s", text)
+	}
+	if name := service.VCardFileName(card); name != "Quoc-Bui.vcf" {
+		t.Errorf("VCardFileName = %s, want Quoc-Bui.vcf", name)
+	}
+}
+
+func TestLinkService_GetVCard_Rules(t *testing.T) {
+	svc, linkRepo, _ := setupLinkService()
+
+	link, err := svc.CreateVCardLink("", testVCard(), nil, 1, nil, 6)
+	if err != nil {
+		t.Fatalf("CreateVCardLink returned error: %v", err)
+	}
+	linkRepo.Links["plain"] = &models.Link{ID: 99, ShortCode: "plain", Type: models.LinkTypeURL, OriginalURL: "https://example.com"}
+
+	if _, err := svc.GetVCard("", "plain"); err != service.ErrLinkNotFound {
+		t.Errorf("Expected ErrLinkNotFound for a URL link, got %v", err)
+	}
+
+	link.Paused = true
+	if _, err := svc.GetVCard("", link.ShortCode); err != service.ErrLinkPaused {
+		t.Errorf("Expected ErrLinkPaused, got %v", err)
+	}
+}
+
+func TestLinkService_UpdateVCard(t *testing.T) {
+	svc, _, _ := setupLinkService()
+
+	link, err := svc.CreateVCardLink("", testVCard(), nil, 1, nil, 6)
+	if err != nil {
+		t.Fatalf("CreateVCardLink returned error: %v", err)
+	}
+
+	if _, err := svc.UpdateVCard("", link.ShortCode, 2, &models.VCard{FirstName: "Mallory"}); err != service.ErrUnauthorized {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+
+	updated, err := svc.UpdateVCard("", link.ShortCode, 1, &models.VCard{Organization: "Acme"})
+	if err != nil {
+		t.Fatalf("UpdateVCard returned error: %v", err)
+	}
+	if updated.LinkID != link.ID {
+		t.Errorf("LinkID = %d, want %d", updated.LinkID, link.ID)
+	}
+
+	card, err := svc.GetOwnedVCard("", link.ShortCode, 1)
+	if err != nil {
+		t.Fatalf("GetOwnedVCard returned error: %v", err)
+	}
+	if card.Organization != "Acme" || card.FirstName != "" {
+		t.Errorf("card was not replaced: %+v", card)
+	}
+}
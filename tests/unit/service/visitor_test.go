@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"quocbui.dev/m/internal/models"
+	"quocbui.dev/m/internal/service"
+	"quocbui.dev/m/tests/mocks"
+)
+
+func TestVisitorHasher_Hash(t *testing.T) {
+	salts := mocks.NewMockVisitorSaltRepository()
+	hasher := service.NewVisitorHasher(salts)
+	morning := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	first, err := hasher.Hash("203.0.113.7", "Mozilla/5.0", morning)
+	if err != nil {
+		t.Fatalf("Hash returned error: %v", err)
+	}
+	if len(first) != 32 || strings.Contains(first, "203") {
+		t.Errorf("hash %q should be 32 hex digits", first)
+	}
+
+	again, _ := hasher.Hash("203.0.113.7", "Mozilla/5.0", morning.Add(10*time.Hour))
+	if again != first {
+		t.Errorf("same visitor on the same day hashed to %s and %s", first, again)
+	}
+	// Another instance shares the salt of the day
+	other, _ := service.NewVisitorHasher(salts).Hash("203.0.113.7", "Mozilla/5.0", morning)
+	if other != first {
+		t.Errorf("instances hashed the same visitor to %s and %s", first, other)
+	}
+	if otherAgent, _ := hasher.Hash("203.0.113.7", "curl/8.0", morning); otherAgent == first {
+		t.Error("different user agents should hash differently")
+	}
+
+	nextDay, _ := hasher.Hash("203.0.113.7", "Mozilla/5.0", morning.AddDate(0, 0, 1))
+	if nextDay == first {
+		t.Error("hashes should rotate daily")
+	}
+	if len(salts.Salts) != 1 {
+		t.Errorf("%d salts kept, want only today's", len(salts.Salts))
+	}
+}
+
+func TestLinkService_Redirect_RecordsVisitorHash(t *testing.T) {
+	linkRepo := mocks.NewMockLinkRepository()
+	clickRepo := mocks.NewMockClickRepository()
+	authService := service.NewAuthService(mocks.NewMockUserRepository(), "test-secret", 24)
+	svc := service.NewLinkService(linkRepo, clickRepo, mocks.NewMockTransactionManager(), service.NewGeoIPService(), authService, nil,
+		service.LinkServiceConfig{Visitors: service.NewVisitorHasher(mocks.NewMockVisitorSaltRepository())})
+
+	linkRepo.Links["abc123"] = &models.Link{ID: 1, ShortCode: "abc123", OriginalURL: "https://example.com"}
+	for i := 0; i < 2; i++ {
+		if _, err := svc.Redirect("abc123", &service.ClickInfo{IPAddress: "203.0.113.7", UserAgent: "Mozilla/5.0"}); err != nil {
+			t.Fatalf("Redirect returned error: %v", err)
+		}
+	}
+
+	clicks := waitForClicks(t, clickRepo, 2)
+	if clicks[0].VisitorHash == "" || clicks[0].VisitorHash != clicks[1].VisitorHash {
+		t.Errorf("visitor hashes = %q, %q, want the same hash twice", clicks[0].VisitorHash, clicks[1].VisitorHash)
+	}
+}
+
+func TestAnalyticsService_GetAnalyticsSummary_Visitors(t *testing.T) {
+	svc, clickRepo, linkRepo := setupAnalyticsService()
+	hasher := service.NewVisitorHasher(mocks.NewMockVisitorSaltRepository())
+
+	userID := uint(1)
+	link := &models.Link{ID: 1, ShortCode: "test", UserID: &userID}
+	linkRepo.Links["test"] = link
+	now := time.Now()
+	for i, ip := range []string{"203.0.113.1", "203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+		hash, err := hasher.Hash(ip, "Mozilla/5.0", now)
+		if err != nil {
+			t.Fatalf("Hash returned error: %v", err)
+		}
+		clickRepo.Clicks = append(clickRepo.Clicks, &models.Click{ID: uint(i + 1), LinkID: 1, Country: "Vietnam", VisitorHash: hash, ClickedAt: now})
+	}
+	// Clicks recorded before visitor hashes are not counted as visitors
+	clickRepo.Clicks = append(clickRepo.Clicks, &models.Click{ID: 5, LinkID: 1, ClickedAt: now})
+
+	summary, err := svc.GetAnalyticsSummary(1, userID, service.TimeRange{}, false)
+	if err != nil {
+		t.Fatalf("GetAnalyticsSummary returned error: %v", err)
+	}
+	if summary.TotalClicks != 5 || summary.Visitors.Total != 3 || summary.Visitors.Approximate {
+		t.Errorf("clicks = %d, visitors = %+v, want 5 clicks by 3 exactly counted visitors", summary.TotalClicks, summary.Visitors)
+	}
+	if summary.Visitors.Countries["Vietnam"] != 3 {
+		t.Errorf("visitors from Vietnam = %d, want 3", summary.Visitors.Countries["Vietnam"])
+	}
+
+	link.ClickCount = service.ExactVisitorCountLimit + 1
+	summary, err = svc.GetAnalyticsSummary(1, userID, service.TimeRange{}, false)
+	if err != nil {
+		t.Fatalf("GetAnalyticsSummary returned error: %v", err)
+	}
+	if !summary.Visitors.Approximate || summary.Visitors.Total != 3 {
+		t.Errorf("visitors = %+v, want an estimate of 3", summary.Visitors)
+	}
+
+	series, err := svc.GetTimeSeries(1, userID, service.IntervalDay, service.TimeRange{})
+	if err != nil {
+		t.Fatalf("GetTimeSeries returned error: %v", err)
+	}
+	if last := series.Points[len(series.Points)-1]; last.Clicks != 5 || last.Visitors != 3 {
+		t.Errorf("today = %d clicks by %d visitors, want 5 by 3", last.Clicks, last.Visitors)
+	}
+}
@@ -0,0 +1,45 @@
+package utils_test
+
+import (
+	"testing"
+
+	"quocbui.dev/m/pkg/utils"
+)
+
+func TestIsBotUserAgent(t *testing.T) {
+	tests := []struct {
+		name     string
+		ua       string
+		expected bool
+	}{
+		{"slack", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true},
+		{"facebook", "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true},
+		{"telegram", "TelegramBot (like TwitterBot)", true},
+		{"discord", "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", true},
+		{"whatsapp", "WhatsApp/2.23.20.0 A", true},
+		{"curl", "curl/8.4.0", true},
+		{"chrome", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", false},
+		{"iphone", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.IsBotUserAgent(tt.ua); got != tt.expected {
+				t.Errorf("IsBotUserAgent(%q) = %v, want %v", tt.ua, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseUserAgent_LinkPreview(t *testing.T) {
+	info := utils.ParseUserAgent("facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)")
+	if !info.IsBot || info.Device != "Bot" {
+		t.Errorf("IsBot = %v, Device = %s, want a bot", info.IsBot, info.Device)
+	}
+
+	info = utils.ParseUserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	if info.IsBot {
+		t.Error("Chrome should not be a bot")
+	}
+}
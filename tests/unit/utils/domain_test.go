@@ -0,0 +1,86 @@
+package utils_test
+
+import (
+	"net"
+	"testing"
+
+	"quocbui.dev/m/pkg/utils"
+)
+
+func TestHostMatchesAny(t *testing.T) {
+	domains := []string{"short.test", "localhost:8080"}
+
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{"short.test", true},
+		{"SHORT.test", true},
+		{"www.short.test", true},
+		{"short.test:443", true},
+		{"localhost", true},
+		{"go.short.test", false},
+		{"example.com", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := utils.HostMatchesAny(tt.host, domains); got != tt.expected {
+			t.Errorf("HostMatchesAny(%q) = %v, want %v", tt.host, got, tt.expected)
+		}
+	}
+}
+
+func TestValidateHostname(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{"go.example.com", true},
+		{"example.io", true},
+		{"my-links.example.co.uk", true},
+		{"localhost", false},
+		{"example.com:8080", false},
+		{"-bad.example.com", false},
+		{"Upper.example.com", false},
+		{"under_score.com", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := utils.ValidateHostname(tt.host); got != tt.expected {
+			t.Errorf("ValidateHostname(%q) = %v, want %v", tt.host, got, tt.expected)
+		}
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := utils.IsPublicIP(net.ParseIP(tt.ip)); got != tt.expected {
+				t.Errorf("IsPublicIP(%q) = %v, want %v", tt.ip, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package utils_test
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"testing"
+
+	"quocbui.dev/m/pkg/utils"
+)
+
+func hashOf(i int) uint64 {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(i))
+	sum := sha256.Sum256(buf[:])
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+func TestHyperLogLog_Estimate(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 100000} {
+		hll := utils.NewHyperLogLog()
+		for i := 0; i < n; i++ {
+			hll.Add(hashOf(i))
+			hll.Add(hashOf(i)) // duplicates don't count
+		}
+		got := hll.Estimate()
+		if diff := math.Abs(float64(got - int64(n))); diff > math.Max(1, 0.03*float64(n)) {
+			t.Errorf("Estimate() = %d, want %d within 3%%", got, n)
+		}
+	}
+}
+
+func TestHyperLogLog_Merge(t *testing.T) {
+	added, merged := utils.NewHyperLogLog(), utils.NewHyperLogLog()
+	for i := 0; i < 5000; i++ {
+		hash := hashOf(i)
+		added.Add(hash)
+		// What the Postgres query computes from the hex digits of the hash
+		register := int(hash >> (64 - utils.HLLPrecision))
+		rank := bits.LeadingZeros64(hash<<utils.HLLPrecision) + 1
+		if rank > utils.HLLMaxRank {
+			rank = utils.HLLMaxRank
+		}
+		merged.Merge(register, rank)
+	}
+	if added.Estimate() != merged.Estimate() {
+		t.Errorf("merged registers estimate %d, added hashes %d", merged.Estimate(), added.Estimate())
+	}
+}
@@ -131,3 +131,56 @@ func TestGenerateQRCode_MultipleGeneration(t *testing.T) {
 		}
 	}
 }
+
+func TestParseHexColor(t *testing.T) {
+	c, err := utils.ParseHexColor("#1A237e")
+	if err != nil {
+		t.Fatalf("ParseHexColor returned error: %v", err)
+	}
+	if c.R != 0x1a || c.G != 0x23 || c.B != 0x7e || c.A != 0xff {
+		t.Errorf("ParseHexColor = %+v", c)
+	}
+	if utils.HexColor(c) != "#1a237e" {
+		t.Errorf("HexColor = %s, want #1a237e", utils.HexColor(c))
+	}
+
+	for _, invalid := range []string{"", "1a237e", "#123", "#12345g", "#1234567"} {
+		if _, err := utils.ParseHexColor(invalid); err == nil {
+			t.Errorf("ParseHexColor(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	black, _ := utils.ParseHexColor("#000000")
+	white, _ := utils.ParseHexColor("#ffffff")
+	gray, _ := utils.ParseHexColor("#777777")
+
+	if ratio := utils.ContrastRatio(black, white); ratio < 20.9 || ratio > 21.1 {
+		t.Errorf("ContrastRatio(black, white) = %.2f, want 21", ratio)
+	}
+	if utils.ContrastRatio(white, black) != utils.ContrastRatio(black, white) {
+		t.Error("ContrastRatio should be symmetric")
+	}
+	if ratio := utils.ContrastRatio(gray, gray); ratio != 1 {
+		t.Errorf("ContrastRatio(gray, gray) = %.2f, want 1", ratio)
+	}
+}
+
+func TestQRBitmap_CorrectionLevels(t *testing.T) {
+	low, err := utils.QRBitmap("https://example.com/abc123", utils.QRCorrectionLow)
+	if err != nil {
+		t.Fatalf("QRBitmap returned error: %v", err)
+	}
+	high, err := utils.QRBitmap("https://example.com/abc123", utils.QRCorrectionHighest)
+	if err != nil {
+		t.Fatalf("QRBitmap returned error: %v", err)
+	}
+	if len(high) <= len(low) {
+		t.Errorf("level H should need more modules than L: %d <= %d", len(high), len(low))
+	}
+
+	if _, err := utils.QRBitmap("x", "Z"); err == nil {
+		t.Error("QRBitmap should reject unknown levels")
+	}
+}
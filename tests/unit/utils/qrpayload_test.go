@@ -0,0 +1,97 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"quocbui.dev/m/pkg/utils"
+)
+
+func TestWiFiPayload_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  utils.WiFiPayload
+		expected string
+	}{
+		{"wpa", utils.WiFiPayload{SSID: "Office", Password: "secret123", Security: utils.WiFiSecurityWPA}, "WIFI:T:WPA;S:Office;P:secret123;;"},
+		{"open network", utils.WiFiPayload{SSID: "Guest", Password: "ignored", Security: utils.WiFiSecurityOpen}, "WIFI:T:nopass;S:Guest;;"},
+		{"hidden", utils.WiFiPayload{SSID: "Lab", Password: "pw", Security: utils.WiFiSecurityWEP, Hidden: true}, "WIFI:T:WEP;S:Lab;P:pw;H:true;;"},
+		{"escaped", utils.WiFiPayload{SSID: `My;Net,"1"`, Password: `a:b\c`, Security: utils.WiFiSecurityWPA}, `WIFI:T:WPA;S:My\;Net\,\"1\";P:a\:b\\c;;`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.payload.String(); got != tt.expected {
+				t.Errorf("String() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEmailPayload_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  utils.EmailPayload
+		expected string
+	}{
+		{"address only", utils.EmailPayload{To: "a@example.com"}, "mailto:a@example.com"},
+		{"subject and body", utils.EmailPayload{To: "a@example.com", Subject: "Hi there", Body: "x&y=z"}, "mailto:a@example.com?subject=Hi%20there&body=x%26y%3Dz"},
+		{"body only", utils.EmailPayload{To: "a@example.com", Body: "1+1"}, "mailto:a@example.com?body=1%2B1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.payload.String(); got != tt.expected {
+				t.Errorf("String() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSMSPayload_String(t *testing.T) {
+	got := utils.SMSPayload{Phone: "+84901234567", Message: "JOIN: now"}.String()
+	if got != "SMSTO:+84901234567:JOIN: now" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestVCard_String(t *testing.T) {
+	card := utils.VCard{
+		FirstName:    "Quoc",
+		LastName:     "Bui",
+		Organization: "Acme, Inc.",
+		Phone:        "+84901234567",
+		Email:        "quoc@example.com",
+		Note:         "line one\nline two",
+	}
+	got := card.String()
+
+	for _, want := range []string{
+		"BEGIN:VCARD\r\nVERSION:3.0\r\n",
+		"N:Bui;Quoc;;;\r\n",
+		"FN:Quoc Bui\r\n",
+		"ORG:Acme\\, Inc.\r\n",
+		"TEL;TYPE=CELL:+84901234567\r\n",
+		"EMAIL:quoc@example.com\r\n",
+		"NOTE:line one\\nline two\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("vCard missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "TITLE") || strings.Contains(got, "ADR") {
+		t.Errorf("vCard should omit empty fields:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "END:VCARD\r\n") {
+		t.Errorf("vCard should end with END:VCARD")
+	}
+}
+
+func TestVCard_FullName(t *testing.T) {
+	if got := (utils.VCard{FirstName: "Quoc"}).FullName(); got != "Quoc" {
+		t.Errorf("FullName() = %q, want Quoc", got)
+	}
+	if got := (utils.VCard{Organization: "Acme"}).FullName(); got != "Acme" {
+		t.Errorf("FullName() = %q, want Acme", got)
+	}
+}
@@ -0,0 +1,83 @@
+package utils_test
+
+import (
+	"testing"
+
+	"quocbui.dev/m/pkg/utils"
+)
+
+func TestReservedWords_IsReserved(t *testing.T) {
+	reserved := utils.NewReservedWords([]string{"admin"}, []string{"badword"})
+	reserved.ReserveRoutes("/health", "/swagger/*any", "/api/v1/shorten", "/:code", "/")
+
+	tests := []struct {
+		code     string
+		expected bool
+	}{
+		{"admin", true},
+		{"ADMIN", true},
+		{"health", true},
+		{"swagger", true},
+		{"api", true},
+		{"Api", true},
+		{"x1BadWordz", true},
+		{"badword", true},
+		{"administrator", false},
+		{"apis", false},
+		{"v1", false},
+		{"shorten", false},
+		{"abc123", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := reserved.IsReserved(tt.code); got != tt.expected {
+				t.Errorf("IsReserved(%q) = %v, want %v", tt.code, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReservedWords_IsReservedAlias(t *testing.T) {
+	reserved := utils.NewReservedWords([]string{"admin"}, []string{"ass", "cunt", "cock"})
+	reserved.ReserveRoutes("/health")
+
+	tests := []struct {
+		alias    string
+		expected bool
+	}{
+		{"admin", true},
+		{"Health", true},
+		{"ass", true},
+		{"my-ass", true},
+		{"COCK_fight", true},
+		{"summer-cunt-2024", true},
+		{"class", false},
+		{"assess", false},
+		{"scunthorpe", false},
+		{"peacock-farm", false},
+		{"admin-panel", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			if got := reserved.IsReservedAlias(tt.alias); got != tt.expected {
+				t.Errorf("IsReservedAlias(%q) = %v, want %v", tt.alias, got, tt.expected)
+			}
+		})
+	}
+
+	// Generated codes are still checked for blocked words anywhere
+	for _, code := range []string{"class", "scunthorpe"} {
+		if !reserved.IsReserved(code) {
+			t.Errorf("IsReserved(%q) = false, want true", code)
+		}
+	}
+}
+
+func TestReservedWords_NilRegistry(t *testing.T) {
+	var reserved *utils.ReservedWords
+	if reserved.IsReserved("api") || reserved.IsReservedAlias("api") {
+		t.Error("nil registry should not reserve anything")
+	}
+}
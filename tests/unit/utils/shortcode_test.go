@@ -1,6 +1,7 @@
 package utils_test
 
 import (
+	"math/big"
 	"regexp"
 	"testing"
 
@@ -67,3 +68,105 @@ func TestGenerateShortCode_Uniqueness(t *testing.T) {
 		t.Errorf("Too many duplicates: got %d unique codes out of %d iterations", uniqueCount, iterations)
 	}
 }
+
+func TestGenerateShortCodeFromAlphabet(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code, err := utils.GenerateShortCodeFromAlphabet("abc", 8)
+		if err != nil {
+			t.Fatalf("GenerateShortCodeFromAlphabet returned error: %v", err)
+		}
+		if !regexp.MustCompile(`^[abc]{8}$`).MatchString(code) {
+			t.Errorf("GenerateShortCodeFromAlphabet returned invalid code: %s", code)
+		}
+	}
+}
+
+func TestValidateAlphabet(t *testing.T) {
+	tests := []struct {
+		alphabet string
+		expected bool
+	}{
+		{"0123456789abcdef", true},
+		{"ab", true},
+		{"a", false},
+		{"abca", false},
+		{"ab/c", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := utils.ValidateAlphabet(tt.alphabet); got != tt.expected {
+			t.Errorf("ValidateAlphabet(%q) = %v, want %v", tt.alphabet, got, tt.expected)
+		}
+	}
+}
+
+func TestEncodeBaseN(t *testing.T) {
+	tests := []struct {
+		n         int64
+		alphabet  string
+		minLength int
+		expected  string
+	}{
+		{0, "01", 0, "0"},
+		{5, "01", 0, "101"},
+		{5, "01", 6, "000101"},
+		{61, "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz", 0, "z"},
+		{62, "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz", 0, "10"},
+	}
+
+	for _, tt := range tests {
+		if got := utils.EncodeBaseN(big.NewInt(tt.n), tt.alphabet, tt.minLength); got != tt.expected {
+			t.Errorf("EncodeBaseN(%d, %q, %d) = %s, want %s", tt.n, tt.alphabet, tt.minLength, got, tt.expected)
+		}
+	}
+}
+
+func TestShuffleAlphabet_Deterministic(t *testing.T) {
+	alphabet := "abcdefghijklmnopqrstuvwxyz"
+
+	first := utils.ShuffleAlphabet(alphabet, "salt")
+	if first != utils.ShuffleAlphabet(alphabet, "salt") {
+		t.Error("ShuffleAlphabet should be deterministic for the same salt")
+	}
+	if first == utils.ShuffleAlphabet(alphabet, "other") {
+		t.Error("ShuffleAlphabet should differ for different salts")
+	}
+	if !utils.ValidateAlphabet(first) || len(first) != len(alphabet) {
+		t.Errorf("ShuffleAlphabet returned invalid permutation: %s", first)
+	}
+}
+
+func TestObfuscateID_UniqueAndMinLength(t *testing.T) {
+	alphabet := "0123456789abcdef"
+	codes := make(map[string]uint64)
+
+	for id := uint64(1); id <= 5000; id++ {
+		code := utils.ObfuscateID(id, alphabet, "salt", 3)
+		if len(code) < 3 {
+			t.Fatalf("ObfuscateID(%d) = %s, shorter than min length", id, code)
+		}
+		if prev, ok := codes[code]; ok {
+			t.Fatalf("ObfuscateID(%d) = %s collides with id %d", id, code, prev)
+		}
+		codes[code] = id
+	}
+
+	if utils.ObfuscateID(1, alphabet, "salt", 3) == utils.EncodeBaseN(big.NewInt(1), alphabet, 3) {
+		t.Error("ObfuscateID should not return the plain sequential encoding")
+	}
+}
+
+func TestGenerateReadableCode(t *testing.T) {
+	pattern := regexp.MustCompile(`^[a-z]+-[a-z]+-[0-9]{2}$`)
+
+	for i := 0; i < 100; i++ {
+		code, err := utils.GenerateReadableCode()
+		if err != nil {
+			t.Fatalf("GenerateReadableCode returned error: %v", err)
+		}
+		if !pattern.MatchString(code) || !utils.ValidateAlias(code) {
+			t.Errorf("GenerateReadableCode returned invalid code: %s", code)
+		}
+	}
+}
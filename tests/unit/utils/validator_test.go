@@ -1,6 +1,7 @@
 package utils_test
 
 import (
+	"strings"
 	"testing"
 
 	"quocbui.dev/m/pkg/utils"
@@ -96,3 +97,52 @@ func TestValidateAlias(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateEmail(t *testing.T) {
+	tests := []struct {
+		email    string
+		expected bool
+	}{
+		{"user@example.com", true},
+		{"first.last+tag@sub.example.com", true},
+		{"", false},
+		{"not-an-email", false},
+		{"User <user@example.com>", false},
+		{"<user@example.com>", false},
+		{"user@", false},
+		{strings.Repeat("a", 250) + "@b.co", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			if got := utils.ValidateEmail(tt.email); got != tt.expected {
+				t.Errorf("ValidateEmail(%q) = %v, want %v", tt.email, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone    string
+		expected string
+		valid    bool
+	}{
+		{"+84 90 123 4567", "+84901234567", true},
+		{"(555) 123-4567", "5551234567", true},
+		{"555.123.4567", "5551234567", true},
+		{"12", "12", false},
+		{"+1234567890123456", "+1234567890123456", false},
+		{"call me", "callme", false},
+		{"++123", "++123", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			got, ok := utils.NormalizePhone(tt.phone)
+			if ok != tt.valid || (ok && got != tt.expected) {
+				t.Errorf("NormalizePhone(%q) = %q, %v, want %q, %v", tt.phone, got, ok, tt.expected, tt.valid)
+			}
+		})
+	}
+}